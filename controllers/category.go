@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"backend/models"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CategoryInput struct {
+	Name string `json:"name" binding:"required"` // 表示名
+	Slug string `json:"slug" binding:"required"` // 識別子（英小文字・数字・ハイフン）
+}
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// GetCategories カテゴリ一覧を取得する
+func GetCategories(c *gin.Context) {
+	var categories []models.Category
+	if err := models.DB.Order("name asc").Find(&categories).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "カテゴリの取得に失敗しました"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": categories})
+}
+
+// CreateCategory カテゴリを作成する
+func CreateCategory(c *gin.Context) {
+	var input CategoryInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !slugPattern.MatchString(input.Slug) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "スラッグは英小文字・数字・ハイフンで指定してください"})
+		return
+	}
+
+	category := models.Category{Name: input.Name, Slug: input.Slug}
+	if err := models.DB.Create(&category).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "カテゴリの作成に失敗しました"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": category})
+}
+
+// UpdateCategory カテゴリを更新する
+func UpdateCategory(c *gin.Context) {
+	var category models.Category
+	if err := models.DB.First(&category, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "カテゴリが見つかりません"})
+		return
+	}
+
+	var input CategoryInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !slugPattern.MatchString(input.Slug) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "スラッグは英小文字・数字・ハイフンで指定してください"})
+		return
+	}
+
+	err := models.DB.Model(&category).Updates(models.Category{Name: input.Name, Slug: input.Slug}).Error
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "カテゴリの更新に失敗しました"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": category})
+}
+
+// DeleteCategory カテゴリを削除する（属していたプランはカテゴリなしになる）
+func DeleteCategory(c *gin.Context) {
+	var category models.Category
+	if err := models.DB.First(&category, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "カテゴリが見つかりません"})
+		return
+	}
+
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		// ゴミ箱のプランも外部キーで参照しているため、削除済みのプランも含めて外す
+		if err := tx.Unscoped().Model(&models.TravelPlan{}).Where("category_id = ?", category.ID).
+			Update("category_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.PlanTemplate{}).Where("category_id = ?", category.ID).
+			Update("category_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&category).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "カテゴリの削除に失敗しました"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": "カテゴリが削除されました"})
+}
+
+// validCategory カテゴリIDが存在するかどうかを返す（未指定の場合はtrue）
+func validCategory(id *uint) bool {
+	if id == nil {
+		return true
+	}
+	var count int64
+	models.DB.Model(&models.Category{}).Where("id = ?", *id).Count(&count)
+	return count > 0
+}
@@ -0,0 +1,251 @@
+package controllers
+
+import (
+	"backend/models"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxCommentLength コメント本文の最大文字数
+const maxCommentLength = 2000
+
+// CommentInput コメントの投稿・編集の入力
+type CommentInput struct {
+	Body     string  `json:"body" binding:"required"` // 本文（@ユーザー名 でメンバーにメンションできる）
+	ItemID   *string `json:"itemId"`                  // アイテムへのコメントの場合のアイテムID
+	ParentID *uint   `json:"parentId"`                // 返信先のコメントID
+}
+
+// GetPlanComments プランのコメントをスレッドごとに取得する
+// クエリパラメータ itemId を指定した場合はそのアイテムへのコメントのみ返す
+func GetPlanComments(c *gin.Context) {
+	plan, ok := findViewablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	// 返信が残っている削除済みのコメントもスレッドの表示に必要なため含める
+	query := models.DB.Unscoped().Preload("Mentions").
+		Where("plan_id = ?", plan.ID).Order("created_at, id")
+	if itemId := c.Query("itemId"); itemId != "" {
+		query = query.Where("item_id = ?", itemId)
+	}
+
+	var comments []models.Comment
+	if err := query.Find(&comments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "コメントの取得に失敗しました"})
+		return
+	}
+	if err := fillCommentUsernames(comments); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "コメントの取得に失敗しました"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": models.CommentThreads(comments)})
+}
+
+// CreatePlanComment プランまたはアイテムにコメントを投稿する
+// プランを閲覧できるユーザーであれば投稿できる
+func CreatePlanComment(c *gin.Context) {
+	var input CommentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, ok := findViewablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	body, ok := validCommentBody(c, input.Body)
+	if !ok {
+		return
+	}
+
+	comment := models.Comment{PlanID: plan.ID, AuthorID: currentUserID(c), Body: body}
+
+	// 返信の場合は返信先と同じプラン・アイテムへのコメントとする
+	if input.ParentID != nil {
+		var parent models.Comment
+		if err := models.DB.First(&parent, "id = ? AND plan_id = ?", *input.ParentID, plan.ID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "返信先のコメントが見つかりません"})
+			return
+		}
+		if input.ItemID != nil && (parent.ItemID == nil || *parent.ItemID != *input.ItemID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "返信先のコメントと異なるアイテムは指定できません"})
+			return
+		}
+		comment.ParentID = &parent.ID
+		comment.ItemID = parent.ItemID
+	} else if input.ItemID != nil {
+		if _, ok := findPlanItem(c, plan, *input.ItemID); !ok {
+			return
+		}
+		comment.ItemID = input.ItemID
+	}
+
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		mentions, err := models.ResolveMentions(tx, plan, body)
+		if err != nil {
+			return err
+		}
+		comment.Mentions = mentions
+		return tx.Create(&comment).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "コメントの投稿に失敗しました"})
+		return
+	}
+
+	writeComment(c, http.StatusCreated, &comment)
+}
+
+// UpdatePlanComment 自分のコメントの本文を編集する
+func UpdatePlanComment(c *gin.Context) {
+	var input struct {
+		Body string `json:"body" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, ok := findViewablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	comment, ok := findComment(c, plan, c.Param("commentId"))
+	if !ok {
+		return
+	}
+	if comment.AuthorID != currentUserID(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "このコメントを編集する権限がありません"})
+		return
+	}
+
+	body, ok := validCommentBody(c, input.Body)
+	if !ok {
+		return
+	}
+
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		mentions, err := models.ResolveMentions(tx, plan, body)
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("comment_id = ?", comment.ID).Delete(&models.CommentMention{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(comment).Updates(map[string]interface{}{"body": body, "edited": true}).Error; err != nil {
+			return err
+		}
+		comment.Mentions = mentions
+		for i := range comment.Mentions {
+			comment.Mentions[i].CommentID = comment.ID
+		}
+		if len(comment.Mentions) == 0 {
+			return nil
+		}
+		return tx.Create(&comment.Mentions).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "コメントの更新に失敗しました"})
+		return
+	}
+
+	writeComment(c, http.StatusOK, comment)
+}
+
+// DeletePlanComment コメントを削除する（投稿者、またはプランの作成者のみ）
+// 返信が残っている場合、スレッドでは本文を伏せて表示する
+func DeletePlanComment(c *gin.Context) {
+	plan, ok := findViewablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	comment, ok := findComment(c, plan, c.Param("commentId"))
+	if !ok {
+		return
+	}
+	userId := currentUserID(c)
+	if comment.AuthorID != userId && plan.CreatorID != userId {
+		c.JSON(http.StatusForbidden, gin.H{"error": "このコメントを削除する権限がありません"})
+		return
+	}
+
+	if err := models.DB.Delete(comment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "コメントの削除に失敗しました"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": "コメントが削除されました"})
+}
+
+// validCommentBody コメント本文の前後の空白を取り除いて検証する
+// 失敗した場合はエラーレスポンスを書き込み、falseを返す
+func validCommentBody(c *gin.Context, body string) (string, bool) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "コメントを入力してください"})
+		return "", false
+	}
+	if utf8.RuneCountInString(body) > maxCommentLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "コメントは2000文字以内で入力してください"})
+		return "", false
+	}
+	return body, true
+}
+
+// findComment プランのコメントを取得する
+// 失敗した場合はエラーレスポンスを書き込み、falseを返す
+func findComment(c *gin.Context, plan *models.TravelPlan, commentID string) (*models.Comment, bool) {
+	var comment models.Comment
+	if err := models.DB.First(&comment, "id = ? AND plan_id = ?", commentID, plan.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "コメントが見つかりません"})
+		return nil, false
+	}
+	return &comment, true
+}
+
+// writeComment 投稿者とメンションのユーザー名を補ってコメントを返す
+func writeComment(c *gin.Context, status int, comment *models.Comment) {
+	comments := []models.Comment{*comment}
+	if err := fillCommentUsernames(comments); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "コメントの取得に失敗しました"})
+		return
+	}
+	comments[0].Replies = []*models.Comment{}
+	c.JSON(status, gin.H{"data": comments[0]})
+}
+
+// fillCommentUsernames コメントの投稿者とメンションされたユーザーのユーザー名を設定する
+func fillCommentUsernames(comments []models.Comment) error {
+	ids := []uint{}
+	for _, comment := range comments {
+		ids = append(ids, comment.AuthorID)
+		for _, m := range comment.Mentions {
+			ids = append(ids, m.UserID)
+		}
+	}
+	names, err := models.Usernames(models.DB, ids)
+	if err != nil {
+		return err
+	}
+	for i := range comments {
+		comments[i].Author = names[comments[i].AuthorID]
+		if comments[i].Mentions == nil {
+			comments[i].Mentions = []models.CommentMention{}
+		}
+		for j := range comments[i].Mentions {
+			comments[i].Mentions[j].Username = names[comments[i].Mentions[j].UserID]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,479 @@
+package controllers
+
+import (
+	"backend/models"
+	"backend/utils/realtime"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// アイテムのCSVの列（1行目は列名の見出し行、列名はPlanItemInputのJSONのキーと同じ）
+//
+//	id          アイテムID（新しく追加する行は空欄、空欄でない場合は既存のアイテムを更新する）
+//	type        種類（"visit"・"transport"・"meal"・"lodging"など、空欄の場合はタイトルと場所から推定する）
+//	title       タイトル（必須）
+//	description 詳細説明
+//	location    場所
+//	latitude    緯度（-90〜90、経度と両方とも指定するか両方とも空欄にする）
+//	longitude   経度（-180〜180）
+//	startTime   開始時間（必須、"2006-01-02 15:04" 形式の現地時刻、またはRFC 3339形式）
+//	endTime     終了時間（開始時間と同じ形式）
+//	timeZone    IANAタイムゾーン（空欄の場合はプランのタイムゾーン、現地時刻はこのタイムゾーンで解釈する）
+//	duration    所要時間（分、空欄の場合は開始・終了時間から求める）
+//	cost        費用（円）
+//	notes       メモ
+//	order       順序（空欄の場合はCSVの行の順）
+//
+// 取り込み時は列の順序を問わず、title・startTime以外の列は省略できる
+// 既存のアイテムを更新する場合、省略した列の値は変更しない
+var itemCSVColumns = []string{
+	"id", "type", "title", "description", "location", "latitude", "longitude",
+	"startTime", "endTime", "timeZone", "duration", "cost", "notes", "order",
+}
+
+const (
+	csvTimeLayout = "2006-01-02 15:04" // CSVに出力する現地時刻の形式
+	maxImportRows = 1000               // 一度に取り込めるアイテムの数
+)
+
+// csvLocalTimeLayouts 取り込み時に受け付ける現地時刻の形式（表計算ソフトの表記を含む）
+var csvLocalTimeLayouts = []string{
+	"2006-01-02 15:04", "2006-01-02 15:04:05", "2006-01-02T15:04", "2006-01-02T15:04:05",
+	"2006/1/2 15:04", "2006/1/2 15:04:05",
+}
+
+// utf8BOM 表計算ソフトでUTF-8として開けるようにCSVの先頭に付けるBOM
+const utf8BOM = "\ufeff"
+
+// csvRowError CSVの行ごとの誤り
+type csvRowError struct {
+	Line    int    `json:"line"`             // 行番号（見出し行が1行目）
+	Column  string `json:"column,omitempty"` // 列名
+	Message string `json:"message"`          // 誤りの内容
+}
+
+// csvItemRow CSVの1行から組み立てたアイテム
+type csvItemRow struct {
+	Line   int              // 行番号
+	Item   *models.PlanItem // アイテム（更新する場合はIDが設定されている）
+	Fields []string         // 更新する場合に変更するフィールド（CSVにある列のみ）
+}
+
+// itemCSVFields CSVの列と、既存のアイテムを更新するときに変更するフィールドの対応
+var itemCSVFields = map[string][]string{
+	"type":        {"Type"},
+	"title":       {"Title"},
+	"description": {"Description"},
+	"location":    {"Location"},
+	"latitude":    {"Latitude"},
+	"longitude":   {"Longitude"},
+	"startTime":   {"StartTime"},
+	// 所要時間の列がない場合は開始・終了時間から求め直す
+	"endTime":  {"EndTime", "Duration"},
+	"timeZone": {"TimeZone"},
+	"duration": {"Duration"},
+	"cost":     {"Cost"},
+	"notes":    {"Notes"},
+	"order":    {"Order"},
+}
+
+// ExportPlanCSV プランのアイテムをCSV形式で出力する（列の構成は itemCSVColumns を参照）
+func ExportPlanCSV(c *gin.Context) {
+	plan, ok := findViewablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	// 変更がなければ304を返す
+	if notModified(c, planViewETag(plan)) {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(utf8BOM)
+	if err := writeItemCSV(&b, plan); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "CSVの作成に失敗しました"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, plan.ID))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", []byte(b.String()))
+}
+
+// ImportPlanItemsCSV CSVファイルの内容でプランのアイテムを追加・更新する
+// すべての行を検証し、1行でも誤りがあれば何も変更せずに行ごとの誤りを返す
+// CSVに含まれないアイテムは変更しない
+func ImportPlanItemsCSV(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+
+	plan, ok := findEditablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	// 他のユーザーによる更新がないか確認
+	if !checkIfMatch(c, planETag(plan)) {
+		return
+	}
+
+	data, _, ok := readUpload(c)
+	if !ok {
+		return
+	}
+
+	var items []models.PlanItem
+	if err := models.DB.Select("id", "version").Where("plan_id = ?", plan.ID).Find(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "アイテムの取得に失敗しました"})
+		return
+	}
+	versions := map[string]int{}
+	for _, item := range items {
+		versions[item.ID] = item.Version
+	}
+
+	rows, rowErrors := parseItemCSV(strings.NewReader(data), plan, versions)
+	if len(rowErrors) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "CSVの内容に誤りがあります", "errors": rowErrors})
+		return
+	}
+	if len(rows) == 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "取り込めるアイテムがありません", "errors": []csvRowError{}})
+		return
+	}
+
+	var created, updated []string
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := models.BumpPlanVersion(tx, plan.ID, plan.Version); err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if row.Item.ID == "" {
+				if err := tx.Create(row.Item).Error; err != nil {
+					return err
+				}
+				created = append(created, row.Item.ID)
+				continue
+			}
+			if err := models.BumpItemVersion(tx, row.Item.ID, versions[row.Item.ID]); err != nil {
+				return err
+			}
+			if err := updateCSVItem(tx, row).Error; err != nil {
+				return err
+			}
+			updated = append(updated, row.Item.ID)
+		}
+		return models.RecordRevision(tx, plan.ID, currentUserID(c), models.RevisionItemsImported)
+	})
+	if err != nil {
+		writeUpdateError(c, err, "アイテムの取り込みに失敗しました")
+		return
+	}
+	models.ReindexPlan(plan.ID)
+
+	imported, ok := findViewablePlan(c, plan.ID)
+	if !ok {
+		return
+	}
+
+	publishPlanEvent(plan.ID, currentUserID(c), realtime.EventItemsImported, gin.H{"created": created, "updated": updated})
+	c.Header("ETag", planETag(imported))
+	c.JSON(http.StatusOK, gin.H{"data": imported, "created": len(created), "updated": len(updated)})
+}
+
+// updateCSVItem CSVの行で既存のアイテムを更新する
+// ゼロ値も更新できるように、CSVにある列のフィールドだけを指定して更新する
+func updateCSVItem(tx *gorm.DB, row csvItemRow) *gorm.DB {
+	fields := make([]interface{}, len(row.Fields))
+	for i, field := range row.Fields {
+		fields[i] = field
+	}
+	return tx.Model(&models.PlanItem{ID: row.Item.ID}).Select(fields[0], fields[1:]...).Updates(row.Item)
+}
+
+// writeItemCSV プランのアイテムをCSVに書き込む（プランは Localize 済みであること）
+// 時刻はアイテムのタイムゾーンの現地時刻で出力し、timeZone はアイテムに設定されている場合のみ出力する
+func writeItemCSV(w io.Writer, plan *models.TravelPlan) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(itemCSVColumns); err != nil {
+		return err
+	}
+	for _, item := range plan.Items {
+		err := cw.Write([]string{
+			escapeCSVText(item.ID),
+			escapeCSVText(item.Type),
+			escapeCSVText(item.Title),
+			escapeCSVText(item.Description),
+			escapeCSVText(item.Location),
+			formatCSVCoordinate(item.Latitude),
+			formatCSVCoordinate(item.Longitude),
+			formatCSVTime(item.StartTime),
+			formatCSVTime(item.EndTime),
+			escapeCSVText(item.TimeZone),
+			strconv.Itoa(item.Duration),
+			strconv.Itoa(item.Cost),
+			escapeCSVText(item.Notes),
+			strconv.Itoa(item.Order),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvFormulaPrefixes 表計算ソフトが数式として扱うセルの先頭の文字
+const csvFormulaPrefixes = "=+-@"
+
+// escapeCSVText 数式として実行されないように、数式の記号で始まる文字列の先頭に ' を付ける
+func escapeCSVText(s string) string {
+	if s != "" && strings.ContainsRune(csvFormulaPrefixes, rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// unescapeCSVText escapeCSVText で先頭に付けた ' を取り除く
+func unescapeCSVText(s string) string {
+	if len(s) > 1 && s[0] == '\'' && strings.ContainsRune(csvFormulaPrefixes, rune(s[1])) {
+		return s[1:]
+	}
+	return s
+}
+
+// parseItemCSV CSVを読み込み、各行を検証してアイテムを組み立てる
+// versions はプランの既存のアイテムIDとバージョン（id列の値はこの中にある必要がある）
+// 誤りがある場合はすべての行の誤りを返す
+func parseItemCSV(r io.Reader, plan *models.TravelPlan, versions map[string]int) ([]csvItemRow, []csvRowError) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, []csvRowError{{Line: 1, Message: "見出し行を読み込めません"}}
+	}
+	header[0] = strings.TrimPrefix(header[0], utf8BOM)
+
+	// 見出し行の列名を確認する
+	var rowErrors []csvRowError
+	columns := map[string]int{}
+	known := map[string]bool{}
+	for _, name := range itemCSVColumns {
+		known[name] = true
+	}
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		switch {
+		case !known[name]:
+			rowErrors = append(rowErrors, csvRowError{Line: 1, Column: name, Message: "不明な列です"})
+		case columns[name] > 0:
+			rowErrors = append(rowErrors, csvRowError{Line: 1, Column: name, Message: "列が重複しています"})
+		default:
+			columns[name] = i + 1
+		}
+	}
+	for _, name := range []string{"title", "startTime"} {
+		if columns[name] == 0 {
+			rowErrors = append(rowErrors, csvRowError{Line: 1, Column: name, Message: "必須の列がありません"})
+		}
+	}
+	if len(rowErrors) > 0 {
+		return nil, rowErrors
+	}
+
+	var fields []string
+	for _, name := range itemCSVColumns {
+		if columns[name] == 0 {
+			continue
+		}
+		for _, field := range itemCSVFields[name] {
+			if !slices.Contains(fields, field) {
+				fields = append(fields, field)
+			}
+		}
+	}
+
+	var rows []csvItemRow
+	seen := map[string]int{}
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rowErrors = append(rowErrors, csvRowError{Line: parseErr.StartLine, Message: "CSVの形式が正しくありません"})
+			} else {
+				rowErrors = append(rowErrors, csvRowError{Message: "CSVを読み込めません"})
+			}
+			break
+		}
+		line, _ := cr.FieldPos(0)
+		if len(record) != len(header) {
+			rowErrors = append(rowErrors, csvRowError{Line: line, Message: fmt.Sprintf("列の数が見出し行と異なります（%d列）", len(record))})
+			continue
+		}
+		if blankRecord(record) {
+			continue
+		}
+		if len(rows) >= maxImportRows {
+			rowErrors = append(rowErrors, csvRowError{Line: line, Message: fmt.Sprintf("一度に取り込めるのは%d行までです", maxImportRows)})
+			break
+		}
+
+		value := func(name string) string {
+			if i := columns[name]; i > 0 {
+				return strings.TrimSpace(record[i-1])
+			}
+			return ""
+		}
+		text := func(name string) string {
+			return unescapeCSVText(value(name))
+		}
+		fail := func(column, message string) {
+			rowErrors = append(rowErrors, csvRowError{Line: line, Column: column, Message: message})
+		}
+
+		input := PlanItemInput{
+			ID:          text("id"),
+			Type:        text("type"),
+			Title:       text("title"),
+			Description: text("description"),
+			Location:    text("location"),
+			TimeZone:    text("timeZone"),
+			Notes:       text("notes"),
+			Order:       len(rows) + 1,
+		}
+		before := len(rowErrors)
+
+		if input.ID != "" {
+			if _, ok := versions[input.ID]; !ok {
+				fail("id", "プランにないアイテムIDです")
+			} else if prev, ok := seen[input.ID]; ok {
+				fail("id", fmt.Sprintf("%d行目と同じアイテムIDです", prev))
+			}
+			seen[input.ID] = line
+		}
+		if input.Title == "" {
+			fail("title", "タイトルは必須です")
+		}
+		if input.Type == "" {
+			input.Type, _ = models.GuessItemType(nil, input.Title+" "+input.Location)
+		}
+
+		loc := plan.Location()
+		if !models.ValidTimeZone(input.TimeZone) {
+			fail("timeZone", "無効なタイムゾーンです")
+		} else if input.TimeZone != "" {
+			loc, _ = models.LoadTimeZone(input.TimeZone)
+		}
+		if s := value("startTime"); s == "" {
+			fail("startTime", "開始時間は必須です")
+		} else if input.StartTime, err = parseCSVTime(s, loc); err != nil {
+			fail("startTime", "開始時間の形式が正しくありません")
+		}
+		if s := value("endTime"); s != "" {
+			if input.EndTime, err = parseCSVTime(s, loc); err != nil {
+				fail("endTime", "終了時間の形式が正しくありません")
+			}
+		}
+		for _, field := range []struct {
+			name string
+			dst  **float64
+		}{{"latitude", &input.Latitude}, {"longitude", &input.Longitude}} {
+			if s := value(field.name); s != "" {
+				f, err := strconv.ParseFloat(s, 64)
+				if err != nil {
+					fail(field.name, "数値を指定してください")
+					continue
+				}
+				*field.dst = &f
+			}
+		}
+
+		for _, field := range []struct {
+			name string
+			dst  *int
+		}{{"duration", &input.Duration}, {"cost", &input.Cost}, {"order", &input.Order}} {
+			s := value(field.name)
+			if s == "" {
+				continue
+			}
+			// 表計算ソフトの桁区切り（"12,000"）も受け付ける
+			n, err := strconv.Atoi(strings.ReplaceAll(s, ",", ""))
+			if err != nil || n < 0 {
+				fail(field.name, "0以上の整数を指定してください")
+				continue
+			}
+			*field.dst = n
+		}
+		if len(rowErrors) > before {
+			continue
+		}
+
+		// 時刻の前後関係・プランの期間はアイテムの作成・更新と同じ条件で確認する
+		item, err := buildPlanItem(plan, input)
+		if err != nil {
+			fail("", err.Error())
+			continue
+		}
+		item.ID = input.ID
+		rows = append(rows, csvItemRow{Line: line, Item: item, Fields: fields})
+	}
+
+	if len(rowErrors) > 0 {
+		return nil, rowErrors
+	}
+	return rows, nil
+}
+
+// formatCSVTime 時刻をCSVに出力する形式にする（未設定の場合は空欄）
+func formatCSVTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(csvTimeLayout)
+}
+
+// formatCSVCoordinate 緯度・経度をCSVに出力する形式にする（未設定の場合は空欄）
+func formatCSVCoordinate(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f, 'f', -1, 64)
+}
+
+// parseCSVTime CSVの時刻を読み込む（時差のない現地時刻は loc の時刻として扱う）
+func parseCSVTime(s string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range csvLocalTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("invalid time")
+}
+
+// blankRecord すべての列が空欄の行かどうかを返す（表計算ソフトが出力する末尾の空行など）
+func blankRecord(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
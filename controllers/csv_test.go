@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"backend/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// TestItemCSVRoundTrip 出力したCSVを取り込むと同じアイテムに更新されることを確認する
+func TestItemCSVRoundTrip(t *testing.T) {
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	seoul, _ := time.LoadLocation("Asia/Seoul")
+	lat, lng := 37.579617, 126.977041
+	plan := &models.TravelPlan{
+		ID:        "plan",
+		TimeZone:  "Asia/Tokyo",
+		StartDate: models.NewDate(2026, 4, 1),
+		EndDate:   models.NewDate(2026, 4, 2),
+		Items: []models.PlanItem{
+			{
+				ID: "flight", Type: "transport", Title: "成田→仁川", Location: "成田空港",
+				StartTime: time.Date(2026, 4, 1, 9, 0, 0, 0, tokyo), EndTime: time.Date(2026, 4, 1, 11, 30, 0, 0, tokyo),
+				Duration: 150, Cost: 32000, Notes: "窓側, 通路側どちらでも\n荷物1個", Order: 1,
+			},
+			{
+				ID: "palace", Type: "visit", Title: "=HYPERLINK(\"http://example.com\")", Description: "@景福宮", TimeZone: "Asia/Seoul", Latitude: &lat, Longitude: &lng,
+				StartTime: time.Date(2026, 4, 2, 10, 0, 0, 0, seoul), EndTime: time.Date(2026, 4, 2, 12, 0, 0, 0, seoul),
+				Duration: 120, Cost: 3000, Notes: "-5℃", Order: 2,
+			},
+		},
+	}
+
+	var b strings.Builder
+	b.WriteString(utf8BOM)
+	assert.NoError(t, writeItemCSV(&b, plan))
+	// 数式の記号で始まるセルは先頭に ' を付けて出力する
+	assert.Contains(t, b.String(), `'=HYPERLINK(""http://example.com"")`)
+	assert.Contains(t, b.String(), "'@景福宮")
+	assert.Contains(t, b.String(), "'-5℃")
+
+	rows, errs := parseItemCSV(strings.NewReader(b.String()), plan, map[string]int{"flight": 1, "palace": 1})
+	assert.Empty(t, errs)
+	assert.Len(t, rows, 2)
+	for i, row := range rows {
+		want := plan.Items[i]
+		assert.Equal(t, want.ID, row.Item.ID)
+		assert.Equal(t, want.Title, row.Item.Title)
+		assert.Equal(t, want.Description, row.Item.Description)
+		assert.Equal(t, want.Notes, row.Item.Notes)
+		assert.Equal(t, want.TimeZone, row.Item.TimeZone)
+		assert.True(t, want.StartTime.Equal(row.Item.StartTime), want.ID)
+		assert.True(t, want.EndTime.Equal(row.Item.EndTime), want.ID)
+		assert.Equal(t, want.Cost, row.Item.Cost)
+		assert.Equal(t, want.Order, row.Item.Order)
+		assert.Equal(t, want.Latitude, row.Item.Latitude)
+		assert.Equal(t, want.Longitude, row.Item.Longitude)
+	}
+}
+
+// TestEscapeCSVText 数式の記号で始まる文字列だけに ' を付け、取り込み時に取り除くことを確認する
+func TestEscapeCSVText(t *testing.T) {
+	for _, s := range []string{"=1+2", "+81", "-5℃", "@SUM(A1)"} {
+		assert.Equal(t, "'"+s, escapeCSVText(s))
+		assert.Equal(t, s, unescapeCSVText(escapeCSVText(s)))
+	}
+	assert.Equal(t, "", escapeCSVText(""))
+	assert.Equal(t, "景福宮", escapeCSVText("景福宮"))
+	// 数式の記号が続かない ' は取り除かない
+	assert.Equal(t, "'quoted'", unescapeCSVText("'quoted'"))
+	assert.Equal(t, "'", unescapeCSVText("'"))
+}
+
+// TestParseItemCSV 列を省略したCSVを取り込めることを確認する
+func TestParseItemCSV(t *testing.T) {
+	plan := &models.TravelPlan{ID: "plan", TimeZone: "Asia/Tokyo"}
+	data := "title,startTime,endTime,cost\n" +
+		"清水寺,2026/4/1 9:00,2026/4/1 10:30,\"1,200\"\n" +
+		",,,\n" +
+		"夕食,2026-04-01T19:00:00+09:00,,\n"
+
+	rows, errs := parseItemCSV(strings.NewReader(data), plan, nil)
+	assert.Empty(t, errs)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, "", rows[0].Item.ID)
+	assert.Equal(t, models.ItemTypeVisit, rows[0].Item.Type)
+	assert.Equal(t, 1200, rows[0].Item.Cost)
+	assert.Equal(t, 90, rows[0].Item.Duration)
+	assert.Equal(t, "2026-04-01T00:00:00Z", rows[0].Item.StartTime.UTC().Format(time.RFC3339))
+	assert.Equal(t, models.ItemTypeMeal, rows[1].Item.Type)
+	assert.Equal(t, 2, rows[1].Item.Order)
+	assert.Equal(t, 4, rows[1].Line)
+}
+
+// TestParseItemCSVErrors すべての行の誤りが行番号と列名付きで返されることを確認する
+func TestParseItemCSVErrors(t *testing.T) {
+	plan := &models.TravelPlan{
+		ID:        "plan",
+		TimeZone:  "Asia/Tokyo",
+		StartDate: models.NewDate(2026, 4, 1),
+		EndDate:   models.NewDate(2026, 4, 1),
+	}
+
+	_, errs := parseItemCSV(strings.NewReader("title,price\n"), plan, nil)
+	assert.Equal(t, []csvRowError{
+		{Line: 1, Column: "price", Message: "不明な列です"},
+		{Line: 1, Column: "startTime", Message: "必須の列がありません"},
+	}, errs)
+
+	data := "id,title,startTime,endTime,timeZone,cost\n" +
+		"a,清水寺,2026-04-01 09:00,2026-04-01 08:00,,\n" +
+		"a,金閣寺,2026-04-01 13:00,,,\n" +
+		"x,,4月1日,,Mars/Base,-5\n" +
+		"b,伏見稲荷,2026-04-03 09:00,,,\n" +
+		"b,短い行\n"
+	rows, errs := parseItemCSV(strings.NewReader(data), plan, map[string]int{"a": 1, "b": 1})
+	assert.Nil(t, rows)
+	assert.Equal(t, []csvRowError{
+		{Line: 2, Message: "終了時間は開始時間以降を指定してください"},
+		{Line: 3, Column: "id", Message: "2行目と同じアイテムIDです"},
+		{Line: 4, Column: "id", Message: "プランにないアイテムIDです"},
+		{Line: 4, Column: "title", Message: "タイトルは必須です"},
+		{Line: 4, Column: "timeZone", Message: "無効なタイムゾーンです"},
+		{Line: 4, Column: "startTime", Message: "開始時間の形式が正しくありません"},
+		{Line: 4, Column: "cost", Message: "0以上の整数を指定してください"},
+		{Line: 5, Message: "アイテムの開始時間がプランの期間外です"},
+		{Line: 6, Message: "列の数が見出し行と異なります（2列）"},
+	}, errs)
+}
+
+// TestImportItemCSVPartialColumns 列を省略したCSVで既存のアイテムを更新する場合、省略した列を変更しないことを確認する
+func TestImportItemCSVPartialColumns(t *testing.T) {
+	plan := &models.TravelPlan{ID: "plan", TimeZone: "Asia/Tokyo"}
+	data := "id,title,startTime\n" +
+		"a,清水寺（本堂）,2026-04-01 09:30\n"
+
+	rows, errs := parseItemCSV(strings.NewReader(data), plan, map[string]int{"a": 3})
+	if !assert.Empty(t, errs) || !assert.Len(t, rows, 1) {
+		return
+	}
+	assert.Equal(t, []string{"Title", "StartTime"}, rows[0].Fields)
+
+	db, err := gorm.Open(mysql.New(mysql.Config{DSN: "user:pass@tcp(127.0.0.1:3306)/test", SkipInitializeWithVersion: true}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if !assert.NoError(t, err) {
+		return
+	}
+	// 接続せずに生成するSQLを確認する
+	result := updateCSVItem(db, rows[0])
+	assert.NoError(t, result.Error)
+	sql := result.Statement.SQL.String()
+	assert.Contains(t, sql, "`title`=?")
+	assert.Contains(t, sql, "`start_time`=?")
+	for _, column := range []string{"description", "location", "latitude", "longitude", "cost", "notes", "order", "type", "duration"} {
+		assert.NotContains(t, sql, "`"+column+"`", column)
+	}
+}
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"backend/models"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// planETag プランのバージョンからETagを作成する（更新時の If-Match に使う）
+func planETag(plan *models.TravelPlan) string {
+	return fmt.Sprintf(`"plan-%s-v%d"`, plan.ID, plan.Version)
+}
+
+// planViewETag プランの表示内容からETagを作成する（取得時の If-None-Match に使う）
+// いいね・ブックマーク・レビュー・複製の数や、カテゴリ・タグ・場所の内容はバージョンを進めずに変わるため、
+// バージョンのETagにこれらのハッシュを付ける（If-Match では同じバージョンのETagとして扱う）
+func planViewETag(plan *models.TravelPlan) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d:%d:%d:%g:%d\n", plan.LikeCount, plan.BookmarkCount, plan.ReviewCount, plan.RatingAverage, plan.ForkCount)
+	if plan.Category != nil {
+		fmt.Fprintf(h, "category:%d:%s\n", plan.Category.ID, plan.Category.Name)
+	}
+	for _, tag := range plan.Tags {
+		fmt.Fprintf(h, "tag:%s\n", tag.Name)
+	}
+	for _, item := range plan.Items {
+		if item.Place != nil {
+			fmt.Fprintf(h, "place:%d:%d\n", item.Place.ID, item.Place.UpdatedAt.UnixNano())
+		}
+	}
+	return fmt.Sprintf(`"plan-%s-v%d.%s"`, plan.ID, plan.Version, hex.EncodeToString(h.Sum(nil))[:16])
+}
+
+// itemETag アイテムのバージョンからETagを作成する
+func itemETag(item *models.PlanItem) string {
+	return fmt.Sprintf(`"item-%s-v%d"`, item.ID, item.Version)
+}
+
+// notModified ETagヘッダーを設定し、If-None-Match と一致する場合は304を返してtrueを返す
+func notModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+	if matchETag(c.GetHeader("If-None-Match"), etag, true) {
+		c.Status(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// checkIfMatch If-Match が指定されていてETagと一致しない場合は412を返してfalseを返す
+// プランの表示内容のETag（planViewETag）は、同じバージョンのETagと一致するものとして扱う
+func checkIfMatch(c *gin.Context, etag string) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" || matchETag(header, etag, false) || matchETag(viewETagVersion(header), etag, false) {
+		return true
+	}
+	c.JSON(http.StatusPreconditionFailed, gin.H{"error": "他のユーザーによって更新されています。最新の内容を取得してください"})
+	return false
+}
+
+// viewETagVersion ヘッダーのETagの一覧から表示内容のハッシュ（"." 以降）を取り除く
+func viewETagVersion(header string) string {
+	candidates := strings.Split(header, ",")
+	for i, candidate := range candidates {
+		candidate = strings.TrimSpace(candidate)
+		if dot := strings.LastIndex(candidate, "."); dot > 0 && strings.HasSuffix(candidate, `"`) {
+			candidate = candidate[:dot] + `"`
+		}
+		candidates[i] = candidate
+	}
+	return strings.Join(candidates, ",")
+}
+
+// matchETag ヘッダーのETagの一覧に指定したETagが含まれるかどうかを返す
+// weak が true の場合は弱い比較（W/ を無視）を行う
+func matchETag(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// writeUpdateError 更新処理のエラーをレスポンスに書き込む
+// バージョンの競合は412、それ以外は500とする
+func writeUpdateError(c *gin.Context, err error, message string) {
+	if errors.Is(err, models.ErrVersionConflict) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "他のユーザーによって更新されています。最新の内容を取得してください"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
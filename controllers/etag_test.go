@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"backend/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestConditionalRequests ETagによる条件付きリクエストのテスト
+func TestConditionalRequests(t *testing.T) {
+	router := setupTestRouter()
+	plan := &models.TravelPlan{ID: "test-plan-1", Version: 3}
+
+	router.GET("/api/plans/:id", func(c *gin.Context) {
+		if notModified(c, planViewETag(plan)) {
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": plan})
+	})
+	router.PUT("/api/plans/:id", func(c *gin.Context) {
+		if !checkIfMatch(c, planETag(plan)) {
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": plan})
+	})
+
+	send := func(method, header, value string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, "/api/plans/test-plan-1", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 初回の取得ではバージョンと表示内容のETagが返る
+	w := send("GET", "", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	assert.Regexp(t, `^"plan-test-plan-1-v3\.[0-9a-f]{16}"$`, etag)
+
+	// 同じETagであれば304
+	assert.Equal(t, http.StatusNotModified, send("GET", "If-None-Match", "W/"+etag).Code)
+	assert.Equal(t, http.StatusOK, send("GET", "If-None-Match", `"plan-test-plan-1-v2"`).Code)
+
+	// バージョンが変わらなくても、いいねの数や場所が変わればETagが変わる
+	plan.LikeCount++
+	assert.Equal(t, http.StatusOK, send("GET", "If-None-Match", etag).Code)
+	liked := planViewETag(plan)
+	plan.Items = []models.PlanItem{{ID: "item-1", Place: &models.Place{ID: 1, UpdatedAt: time.Now()}}}
+	assert.NotEqual(t, liked, planViewETag(plan))
+
+	// If-Matchが一致しない場合は412（表示内容のETagは同じバージョンであれば一致する）
+	assert.Equal(t, http.StatusOK, send("PUT", "", "").Code)
+	assert.Equal(t, http.StatusOK, send("PUT", "If-Match", `"plan-test-plan-1-v2", "plan-test-plan-1-v3"`).Code)
+	assert.Equal(t, http.StatusOK, send("PUT", "If-Match", etag).Code)
+	assert.Equal(t, http.StatusPreconditionFailed, send("PUT", "If-Match", `"plan-test-plan-1-v2"`).Code)
+	assert.Equal(t, http.StatusPreconditionFailed, send("PUT", "If-Match", `"plan-test-plan-1-v2.0123456789abcdef"`).Code)
+	assert.Equal(t, http.StatusPreconditionFailed, send("PUT", "If-Match", `W/"plan-test-plan-1-v3"`).Code)
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"backend/models"
+	"backend/utils/realtime"
+	"backend/utils/token"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// heartbeatInterval プロキシに接続を切られないように空のコメントを送る間隔
+const heartbeatInterval = 25 * time.Second
+
+// Events プランのイベントの配信先
+// 複数のプロセスで動かす場合は、起動時に外部のブローカーを使うBrokerに差し替える
+var Events realtime.Broker = realtime.NewHub()
+
+// presence プランを閲覧中のユーザー（このプロセスに接続しているユーザーのみ）
+var presence = realtime.NewPresence()
+
+// canViewPlan イベントを配信する前にユーザーがプランを閲覧できるか確認する（テストで差し替える）
+var canViewPlan = models.CanViewPlan
+
+// streamUserKey イベント配信の接続用チケットで認証したユーザーIDを保存するコンテキストのキー
+const streamUserKey = "streamUserID"
+
+// CreateEventTicket プランのイベント配信に接続するためのチケットを発行する
+// EventSourceはヘッダーを指定できないため、非公開プランの配信にはこのチケットをクエリパラメータ ticket で指定する
+func CreateEventTicket(c *gin.Context) {
+	plan, ok := findViewablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	ticket, expiresAt, err := token.GenerateStreamTicket(currentUserID(c), plan.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "チケットの発行に失敗しました"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"ticket": ticket, "expiresAt": expiresAt}})
+}
+
+// StreamPlanEvents プランの変更と閲覧中のユーザーをServer-Sent Eventsで配信する
+// EventSourceはヘッダーを指定できないため、認証トークンの代わりに CreateEventTicket で発行した
+// チケットをクエリパラメータ ticket でも受け付ける（チケットは接続時にだけ検証する）
+// プランが非公開になった場合やメンバーから外された場合に配信を止めるため、イベントを送る前に閲覧できるか確認し直す
+func StreamPlanEvents(c *gin.Context) {
+	if ticket := c.Query("ticket"); ticket != "" {
+		userId, err := token.ParseStreamTicket(ticket, c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "チケットが無効か期限切れです"})
+			return
+		}
+		c.Set(streamUserKey, userId)
+	}
+
+	plan, ok := findViewablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	events, unsubscribe := Events.Subscribe(plan.ID)
+	defer unsubscribe()
+
+	// ログインしているユーザーのみ入退室を通知する
+	userId := currentUserID(c)
+	if userId != 0 {
+		if presence.Join(plan.ID, userId) {
+			publishPlanEvent(plan.ID, userId, realtime.EventPresenceJoined, gin.H{"userId": userId})
+		}
+		defer func() {
+			if presence.Leave(plan.ID, userId) {
+				publishPlanEvent(plan.ID, userId, realtime.EventPresenceLeft, gin.H{"userId": userId})
+			}
+		}()
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	// 接続直後に現在の状態を送る
+	c.Render(-1, sse.Event{Event: "presence", Data: gin.H{"users": presence.Users(plan.ID), "version": plan.Version}})
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			return sendPlanEvent(c, plan.ID, userId, event)
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": ping\n\n")
+			return err == nil
+		}
+	})
+}
+
+// sendPlanEvent イベントを送り、配信を続ける場合はtrueを返す
+// 削除の通知は送ってから切断する。閲覧できなくなった場合は送らずに切断する
+func sendPlanEvent(c *gin.Context, planID string, userID uint, event realtime.Event) bool {
+	if event.Type != realtime.EventPlanDeleted && !canViewPlan(planID, userID) {
+		return false
+	}
+	c.Render(-1, sse.Event{Id: strconv.FormatUint(event.ID, 10), Event: event.Type, Data: event})
+	return event.Type != realtime.EventPlanDeleted
+}
+
+// publishPlanEvent プランを閲覧中のユーザーにイベントを配信する
+func publishPlanEvent(planID string, userID uint, eventType string, data interface{}) {
+	Events.Publish(planID, realtime.Event{Type: eventType, UserID: userID, Data: data})
+}
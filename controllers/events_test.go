@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"backend/utils/realtime"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSendPlanEvent 閲覧できなくなったユーザーにはイベントを送らずに配信を止めることを確認する
+func TestSendPlanEvent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	viewable := true
+	original := canViewPlan
+	canViewPlan = func(planID string, userID uint) bool { return viewable }
+	defer func() { canViewPlan = original }()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	assert.True(t, sendPlanEvent(c, "plan", 0, realtime.Event{ID: 1, Type: realtime.EventItemCreated, Data: "清水寺"}))
+	assert.Contains(t, w.Body.String(), "event:item.created")
+	assert.Contains(t, w.Body.String(), "清水寺")
+
+	// プランが非公開になった場合は送らない
+	viewable = false
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	assert.False(t, sendPlanEvent(c, "plan", 0, realtime.Event{ID: 2, Type: realtime.EventItemUpdated, Data: "金閣寺"}))
+	assert.Empty(t, w.Body.String())
+
+	// 削除の通知は送ってから配信を止める
+	assert.False(t, sendPlanEvent(c, "plan", 0, realtime.Event{ID: 3, Type: realtime.EventPlanDeleted}))
+	assert.Contains(t, w.Body.String(), "event:plan.deleted")
+}
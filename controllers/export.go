@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"backend/models"
+	"backend/utils/ical"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	icalProdID    = "-//my_home_backend//Travel Plans//JA"
+	icalUIDDomain = "my-home-backend" // 予定のUIDの "@" 以降（同じアイテムは常に同じUIDになる）
+)
+
+// ExportPlanICS プランのアイテムをiCalendar形式（.ics）で出力する
+// 各アイテムはアイテムのタイムゾーンの現地時刻で出力するため、スマートフォンのカレンダーにそのまま取り込める
+func ExportPlanICS(c *gin.Context) {
+	plan, ok := findViewablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	// 変更がなければ304を返す
+	if notModified(c, planViewETag(plan)) {
+		return
+	}
+
+	cal := ical.Calendar{
+		ProdID:   icalProdID,
+		Name:     plan.Title,
+		TimeZone: plan.Location().String(),
+		Events:   planEvents(plan),
+	}
+
+	var b strings.Builder
+	if err := cal.Encode(&b); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "カレンダーの作成に失敗しました"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.ics"`, plan.ID))
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(b.String()))
+}
+
+// planEvents プランの各アイテムを予定に変換する（プランは Localize 済みであること）
+func planEvents(plan *models.TravelPlan) []ical.Event {
+	events := make([]ical.Event, 0, len(plan.Items))
+	for _, item := range plan.Items {
+		events = append(events, ical.Event{
+			UID:          item.ID + "@" + icalUIDDomain,
+			Summary:      item.Title,
+			Description:  itemEventDescription(plan, &item),
+			Location:     item.Location,
+			Categories:   nonEmpty(item.Type),
+			Start:        item.StartTime,
+			End:          item.EndTime,
+			Stamp:        plan.UpdatedAt,
+			LastModified: plan.UpdatedAt,
+			Sequence:     item.Version - 1,
+		})
+	}
+	return events
+}
+
+// itemEventDescription 予定の説明（アイテムの説明・メモ・費用とプラン名）を返す
+func itemEventDescription(plan *models.TravelPlan, item *models.PlanItem) string {
+	var parts []string
+	if item.Description != "" {
+		parts = append(parts, item.Description)
+	}
+	if item.Notes != "" {
+		parts = append(parts, "メモ: "+item.Notes)
+	}
+	if item.Cost > 0 {
+		parts = append(parts, fmt.Sprintf("費用: %d円", item.Cost))
+	}
+	parts = append(parts, "プラン: "+plan.Title)
+	return strings.Join(parts, "\n")
+}
+
+func nonEmpty(values ...string) []string {
+	var result []string
+	for _, v := range values {
+		if v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
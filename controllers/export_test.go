@@ -0,0 +1,38 @@
+package controllers
+
+import (
+	"backend/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPlanEvents アイテムが安定したUIDと説明を持つ予定に変換されることを確認する
+func TestPlanEvents(t *testing.T) {
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	plan := &models.TravelPlan{
+		ID:        "plan",
+		Title:     "京都1日観光プラン",
+		UpdatedAt: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		Items: []models.PlanItem{{
+			ID:          "item",
+			Type:        "visit",
+			Title:       "清水寺観光",
+			Description: "本堂を見学",
+			Notes:       "混雑に注意",
+			Cost:        400,
+			StartTime:   time.Date(2026, 4, 1, 9, 0, 0, 0, tokyo),
+			EndTime:     time.Date(2026, 4, 1, 11, 0, 0, 0, tokyo),
+			Version:     3,
+		}},
+	}
+
+	events := planEvents(plan)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "item@"+icalUIDDomain, events[0].UID)
+	assert.Equal(t, "本堂を見学\nメモ: 混雑に注意\n費用: 400円\nプラン: 京都1日観光プラン", events[0].Description)
+	assert.Equal(t, []string{"visit"}, events[0].Categories)
+	assert.Equal(t, 2, events[0].Sequence)
+	assert.Equal(t, tokyo, events[0].Start.Location())
+}
@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"backend/models"
+	"backend/utils/ical"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// feedMaxAge カレンダーアプリにフィードをキャッシュしてよい秒数
+const feedMaxAge = 15 * 60
+
+// feedVersion フィードに含まれるプランの変更の確認に使う値
+type feedVersion struct {
+	ID        string
+	Version   int
+	UpdatedAt time.Time
+}
+
+// GetCalendarFeed 自分の購読用URLの状態を取得する（URLは発行時にのみ返す）
+func GetCalendarFeed(c *gin.Context) {
+	var feed models.CalendarFeedToken
+	if err := models.DB.First(&feed, "user_id = ?", currentUserID(c)).Error; err != nil {
+		c.JSON(http.StatusOK, gin.H{"data": gin.H{"subscribed": false}})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"subscribed":     true,
+		"createdAt":      feed.CreatedAt,
+		"lastAccessedAt": feed.LastAccessedAt,
+	}})
+}
+
+// CreateCalendarFeed 購読用URLを発行する
+// すでに発行している場合は作り直し、以前のURLは使えなくなる
+func CreateCalendarFeed(c *gin.Context) {
+	secret, feed, err := models.IssueCalendarFeedToken(currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "購読用URLの発行に失敗しました"})
+		return
+	}
+
+	url := feedURL(secret)
+	c.JSON(http.StatusCreated, gin.H{"data": gin.H{
+		"url":       url,
+		"webcalUrl": "webcal://" + strings.SplitN(url, "://", 2)[1],
+		"createdAt": feed.CreatedAt,
+	}})
+}
+
+// DeleteCalendarFeed 購読用URLを無効にする
+func DeleteCalendarFeed(c *gin.Context) {
+	if err := models.RevokeCalendarFeedToken(currentUserID(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "購読用URLの削除に失敗しました"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": "購読用URLを無効にしました"})
+}
+
+// ServeCalendarFeed 購読用URLのトークンに対応するユーザーの確定済みプランをiCalendar形式で出力する
+// カレンダーアプリが定期的に取得するため、ETagで変更がなければ304を返す
+// フィードからプランが外れると最終更新日時が戻ることがあるため、Last-Modified・If-Modified-Since は使わない
+func ServeCalendarFeed(c *gin.Context) {
+	feed, err := models.FindCalendarFeedToken(c.Param("token"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "カレンダーが見つかりません"})
+		return
+	}
+
+	// プランのIDとバージョンだけを先に取得し、変更がなければ本体を読み込まない
+	var versions []feedVersion
+	if err := models.CalendarFeedPlans(feed.UserID).Select("id", "version", "updated_at").
+		Order("id").Scan(&versions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "カレンダーの作成に失敗しました"})
+		return
+	}
+	c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", feedMaxAge))
+	if notModified(c, feedETag(versions)) {
+		return
+	}
+
+	var plans []models.TravelPlan
+	if err := models.CalendarFeedPlans(feed.UserID).Preload("Items", orderItems).
+		Order("start_date, id").Find(&plans).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "カレンダーの作成に失敗しました"})
+		return
+	}
+
+	cal := ical.Calendar{ProdID: icalProdID, Name: "旅行プラン"}
+	for i := range plans {
+		plans[i].Localize()
+		cal.Events = append(cal.Events, planEvents(&plans[i])...)
+	}
+
+	var b strings.Builder
+	if err := cal.Encode(&b); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "カレンダーの作成に失敗しました"})
+		return
+	}
+
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(b.String()))
+}
+
+// feedETag フィードに含まれるプランのバージョンからETagを作成する
+// プランの追加・削除やメンバーの変更でも一覧が変わるためETagが変わる
+func feedETag(versions []feedVersion) string {
+	h := sha256.New()
+	for _, v := range versions {
+		fmt.Fprintf(h, "%s:%d:%d\n", v.ID, v.Version, v.UpdatedAt.UnixNano())
+	}
+	return fmt.Sprintf(`"feed-%s"`, hex.EncodeToString(h.Sum(nil))[:32])
+}
+
+// feedURL 購読用URLを作成する
+func feedURL(secret string) string {
+	return fmt.Sprintf("%s/api/feeds/%s/plans.ics", publicBaseURL, secret)
+}
+
+// publicBaseURL 共有ページや購読用URLに使う公開URL（スキームとホスト）
+// リクエストの Host や X-Forwarded-Proto ヘッダーはクライアントが書き換えられるため使わない
+var publicBaseURL = "http://localhost:8080"
+
+// SetPublicBaseURL 共有ページや購読用URLに使う公開URLを設定する（url が空の場合は変更しない）
+func SetPublicBaseURL(url string) {
+	if url == "" {
+		return
+	}
+	publicBaseURL = strings.TrimRight(url, "/")
+}
@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFeedETag プランの更新・追加・削除でETagが変わることを確認する
+func TestFeedETag(t *testing.T) {
+	older := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+	versions := []feedVersion{{ID: "a", Version: 1, UpdatedAt: newer}, {ID: "b", Version: 2, UpdatedAt: older}}
+
+	etag := feedETag(versions)
+	assert.Equal(t, etag, feedETag([]feedVersion{{ID: "a", Version: 1, UpdatedAt: newer}, {ID: "b", Version: 2, UpdatedAt: older}}))
+
+	bumped := feedETag([]feedVersion{{ID: "a", Version: 2, UpdatedAt: newer}, {ID: "b", Version: 2, UpdatedAt: older}})
+	assert.NotEqual(t, etag, bumped)
+
+	added := feedETag(append(versions, feedVersion{ID: "c", Version: 1, UpdatedAt: older}))
+	assert.NotEqual(t, etag, added)
+
+	// 最も新しいプランがフィードから外れてもETagが変わる
+	removed := feedETag(versions[1:])
+	assert.NotEqual(t, etag, removed)
+
+	assert.NotEmpty(t, feedETag(nil))
+}
+
+// TestFeedURL 購読用URLが設定した公開URLから作られることを確認する
+func TestFeedURL(t *testing.T) {
+	defer SetPublicBaseURL(publicBaseURL)
+
+	SetPublicBaseURL("https://example.com/")
+	assert.Equal(t, "https://example.com/api/feeds/secret/plans.ics", feedURL("secret"))
+
+	// 空の場合は変更しない
+	SetPublicBaseURL("")
+	assert.Equal(t, "https://example.com/api/feeds/secret/plans.ics", feedURL("secret"))
+}
@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"backend/models"
+	"backend/utils/token"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ForkPlanInput struct {
+	StartDate models.Date `json:"startDate"` // 複製後の旅行開始日（省略時は元のプランと同じ日程）
+}
+
+// ForkPlan 公開プラン（または自分のプラン）を自分の非公開の下書きとして複製する
+func ForkPlan(c *gin.Context) {
+	var input ForkPlanInput
+
+	// リクエストボディは省略可能
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// トークンからユーザーIDを取得
+	userId, err := token.ExtractTokenId(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "認証に失敗しました"})
+		return
+	}
+
+	source, ok := findViewablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	forked, err := models.ForkPlan(source, userId, input.StartDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "プランの複製に失敗しました"})
+		return
+	}
+	models.ReindexPlan(forked.ID)
+
+	forked.Localize()
+	c.JSON(http.StatusOK, gin.H{"data": forked})
+}
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"backend/models"
+	"backend/utils/geo"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// geoFormat 地図アプリ向けの出力形式
+type geoFormat struct {
+	extension   string
+	contentType string
+	encode      func(w io.Writer, route *geo.Route) error
+}
+
+var (
+	geoJSONFormat = geoFormat{"geojson", "application/geo+json", geo.EncodeGeoJSON}
+	gpxFormat     = geoFormat{"gpx", "application/gpx+xml", func(w io.Writer, route *geo.Route) error {
+		return geo.EncodeGPX(w, route, "my_home_backend")
+	}}
+	kmlFormat = geoFormat{"kml", "application/vnd.google-earth.kml+xml", geo.EncodeKML}
+)
+
+// ExportPlanGeoJSON プランの立ち寄り先をGeoJSONのFeatureCollectionで出力する
+func ExportPlanGeoJSON(c *gin.Context) {
+	exportPlanRoute(c, geoJSONFormat)
+}
+
+// ExportPlanGPX プランの立ち寄り先をGPXのウェイポイントとルートで出力する
+func ExportPlanGPX(c *gin.Context) {
+	exportPlanRoute(c, gpxFormat)
+}
+
+// ExportPlanKML プランの立ち寄り先をKMLで出力する
+func ExportPlanKML(c *gin.Context) {
+	exportPlanRoute(c, kmlFormat)
+}
+
+// exportPlanRoute 緯度・経度が設定されたアイテムを順序どおりに指定した形式で出力する
+// 緯度・経度のないアイテムは出力せず、その数を X-Skipped-Items ヘッダーで返す
+func exportPlanRoute(c *gin.Context, format geoFormat) {
+	plan, ok := findViewablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	// 変更がなければ304を返す
+	if notModified(c, planViewETag(plan)) {
+		return
+	}
+
+	route, skipped := planRoute(plan)
+	var b bytes.Buffer
+	if err := format.encode(&b, route); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ファイルの作成に失敗しました"})
+		return
+	}
+
+	c.Header("X-Skipped-Items", fmt.Sprint(skipped))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, plan.ID, format.extension))
+	c.Data(http.StatusOK, format.contentType+"; charset=utf-8", b.Bytes())
+}
+
+// planRoute プランのアイテムを順序・開始時間の順に並べた地点の一覧にする（プランは Localize 済みであること）
+// 緯度・経度のないアイテムは含めず、その数を返す
+func planRoute(plan *models.TravelPlan) (*geo.Route, int) {
+	items := make([]models.PlanItem, len(plan.Items))
+	copy(items, plan.Items)
+	sort.SliceStable(items, func(a, b int) bool {
+		if items[a].Order != items[b].Order {
+			return items[a].Order < items[b].Order
+		}
+		return items[a].StartTime.Before(items[b].StartTime)
+	})
+
+	route := &geo.Route{Name: plan.Title, Description: plan.Description, Updated: plan.UpdatedAt}
+	skipped := 0
+	for _, item := range items {
+		if !item.HasCoordinates() {
+			skipped++
+			continue
+		}
+		route.Stops = append(route.Stops, geo.Stop{
+			ID:          item.ID,
+			Order:       item.Order,
+			Name:        item.Title,
+			Type:        item.Type,
+			Address:     item.Location,
+			Description: item.Description,
+			Latitude:    *item.Latitude,
+			Longitude:   *item.Longitude,
+			Start:       item.StartTime,
+			End:         item.EndTime,
+			Cost:        item.Cost,
+		})
+	}
+	return route, skipped
+}
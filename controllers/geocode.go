@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"backend/models"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GeocodePlace 場所の名前や住所から緯度・経度の候補を検索する
+func GeocodePlace(c *gin.Context) {
+	if models.Geocoder == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "ジオコーディングは設定されていません"})
+		return
+	}
+
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "検索キーワードを指定してください"})
+		return
+	}
+
+	results, err := models.Geocoder.Geocode(c.Request.Context(), q)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "場所の検索に失敗しました"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": results})
+}
+
+// BackfillCoordinates 緯度・経度が未設定のアイテムの補完をバックグラウンドで開始する
+func BackfillCoordinates(c *gin.Context) {
+	if models.Geocoder == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "ジオコーディングは設定されていません"})
+		return
+	}
+
+	if err := models.StartBackfillCoordinates(models.Geocoder); err != nil {
+		if errors.Is(err, models.ErrBackfillRunning) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "緯度・経度の補完を開始できませんでした"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"data": "緯度・経度の補完を開始しました"})
+}
@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"backend/models"
+	"backend/utils/ical"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxImportSize 取り込むファイルの最大サイズ
+const maxImportSize = 2 << 20
+
+// ImportPlanICS iCalendar形式（.ics）のファイルから新しい下書きのプランを作成する
+// ファイルは multipart/form-data の file、または text/calendar のリクエストボディで受け付ける
+// title・timeZone（フォームまたはクエリパラメータ）を省略した場合はカレンダー名・カレンダーのタイムゾーンを使う
+func ImportPlanICS(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+
+	data, filename, ok := readUpload(c)
+	if !ok {
+		return
+	}
+
+	timeZone := formValue(c, "timeZone")
+	if !models.ValidTimeZone(timeZone) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なタイムゾーンです"})
+		return
+	}
+
+	// タイムゾーンの指定がない日時はプランのタイムゾーンの日時として読み込むため、先にカレンダーのタイムゾーンを確認する
+	cal, err := ical.Parse(strings.NewReader(data), planLocation(timeZone))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "iCalendar形式のファイルを読み込めません"})
+		return
+	}
+	if timeZone == "" && models.ValidTimeZone(cal.TimeZone) {
+		timeZone = cal.TimeZone
+		if cal, err = ical.Parse(strings.NewReader(data), planLocation(timeZone)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "iCalendar形式のファイルを読み込めません"})
+			return
+		}
+	}
+	if timeZone == "" {
+		timeZone = models.DefaultTimeZone
+	}
+
+	title := strings.TrimSpace(formValue(c, "title"))
+	if title == "" {
+		title = cal.Name
+	}
+	if title == "" && filename != "" {
+		title = strings.TrimSuffix(filename, filepath.Ext(filename))
+	}
+	if title == "" {
+		title = "取り込んだプラン"
+	}
+
+	userId := currentUserID(c)
+	plan, report := models.PlanFromCalendar(cal, userId, title, timeZone)
+	if report.Imported == 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "取り込める予定がありません", "report": report})
+		return
+	}
+
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&plan).Error; err != nil {
+			return err
+		}
+		return models.RecordRevision(tx, plan.ID, userId, models.RevisionPlanCreated)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "プランの作成に失敗しました"})
+		return
+	}
+	models.ReindexPlan(plan.ID)
+	report.AssignItemIDs(&plan)
+
+	plan.Localize()
+	c.JSON(http.StatusCreated, gin.H{"data": plan, "report": report})
+}
+
+// readUpload アップロードされたファイルの内容とファイル名を返す
+// 失敗した場合はエラーレスポンスを書き込み、falseを返す
+func readUpload(c *gin.Context) (string, string, bool) {
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		file, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ファイルを指定してください"})
+			return "", "", false
+		}
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ファイルを読み込めません"})
+			return "", "", false
+		}
+		defer f.Close()
+		data, err := io.ReadAll(io.LimitReader(f, maxImportSize+1))
+		if err != nil || len(data) > maxImportSize {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "ファイルが大きすぎます"})
+			return "", "", false
+		}
+		return string(data), file.Filename, true
+	}
+
+	data, err := io.ReadAll(c.Request.Body)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "ファイルが大きすぎます"})
+		return "", "", false
+	}
+	if err != nil || len(data) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ファイルを指定してください"})
+		return "", "", false
+	}
+	return string(data), "", true
+}
+
+// planLocation タイムゾーン名のロケーションを返す（空や無効な場合は既定のタイムゾーン）
+func planLocation(timeZone string) *time.Location {
+	return (&models.TravelPlan{TimeZone: timeZone}).Location()
+}
+
+// formValue フォームの値を返す（フォームにない場合はクエリパラメータ）
+func formValue(c *gin.Context, key string) string {
+	if v := c.PostForm(key); v != "" {
+		return v
+	}
+	return c.Query(key)
+}
@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"backend/models"
+	"backend/utils/realtime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetPlanItem プランのアイテムを取得する
+func GetPlanItem(c *gin.Context) {
+	plan, ok := findViewablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	item, ok := findPlanItem(c, plan, c.Param("itemId"))
+	if !ok {
+		return
+	}
+
+	// 変更がなければ304を返す
+	if notModified(c, itemETag(item)) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": item})
+}
+
+// UpdatePlanItem プランのアイテムを入力内容で置き換える
+func UpdatePlanItem(c *gin.Context) {
+	var input PlanItemInput
+
+	// リクエストのJSONデータをPlanItemInput構造体にバインドする
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, ok := findEditablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	item, ok := findPlanItem(c, plan, c.Param("itemId"))
+	if !ok {
+		return
+	}
+
+	// 他のユーザーによる更新がないか確認
+	if !checkIfMatch(c, itemETag(item)) {
+		return
+	}
+
+	updated, ok := newPlanItem(c, plan, input)
+	if !ok {
+		return
+	}
+
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := models.BumpItemVersion(tx, item.ID, item.Version); err != nil {
+			return err
+		}
+		if err := models.BumpPlanVersion(tx, plan.ID, plan.Version); err != nil {
+			return err
+		}
+		// ゼロ値も更新できるように更新するフィールドを指定する
+		err := tx.Model(item).
+			Select("Type", "Title", "Description", "Location", "Latitude", "Longitude", "PlaceID", "StartTime",
+				"EndTime", "TimeZone", "Duration", "Cost", "Notes", "Order").
+			Updates(updated).Error
+		if err != nil {
+			return err
+		}
+		return models.RecordRevision(tx, plan.ID, currentUserID(c), models.RevisionItemUpdated)
+	})
+	if err != nil {
+		writeUpdateError(c, err, "アイテムの更新に失敗しました")
+		return
+	}
+	models.ReindexPlan(plan.ID)
+
+	item.Version++
+	plan.LocalizeItem(item)
+	publishPlanEvent(plan.ID, currentUserID(c), realtime.EventItemUpdated, item)
+	c.Header("ETag", itemETag(item))
+	c.JSON(http.StatusOK, gin.H{"data": item})
+}
+
+// DeletePlanItem プランのアイテムを削除する
+func DeletePlanItem(c *gin.Context) {
+	plan, ok := findEditablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	item, ok := findPlanItem(c, plan, c.Param("itemId"))
+	if !ok {
+		return
+	}
+
+	// 他のユーザーによる更新がないか確認
+	if !checkIfMatch(c, itemETag(item)) {
+		return
+	}
+
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := models.BumpItemVersion(tx, item.ID, item.Version); err != nil {
+			return err
+		}
+		if err := models.BumpPlanVersion(tx, plan.ID, plan.Version); err != nil {
+			return err
+		}
+		if err := tx.Delete(item).Error; err != nil {
+			return err
+		}
+		return models.RecordRevision(tx, plan.ID, currentUserID(c), models.RevisionItemDeleted)
+	})
+	if err != nil {
+		writeUpdateError(c, err, "アイテムの削除に失敗しました")
+		return
+	}
+	models.ReindexPlan(plan.ID)
+	publishPlanEvent(plan.ID, currentUserID(c), realtime.EventItemDeleted, gin.H{"id": item.ID})
+
+	c.JSON(http.StatusOK, gin.H{"data": "アイテムが削除されました"})
+}
+
+// ReorderPlanItems プランのアイテムの順序を並べ替える
+// itemIds にはプランのすべてのアイテムのIDを新しい順序で指定する
+func ReorderPlanItems(c *gin.Context) {
+	var input struct {
+		ItemIDs []string `json:"itemIds" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, ok := findEditablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	// 他のユーザーによる更新がないか確認
+	if !checkIfMatch(c, planETag(plan)) {
+		return
+	}
+
+	// 指定されたIDがプランのアイテムと過不足なく一致するか確認
+	var items []models.PlanItem
+	if err := models.DB.Select("id", "version").Where("plan_id = ?", plan.ID).Find(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "アイテムの取得に失敗しました"})
+		return
+	}
+	versions := map[string]int{}
+	for _, item := range items {
+		versions[item.ID] = item.Version
+	}
+	seen := map[string]bool{}
+	for _, id := range input.ItemIDs {
+		if _, ok := versions[id]; !ok || seen[id] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "itemIdsにはプランのアイテムを重複なく指定してください"})
+			return
+		}
+		seen[id] = true
+	}
+	if len(seen) != len(versions) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "itemIdsにはプランのすべてのアイテムを指定してください"})
+		return
+	}
+
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := models.BumpPlanVersion(tx, plan.ID, plan.Version); err != nil {
+			return err
+		}
+		for i, id := range input.ItemIDs {
+			if err := models.BumpItemVersion(tx, id, versions[id]); err != nil {
+				return err
+			}
+			if err := tx.Model(&models.PlanItem{}).Where("id = ?", id).Update("order", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return models.RecordRevision(tx, plan.ID, currentUserID(c), models.RevisionItemsReordered)
+	})
+	if err != nil {
+		writeUpdateError(c, err, "アイテムの並べ替えに失敗しました")
+		return
+	}
+
+	reordered, ok := findViewablePlan(c, plan.ID)
+	if !ok {
+		return
+	}
+
+	publishPlanEvent(plan.ID, currentUserID(c), realtime.EventItemsReordered, gin.H{"itemIds": input.ItemIDs})
+	c.Header("ETag", planETag(reordered))
+	c.JSON(http.StatusOK, gin.H{"data": reordered})
+}
+
+// findPlanItem プランのアイテムを取得する
+// 失敗した場合はエラーレスポンスを書き込み、falseを返す
+func findPlanItem(c *gin.Context, plan *models.TravelPlan, itemID string) (*models.PlanItem, bool) {
+	var item models.PlanItem
+	if err := models.DB.First(&item, "id = ? AND plan_id = ?", itemID, plan.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "アイテムが見つかりません"})
+		return nil, false
+	}
+
+	plan.LocalizeItem(&item)
+	return &item, true
+}
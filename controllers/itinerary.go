@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"backend/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPlanDays プランの行程を日ごとにまとめて取得する
+func GetPlanDays(c *gin.Context) {
+	plan, ok := findViewablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	// 変更がなければ304を返す
+	if notModified(c, planViewETag(plan)) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": plan.Days()})
+}
+
+// GetPlanDay プランの指定した日（1始まり）の行程を取得する
+func GetPlanDay(c *gin.Context) {
+	plan, ok := findViewablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	day, ok := findTripDay(c, plan)
+	if !ok {
+		return
+	}
+
+	// 変更がなければ304を返す
+	if notModified(c, planViewETag(plan)) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": day})
+}
+
+// CreatePlanDayItem プランの指定した日にアイテムを追加する
+func CreatePlanDayItem(c *gin.Context) {
+	var input PlanItemInput
+
+	// リクエストのJSONデータをPlanItemInput構造体にバインドする
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, ok := findEditablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	// 日数を数えるためにアイテムを読み込む
+	if err := models.DB.Where("plan_id = ?", plan.ID).Find(&plan.Items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "アイテムの取得に失敗しました"})
+		return
+	}
+
+	day, ok := findTripDay(c, plan)
+	if !ok {
+		return
+	}
+
+	// アイテムの開始時間が指定した日に含まれるか確認（アイテムのタイムゾーンで判定）
+	probe := models.PlanItem{StartTime: input.StartTime, TimeZone: input.TimeZone}
+	if !plan.LocalDate(&probe).Equal(day.Date.Time) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "アイテムの開始時間が指定した日と一致しません"})
+		return
+	}
+
+	item, ok := createPlanItem(c, plan, input)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": item})
+}
+
+// findTripDay パスパラメータ day に対応する日の行程を返す
+// 失敗した場合はエラーレスポンスを書き込み、falseを返す
+func findTripDay(c *gin.Context, plan *models.TravelPlan) (*models.TripDay, bool) {
+	n, err := strconv.Atoi(c.Param("day"))
+	if err != nil || n < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効な日数です"})
+		return nil, false
+	}
+
+	days := plan.Days()
+	if n > len(days) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "指定した日の行程が見つかりません"})
+		return nil, false
+	}
+
+	return &days[n-1], true
+}
@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"backend/models"
+	"backend/utils/geo"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPlanLegs 位置のわかる連続したアイテムの間の移動距離と移動時間を見積もり、間に合わない区間を示す
+// mode を指定するとすべての区間をその移動手段で見積もる（未指定の場合は移動アイテムや距離から選ぶ）
+func GetPlanLegs(c *gin.Context) {
+	mode := geo.Mode(c.Query("mode"))
+	if mode != "" && !geo.ValidMode(mode) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効な移動手段です"})
+		return
+	}
+
+	plan, ok := findViewablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	report, err := plan.Legs(c.Request.Context(), models.Router, mode)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "移動時間の見積もりに失敗しました"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
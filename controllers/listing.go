@@ -0,0 +1,313 @@
+package controllers
+
+import (
+	"backend/models"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageLimit = 20  // limit未指定時の件数
+	maxPageLimit     = 100 // limitの上限
+)
+
+var (
+	errLimit  = errors.New("limitには1以上の整数を指定してください")
+	errOffset = errors.New("offsetには0以上の整数を指定してください")
+)
+
+// sortKey 一覧の並び替えに使えるキー
+type sortKey struct {
+	column string                                 // 並び替えに使うカラム
+	value  func(plan *models.TravelPlan) any      // カーソルに保存する値
+	parse  func(raw json.RawMessage) (any, error) // カーソルから値を復元する
+}
+
+// planSortKeys 並び替えに使えるキーの一覧（同じ値の場合はIDで並べる）
+var planSortKeys = map[string]sortKey{
+	"createdAt": {
+		column: "travel_plans.created_at",
+		value:  func(p *models.TravelPlan) any { return p.CreatedAt },
+		parse:  parseCursorTime,
+	},
+	"updatedAt": {
+		column: "travel_plans.updated_at",
+		value:  func(p *models.TravelPlan) any { return p.UpdatedAt },
+		parse:  parseCursorTime,
+	},
+	"totalCost": {
+		column: "travel_plans.total_cost",
+		value:  func(p *models.TravelPlan) any { return p.TotalCost },
+		parse:  parseCursorInt,
+	},
+	"title": {
+		column: "travel_plans.title",
+		value:  func(p *models.TravelPlan) any { return p.Title },
+		parse:  parseCursorString,
+	},
+	"popular": {
+		column: "travel_plans.like_count",
+		value:  func(p *models.TravelPlan) any { return p.LikeCount },
+		parse:  parseCursorInt,
+	},
+	"trending": {
+		column: "travel_plans.trending_score",
+		value:  func(p *models.TravelPlan) any { return p.TrendingScore },
+		parse:  parseCursorFloat,
+	},
+	"topRated": {
+		column: "travel_plans.rating_score",
+		value:  func(p *models.TravelPlan) any { return p.RatingScore },
+		parse:  parseCursorFloat,
+	},
+}
+
+// planCursor 次のページの開始位置
+type planCursor struct {
+	Sort  string          `json:"s"`  // 並び替えキー
+	Desc  bool            `json:"d"`  // 降順かどうか
+	Value json.RawMessage `json:"v"`  // 最後のプランの並び替えキーの値
+	ID    string          `json:"id"` // 最後のプランのID
+}
+
+// listParams 一覧取得のパラメータ
+type listParams struct {
+	Sort   string
+	Desc   bool
+	Limit  int
+	Cursor *planCursor
+}
+
+// listFilters 一覧の絞り込み条件
+type listFilters struct {
+	Statuses []string
+	MinCost  *int
+	MaxCost  *int
+	From     models.Date
+	To       models.Date
+}
+
+// parseListParams クエリパラメータから並び替え・件数・カーソルを取得する
+// sort は "createdAt" のように指定し、先頭に "-" を付けると降順になる（未指定時は defaultSort）
+func parseListParams(c *gin.Context, defaultSort string) (listParams, error) {
+	params := listParams{Limit: defaultPageLimit}
+
+	sort := c.DefaultQuery("sort", defaultSort)
+	if strings.HasPrefix(sort, "-") {
+		params.Desc = true
+		sort = sort[1:]
+	}
+	if _, ok := planSortKeys[sort]; !ok {
+		return params, fmt.Errorf("無効な並び替えキーです: %s", sort)
+	}
+	params.Sort = sort
+
+	if s := c.Query("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 {
+			return params, errLimit
+		}
+		params.Limit = min(limit, maxPageLimit)
+	}
+
+	if s := c.Query("cursor"); s != "" {
+		cursor, err := decodeCursor(s)
+		if err != nil || cursor.Sort != params.Sort || cursor.Desc != params.Desc {
+			return params, errors.New("無効なカーソルです")
+		}
+		params.Cursor = cursor
+	}
+
+	return params, nil
+}
+
+// parseListFilters クエリパラメータから絞り込み条件を取得する
+func parseListFilters(c *gin.Context) (listFilters, error) {
+	var filters listFilters
+
+	for _, status := range queryList(c, "status") {
+		if !validStatuses[status] {
+			return filters, fmt.Errorf("無効なステータスです: %s", status)
+		}
+		filters.Statuses = append(filters.Statuses, status)
+	}
+
+	for key, dst := range map[string]**int{"minCost": &filters.MinCost, "maxCost": &filters.MaxCost} {
+		if s := c.Query(key); s != "" {
+			v, err := strconv.Atoi(s)
+			if err != nil {
+				return filters, fmt.Errorf("%sには整数を指定してください", key)
+			}
+			*dst = &v
+		}
+	}
+
+	for key, dst := range map[string]*models.Date{"from": &filters.From, "to": &filters.To} {
+		if s := c.Query(key); s != "" {
+			d, err := models.ParseDate(s)
+			if err != nil {
+				return filters, fmt.Errorf("%sにはYYYY-MM-DD形式の日付を指定してください", key)
+			}
+			*dst = d
+		}
+	}
+
+	return filters, nil
+}
+
+// scope 絞り込み条件をクエリに適用するスコープを返す
+func (f listFilters) scope() func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(f.Statuses) > 0 {
+			db = db.Where("travel_plans.status IN ?", f.Statuses)
+		}
+		if f.MinCost != nil {
+			db = db.Where("travel_plans.total_cost >= ?", *f.MinCost)
+		}
+		if f.MaxCost != nil {
+			db = db.Where("travel_plans.total_cost <= ?", *f.MaxCost)
+		}
+		// 旅行期間が指定した期間と重なるプランに絞り込む（終了日未設定の場合は開始日のみの旅行とみなす）
+		if !f.From.IsZero() {
+			db = db.Where("COALESCE(travel_plans.end_date, travel_plans.start_date) >= ?", f.From)
+		}
+		if !f.To.IsZero() {
+			db = db.Where("travel_plans.start_date <= ?", f.To)
+		}
+		return db
+	}
+}
+
+// scope カーソル以降のプランに絞り込み、並び替えと件数を適用するスコープを返す
+// 次のページがあるか判定するために1件多く取得する
+func (p listParams) scope() func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		key := planSortKeys[p.Sort]
+		op, dir := ">", "asc"
+		if p.Desc {
+			op, dir = "<", "desc"
+		}
+
+		if p.Cursor != nil {
+			value, err := key.parse(p.Cursor.Value)
+			if err != nil {
+				_ = db.AddError(err)
+				return db
+			}
+			db = db.Where(
+				fmt.Sprintf("(%s %s ?) OR (%s = ? AND travel_plans.id %s ?)", key.column, op, key.column, op),
+				value, value, p.Cursor.ID)
+		}
+
+		return db.Order(key.column + " " + dir).Order("travel_plans.id " + dir).Limit(p.Limit + 1)
+	}
+}
+
+// nextCursor 取得したプランから次のページのカーソルを作成し、余分に取得した1件を取り除く
+func (p listParams) nextCursor(plans []models.TravelPlan) ([]models.TravelPlan, *string) {
+	if len(plans) <= p.Limit {
+		return plans, nil
+	}
+	plans = plans[:p.Limit]
+	last := &plans[len(plans)-1]
+
+	value, _ := json.Marshal(planSortKeys[p.Sort].value(last))
+	cursor := encodeCursor(planCursor{Sort: p.Sort, Desc: p.Desc, Value: value, ID: last.ID})
+	return plans, &cursor
+}
+
+// encodeCursor カーソルをURLに含められる文字列にする
+func encodeCursor(cursor planCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor 文字列からカーソルを復元する
+func decodeCursor(s string) (*planCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var cursor planCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+	if _, ok := planSortKeys[cursor.Sort]; !ok || cursor.ID == "" {
+		return nil, errors.New("invalid cursor")
+	}
+	return &cursor, nil
+}
+
+func parseCursorTime(raw json.RawMessage) (any, error) {
+	var t time.Time
+	err := json.Unmarshal(raw, &t)
+	return t, err
+}
+
+func parseCursorInt(raw json.RawMessage) (any, error) {
+	var n int
+	err := json.Unmarshal(raw, &n)
+	return n, err
+}
+
+func parseCursorFloat(raw json.RawMessage) (any, error) {
+	var f float64
+	err := json.Unmarshal(raw, &f)
+	return f, err
+}
+
+func parseCursorString(raw json.RawMessage) (any, error) {
+	var s string
+	err := json.Unmarshal(raw, &s)
+	return s, err
+}
+
+// listPlans 絞り込み済みのクエリに一覧の条件を適用し、プラン一覧・総件数・ファセットを返す
+func listPlans(c *gin.Context, query *gorm.DB, defaultSort string) {
+	params, err := parseListParams(c, defaultSort)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filters, err := parseListFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query = query.Scopes(filters.scope()).Session(&gorm.Session{})
+
+	// 総件数とファセットはカーソルに関係なく条件に一致するすべてのプランで集計する
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "プランの取得に失敗しました"})
+		return
+	}
+	facets, err := models.CountFacets(query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "プランの取得に失敗しました"})
+		return
+	}
+
+	var plans []models.TravelPlan
+	if err := query.Scopes(params.scope()).Preload("Category").Preload("Tags").Find(&plans).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "プランの取得に失敗しました"})
+		return
+	}
+	plans, next := params.nextCursor(plans)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       plans,
+		"nextCursor": next,
+		"total":      total,
+		"facets":     facets,
+	})
+}
@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"backend/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// newQueryContext 指定したクエリ文字列を持つテスト用のコンテキストを作成する
+func newQueryContext(rawQuery string) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest("GET", "/api/public-plans?"+rawQuery, nil)
+	return c
+}
+
+// TestParseListParams 並び替え・件数のパラメータ解析のテスト
+func TestParseListParams(t *testing.T) {
+	params, err := parseListParams(newQueryContext(""), "-createdAt")
+	assert.NoError(t, err)
+	assert.Equal(t, "createdAt", params.Sort)
+	assert.True(t, params.Desc)
+	assert.Equal(t, defaultPageLimit, params.Limit)
+
+	params, err = parseListParams(newQueryContext("sort=totalCost&limit=1000"), "-createdAt")
+	assert.NoError(t, err)
+	assert.Equal(t, "totalCost", params.Sort)
+	assert.False(t, params.Desc)
+	assert.Equal(t, maxPageLimit, params.Limit)
+
+	_, err = parseListParams(newQueryContext("sort=password"), "-createdAt")
+	assert.Error(t, err)
+
+	_, err = parseListParams(newQueryContext("limit=0"), "-createdAt")
+	assert.Error(t, err)
+}
+
+// TestNextCursor 次のページのカーソル作成と復元のテスト
+func TestNextCursor(t *testing.T) {
+	created := time.Date(2026, 10, 18, 9, 30, 0, 123000000, time.UTC)
+	plans := []models.TravelPlan{
+		{ID: "a", TotalCost: 3000, CreatedAt: created},
+		{ID: "b", TotalCost: 2000, CreatedAt: created},
+		{ID: "c", TotalCost: 1000, CreatedAt: created},
+	}
+	params := listParams{Sort: "createdAt", Desc: true, Limit: 2}
+
+	page, next := params.nextCursor(plans)
+	assert.Equal(t, 2, len(page))
+	assert.NotNil(t, next)
+
+	// 同じ並び替え条件であればカーソルを受け付ける
+	c := newQueryContext("sort=-createdAt&limit=2&cursor=" + *next)
+	restored, err := parseListParams(c, "-createdAt")
+	assert.NoError(t, err)
+	assert.Equal(t, "b", restored.Cursor.ID)
+	value, err := planSortKeys["createdAt"].parse(restored.Cursor.Value)
+	assert.NoError(t, err)
+	assert.True(t, created.Equal(value.(time.Time)))
+
+	// 並び替え条件が異なるカーソルは拒否する
+	_, err = parseListParams(newQueryContext("sort=title&cursor="+*next), "-createdAt")
+	assert.Error(t, err)
+
+	// 最後のページではカーソルを返さない
+	_, next = params.nextCursor(plans[:2])
+	assert.Nil(t, next)
+}
+
+// TestParseListFilters 絞り込み条件の解析のテスト
+func TestParseListFilters(t *testing.T) {
+	filters, err := parseListFilters(newQueryContext("status=draft,confirmed&minCost=1000&maxCost=50000&from=2026-11-01"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"draft", "confirmed"}, filters.Statuses)
+	assert.Equal(t, 1000, *filters.MinCost)
+	assert.Equal(t, 50000, *filters.MaxCost)
+	assert.Equal(t, "2026-11-01", filters.From.String())
+	assert.True(t, filters.To.IsZero())
+
+	_, err = parseListFilters(newQueryContext("status=archived"))
+	assert.Error(t, err)
+
+	_, err = parseListFilters(newQueryContext("to=11/01"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"backend/models"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPlanMembers プランの作成者とメンバーの一覧を取得する
+func GetPlanMembers(c *gin.Context) {
+	plan, ok := findViewablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	members, err := models.PlanMembers(plan)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "メンバーの取得に失敗しました"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": members})
+}
+
+// AddPlanMember ユーザー名を指定してプランにメンバーを追加する（作成者のみ）
+func AddPlanMember(c *gin.Context) {
+	var input struct {
+		Username string `json:"username" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, ok := findEditablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var user models.User
+	if err := models.DB.Where("username = ?", strings.ToLower(input.Username)).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ユーザーが見つかりません"})
+		return
+	}
+	if models.IsPlanMember(plan, user.ID) {
+		c.JSON(http.StatusConflict, gin.H{"error": "このユーザーはすでにメンバーです"})
+		return
+	}
+
+	member := models.PlanMember{PlanID: plan.ID, UserID: user.ID, Username: user.Username}
+	if err := models.DB.Create(&member).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "メンバーの追加に失敗しました"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": member})
+}
+
+// RemovePlanMember プランからメンバーを外す（作成者、またはメンバー本人のみ）
+func RemovePlanMember(c *gin.Context) {
+	var plan models.TravelPlan
+	if err := models.DB.First(&plan, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "プランが見つかりません"})
+		return
+	}
+
+	memberId, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なユーザーIDです"})
+		return
+	}
+
+	userId := currentUserID(c)
+	if userId != plan.CreatorID && uint(memberId) != userId {
+		c.JSON(http.StatusForbidden, gin.H{"error": "このメンバーを外す権限がありません"})
+		return
+	}
+
+	result := models.DB.Where("plan_id = ? AND user_id = ?", plan.ID, memberId).Delete(&models.PlanMember{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "メンバーの削除に失敗しました"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "メンバーが見つかりません"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": "メンバーを外しました"})
+}
@@ -0,0 +1,541 @@
+package controllers
+
+import (
+	"backend/models"
+	"backend/utils/jsonpatch"
+	"backend/utils/realtime"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+	maxTitleLength        = 255
+)
+
+// planDocument PATCHで変更できるプランのフィールド
+// null（またはフィールドの削除）は、startDate・endDate・categoryIdでは未設定、
+// description・tagsでは空、timeZoneでは既定のタイムゾーンを表す。それ以外のフィールドでは指定できない
+type planDocument struct {
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	TotalCost   int         `json:"totalCost"`
+	StartDate   models.Date `json:"startDate"`
+	EndDate     models.Date `json:"endDate"`
+	TimeZone    string      `json:"timeZone"`
+	IsPublic    bool        `json:"isPublic"`
+	Status      string      `json:"status"`
+	CategoryID  *uint       `json:"categoryId"`
+	Tags        []string    `json:"tags"`
+}
+
+// itemDocument PATCHで変更できるアイテムのフィールド
+// null（またはフィールドの削除）は、description・location・notesでは空、timeZoneではプランのタイムゾーン、
+// durationでは開始・終了時間から求めた値、latitude・longitude・placeIdでは未設定を表す。それ以外のフィールドでは指定できない
+type itemDocument struct {
+	Type        string    `json:"type"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Location    string    `json:"location"`
+	Latitude    *float64  `json:"latitude"`
+	Longitude   *float64  `json:"longitude"`
+	PlaceID     *uint     `json:"placeId"`
+	StartTime   time.Time `json:"startTime"`
+	EndTime     time.Time `json:"endTime"`
+	TimeZone    string    `json:"timeZone"`
+	Duration    *int      `json:"duration"`
+	Cost        int       `json:"cost"`
+	Notes       string    `json:"notes"`
+	Order       int       `json:"order"`
+}
+
+// PatchPlan プランをJSON Merge Patch (RFC 7396) またはJSON Patch (RFC 6902) で部分的に更新する
+func PatchPlan(c *gin.Context) {
+	plan, ok := findEditablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	// 他のユーザーによる更新がないか確認
+	if !checkIfMatch(c, planETag(plan)) {
+		return
+	}
+
+	if err := models.DB.Model(plan).Association("Tags").Find(&plan.Tags); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "プランの取得に失敗しました"})
+		return
+	}
+
+	current := planDocument{
+		Title:       plan.Title,
+		Description: plan.Description,
+		TotalCost:   plan.TotalCost,
+		StartDate:   plan.StartDate,
+		EndDate:     plan.EndDate,
+		TimeZone:    plan.TimeZone,
+		IsPublic:    plan.IsPublic,
+		Status:      plan.Status,
+		CategoryID:  plan.CategoryID,
+		Tags:        []string{},
+	}
+	for _, tag := range plan.Tags {
+		current.Tags = append(current.Tags, tag.Name)
+	}
+
+	doc, ok := applyPatch(c, current)
+	if !ok {
+		return
+	}
+
+	next := planDocument{
+		Title:       doc.text("title", true, maxTitleLength),
+		Description: doc.text("description", false, 0),
+		TotalCost:   doc.integer("totalCost", 0),
+		StartDate:   doc.date("startDate"),
+		EndDate:     doc.date("endDate"),
+		TimeZone:    doc.text("timeZone", false, 64),
+		IsPublic:    doc.boolean("isPublic"),
+		Status:      doc.text("status", true, 0),
+		CategoryID:  doc.id("categoryId"),
+		Tags:        models.NormalizeTagNames(doc.list("tags")),
+	}
+	if next.TimeZone == "" {
+		next.TimeZone = models.DefaultTimeZone
+	}
+	if _, ok := doc.errors["timeZone"]; !ok && !models.ValidTimeZone(next.TimeZone) {
+		doc.errors["timeZone"] = "無効なタイムゾーンです"
+	}
+	if _, ok := doc.errors["status"]; !ok && !validStatuses[next.Status] {
+		doc.errors["status"] = "無効なステータスです"
+	}
+	if _, ok := doc.errors["categoryId"]; !ok && !validCategory(next.CategoryID) {
+		doc.errors["categoryId"] = "カテゴリが見つかりません"
+	}
+	if !validPlanDates(next.StartDate, next.EndDate) {
+		doc.errors["endDate"] = "終了日は開始日以降の日付を指定してください"
+	}
+	if !doc.valid(c) {
+		return
+	}
+
+	// 変更がなければ何もしない
+	if reflect.DeepEqual(current, next) {
+		c.Header("ETag", planETag(plan))
+		c.JSON(http.StatusOK, gin.H{"data": plan})
+		return
+	}
+
+	userId := currentUserID(c)
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := models.BumpPlanVersion(tx, plan.ID, plan.Version); err != nil {
+			return err
+		}
+		// ゼロ値（false・0・空文字）やnullも更新できるようにmapで更新する
+		err := tx.Model(plan).Updates(map[string]interface{}{
+			"title":       next.Title,
+			"description": next.Description,
+			"total_cost":  next.TotalCost,
+			"start_date":  next.StartDate,
+			"end_date":    next.EndDate,
+			"time_zone":   next.TimeZone,
+			"is_public":   next.IsPublic,
+			"status":      next.Status,
+			"category_id": next.CategoryID,
+			"updated_at":  time.Now(),
+		}).Error
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(current.Tags, next.Tags) {
+			tags, err := models.FindOrCreateTags(tx, next.Tags)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(plan).Association("Tags").Replace(tags); err != nil {
+				return err
+			}
+		}
+		return models.RecordRevision(tx, plan.ID, userId, models.RevisionPlanUpdated)
+	})
+	if err != nil {
+		writeUpdateError(c, err, "プランの更新に失敗しました")
+		return
+	}
+	models.ReindexPlan(plan.ID)
+
+	updated, ok := findViewablePlan(c, plan.ID)
+	if !ok {
+		return
+	}
+
+	publishPlanEvent(plan.ID, userId, realtime.EventPlanUpdated, updated)
+	c.Header("ETag", planETag(updated))
+	c.JSON(http.StatusOK, gin.H{"data": updated})
+}
+
+// PatchPlanItem アイテムをJSON Merge Patch (RFC 7396) またはJSON Patch (RFC 6902) で部分的に更新する
+func PatchPlanItem(c *gin.Context) {
+	plan, ok := findEditablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	item, ok := findPlanItem(c, plan, c.Param("itemId"))
+	if !ok {
+		return
+	}
+
+	// 他のユーザーによる更新がないか確認
+	if !checkIfMatch(c, itemETag(item)) {
+		return
+	}
+
+	current := itemDocument{
+		Type:        item.Type,
+		Title:       item.Title,
+		Description: item.Description,
+		Location:    item.Location,
+		Latitude:    item.Latitude,
+		Longitude:   item.Longitude,
+		PlaceID:     item.PlaceID,
+		StartTime:   item.StartTime,
+		EndTime:     item.EndTime,
+		TimeZone:    item.TimeZone,
+		Duration:    &item.Duration,
+		Cost:        item.Cost,
+		Notes:       item.Notes,
+		Order:       item.Order,
+	}
+
+	doc, ok := applyPatch(c, current)
+	if !ok {
+		return
+	}
+
+	next := itemDocument{
+		Type:        doc.text("type", true, 50),
+		Title:       doc.text("title", true, maxTitleLength),
+		Description: doc.text("description", false, 0),
+		Location:    doc.text("location", false, 0),
+		Latitude:    doc.number("latitude"),
+		Longitude:   doc.number("longitude"),
+		PlaceID:     doc.id("placeId"),
+		StartTime:   doc.timestamp("startTime"),
+		EndTime:     doc.timestamp("endTime"),
+		TimeZone:    doc.text("timeZone", false, 64),
+		Duration:    doc.optionalInteger("duration", 0),
+		Cost:        doc.integer("cost", 0),
+		Notes:       doc.text("notes", false, 0),
+		Order:       doc.integer("order", 0),
+	}
+	if _, ok := doc.errors["timeZone"]; !ok && !models.ValidTimeZone(next.TimeZone) {
+		doc.errors["timeZone"] = "無効なタイムゾーンです"
+	}
+	if next.EndTime.Before(next.StartTime) {
+		doc.errors["endTime"] = "終了時間は開始時間以降を指定してください"
+	}
+	if !models.ValidCoordinates(next.Latitude, next.Longitude) {
+		doc.errors["latitude"] = errCoordinates.Error()
+	}
+	// 場所を指定した場合は、POST・PUTと同じく場所の名前と位置で空の場所・緯度経度を補う
+	if _, ok := doc.errors["placeId"]; !ok && next.PlaceID != nil {
+		if place, err := models.FindPlace(models.DB, *next.PlaceID); err != nil {
+			doc.errors["placeId"] = "場所が見つかりません"
+		} else {
+			applied := models.PlanItem{Location: next.Location, Latitude: next.Latitude, Longitude: next.Longitude}
+			applied.ApplyPlace(place)
+			next.Location, next.Latitude, next.Longitude = applied.Location, applied.Latitude, applied.Longitude
+		}
+	}
+	probe := models.PlanItem{StartTime: next.StartTime, TimeZone: next.TimeZone}
+	if _, ok := doc.errors["startTime"]; !ok && !plan.ContainsDate(plan.LocalDate(&probe)) {
+		doc.errors["startTime"] = "アイテムの開始時間がプランの期間外です"
+	}
+	if !doc.valid(c) {
+		return
+	}
+	// nullの場合は開始・終了時間から求める（0を指定した場合はそのまま保存する）
+	if next.Duration == nil {
+		duration := int(next.EndTime.Sub(next.StartTime).Minutes())
+		next.Duration = &duration
+	}
+
+	// 変更がなければ何もしない（時刻はタイムゾーンの表記に関係なく比較する）
+	if next.StartTime.Equal(current.StartTime) && next.EndTime.Equal(current.EndTime) {
+		next.StartTime, next.EndTime = current.StartTime, current.EndTime
+		if reflect.DeepEqual(current, next) {
+			c.Header("ETag", itemETag(item))
+			c.JSON(http.StatusOK, gin.H{"data": item})
+			return
+		}
+	}
+
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := models.BumpItemVersion(tx, item.ID, item.Version); err != nil {
+			return err
+		}
+		if err := models.BumpPlanVersion(tx, plan.ID, plan.Version); err != nil {
+			return err
+		}
+		err := tx.Model(item).Updates(map[string]interface{}{
+			"type":        next.Type,
+			"title":       next.Title,
+			"description": next.Description,
+			"location":    next.Location,
+			"latitude":    next.Latitude,
+			"longitude":   next.Longitude,
+			"place_id":    next.PlaceID,
+			"start_time":  next.StartTime.UTC(),
+			"end_time":    next.EndTime.UTC(),
+			"time_zone":   next.TimeZone,
+			"duration":    *next.Duration,
+			"cost":        next.Cost,
+			"notes":       next.Notes,
+			"order":       next.Order,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return models.RecordRevision(tx, plan.ID, currentUserID(c), models.RevisionItemUpdated)
+	})
+	if err != nil {
+		writeUpdateError(c, err, "アイテムの更新に失敗しました")
+		return
+	}
+	models.ReindexPlan(plan.ID)
+
+	updated, ok := findPlanItem(c, plan, item.ID)
+	if !ok {
+		return
+	}
+
+	publishPlanEvent(plan.ID, currentUserID(c), realtime.EventItemUpdated, updated)
+	c.Header("ETag", itemETag(updated))
+	c.JSON(http.StatusOK, gin.H{"data": updated})
+}
+
+// patchedDocument パッチ適用後のドキュメントとフィールドごとの検証エラー
+type patchedDocument struct {
+	fields map[string]json.RawMessage
+	errors map[string]string
+}
+
+// applyPatch Content-Typeに応じてリクエストボディのパッチを現在の内容に適用する
+// 失敗した場合はエラーレスポンスを書き込み、falseを返す
+func applyPatch(c *gin.Context, current interface{}) (*patchedDocument, bool) {
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "リクエストボディを読み込めません"})
+		return nil, false
+	}
+	original, err := json.Marshal(current)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "パッチの適用に失敗しました"})
+		return nil, false
+	}
+
+	var patched []byte
+	switch c.ContentType() {
+	case mergePatchContentType:
+		patched, err = jsonpatch.MergePatch(original, body)
+	case jsonPatchContentType:
+		patched, err = jsonpatch.Apply(original, body)
+	default:
+		c.Header("Accept-Patch", mergePatchContentType+", "+jsonPatchContentType)
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error": fmt.Sprintf("Content-Typeには %s または %s を指定してください", mergePatchContentType, jsonPatchContentType),
+		})
+		return nil, false
+	}
+	if errors.Is(err, jsonpatch.ErrTestFailed) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	doc := &patchedDocument{errors: map[string]string{}}
+	if err := json.Unmarshal(patched, &doc.fields); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "パッチ適用後のドキュメントはオブジェクトである必要があります"})
+		return nil, false
+	}
+
+	// 変更できないフィールドや存在しないフィールドは受け付けない
+	known := map[string]bool{}
+	t := reflect.TypeOf(current)
+	for i := 0; i < t.NumField(); i++ {
+		known[strings.Split(t.Field(i).Tag.Get("json"), ",")[0]] = true
+	}
+	for name := range doc.fields {
+		if !known[name] {
+			doc.errors[name] = "変更できないフィールドです"
+		}
+	}
+
+	return doc, true
+}
+
+// valid 検証エラーがなければtrueを返す。エラーがある場合は400とフィールドごとのエラーを返す
+func (d *patchedDocument) valid(c *gin.Context) bool {
+	if len(d.errors) == 0 {
+		return true
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "入力内容に誤りがあります", "fields": d.errors})
+	return false
+}
+
+// value フィールドの値を取得する。値がない（削除された、またはnull）場合はfalseを返す
+// required が true の場合は値がないことをエラーとして記録する
+func (d *patchedDocument) value(name string, required bool) (json.RawMessage, bool) {
+	raw, ok := d.fields[name]
+	if !ok || string(raw) == "null" {
+		if required {
+			d.errors[name] = "必須です"
+		}
+		return nil, false
+	}
+	return raw, true
+}
+
+func (d *patchedDocument) decode(name string, raw json.RawMessage, dst interface{}, message string) bool {
+	if err := json.Unmarshal(raw, dst); err != nil {
+		d.errors[name] = message
+		return false
+	}
+	return true
+}
+
+// text 文字列のフィールドを取得する（maxLenが0の場合は長さを制限しない）
+func (d *patchedDocument) text(name string, required bool, maxLen int) string {
+	raw, ok := d.value(name, required)
+	if !ok {
+		return ""
+	}
+	var s string
+	if !d.decode(name, raw, &s, "文字列を指定してください") {
+		return ""
+	}
+	if required && strings.TrimSpace(s) == "" {
+		d.errors[name] = "必須です"
+	}
+	if maxLen > 0 && utf8.RuneCountInString(s) > maxLen {
+		d.errors[name] = fmt.Sprintf("%d文字以内で指定してください", maxLen)
+	}
+	return s
+}
+
+// integer min以上の整数のフィールドを取得する（必須）
+func (d *patchedDocument) integer(name string, min int) int {
+	raw, ok := d.value(name, true)
+	if !ok {
+		return 0
+	}
+	var n int
+	if !d.decode(name, raw, &n, "整数を指定してください") {
+		return 0
+	}
+	if n < min {
+		d.errors[name] = fmt.Sprintf("%d以上を指定してください", min)
+	}
+	return n
+}
+
+// optionalInteger min以上の整数のフィールドを取得する（nullは未設定）
+func (d *patchedDocument) optionalInteger(name string, min int) *int {
+	raw, ok := d.value(name, false)
+	if !ok {
+		return nil
+	}
+	var n int
+	if !d.decode(name, raw, &n, "整数を指定してください") {
+		return nil
+	}
+	if n < min {
+		d.errors[name] = fmt.Sprintf("%d以上を指定してください", min)
+	}
+	return &n
+}
+
+// boolean 真偽値のフィールドを取得する（必須）
+func (d *patchedDocument) boolean(name string) bool {
+	raw, ok := d.value(name, true)
+	if !ok {
+		return false
+	}
+	var b bool
+	d.decode(name, raw, &b, "true または false を指定してください")
+	return b
+}
+
+// date 日付のフィールドを取得する（nullは未設定）
+func (d *patchedDocument) date(name string) models.Date {
+	raw, ok := d.value(name, false)
+	if !ok {
+		return models.Date{}
+	}
+	var date models.Date
+	d.decode(name, raw, &date, "YYYY-MM-DD形式の日付を指定してください")
+	return date
+}
+
+// number 数値のフィールドを取得する（nullは未設定）
+func (d *patchedDocument) number(name string) *float64 {
+	raw, ok := d.value(name, false)
+	if !ok {
+		return nil
+	}
+	var f float64
+	if !d.decode(name, raw, &f, "数値を指定してください") {
+		return nil
+	}
+	return &f
+}
+
+// timestamp RFC 3339形式の日時のフィールドを取得する（必須）
+func (d *patchedDocument) timestamp(name string) time.Time {
+	raw, ok := d.value(name, true)
+	if !ok {
+		return time.Time{}
+	}
+	var t time.Time
+	d.decode(name, raw, &t, "RFC 3339形式の日時を指定してください")
+	return t
+}
+
+// id IDのフィールドを取得する（nullは未設定）
+func (d *patchedDocument) id(name string) *uint {
+	raw, ok := d.value(name, false)
+	if !ok {
+		return nil
+	}
+	var id uint
+	if !d.decode(name, raw, &id, "IDを指定してください") {
+		return nil
+	}
+	return &id
+}
+
+// list 文字列の配列のフィールドを取得する（nullは空）
+func (d *patchedDocument) list(name string) []string {
+	raw, ok := d.value(name, false)
+	if !ok {
+		return []string{}
+	}
+	var values []string
+	if !d.decode(name, raw, &values, "文字列の配列を指定してください") {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestApplyPatch パッチの適用とフィールドごとの検証のテスト
+func TestApplyPatch(t *testing.T) {
+	router := setupTestRouter()
+	current := planDocument{Title: "京都旅行", TotalCost: 5000, IsPublic: true, Status: "draft", Tags: []string{}}
+
+	router.PATCH("/api/plans/:id", func(c *gin.Context) {
+		doc, ok := applyPatch(c, current)
+		if !ok {
+			return
+		}
+		next := planDocument{
+			Title:     doc.text("title", true, maxTitleLength),
+			TotalCost: doc.integer("totalCost", 0),
+			IsPublic:  doc.boolean("isPublic"),
+			StartDate: doc.date("startDate"),
+		}
+		if !doc.valid(c) {
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": next})
+	})
+
+	send := func(contentType, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("PATCH", "/api/plans/test-plan-1", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// false や 0 も反映される
+	w := send(mergePatchContentType, `{"isPublic":false,"totalCost":0}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"isPublic":false`)
+	assert.Contains(t, w.Body.String(), `"totalCost":0`)
+	assert.Contains(t, w.Body.String(), `"title":"京都旅行"`)
+
+	// JSON Patch
+	w = send(jsonPatchContentType, `[{"op":"test","path":"/title","value":"京都旅行"},{"op":"replace","path":"/title","value":"大阪旅行"}]`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"title":"大阪旅行"`)
+
+	// testが失敗した場合は409
+	w = send(jsonPatchContentType, `[{"op":"test","path":"/title","value":"大阪旅行"}]`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// 必須フィールドのnullや不正な値、未知のフィールドはフィールドごとのエラーになる
+	w = send(mergePatchContentType, `{"title":null,"totalCost":-1,"startDate":"2025/01/01","creatorId":2}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"title"`)
+	assert.Contains(t, w.Body.String(), `"totalCost"`)
+	assert.Contains(t, w.Body.String(), `"startDate"`)
+	assert.Contains(t, w.Body.String(), `"creatorId"`)
+
+	// 対応していないContent-Typeは415
+	w = send("application/json", `{"title":"大阪旅行"}`)
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Accept-Patch"))
+}
+
+// TestApplyPatchOptionalInteger nullは未設定、0はそのままの値として扱うことを確認する
+func TestApplyPatchOptionalInteger(t *testing.T) {
+	router := setupTestRouter()
+	duration := 90
+	current := itemDocument{Title: "清水寺", Duration: &duration}
+
+	router.PATCH("/api/plans/:id/items/:itemId", func(c *gin.Context) {
+		doc, ok := applyPatch(c, current)
+		if !ok {
+			return
+		}
+		next := doc.optionalInteger("duration", 0)
+		if !doc.valid(c) {
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": next})
+	})
+
+	send := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("PATCH", "/api/plans/test-plan-1/items/test-item-1", strings.NewReader(body))
+		req.Header.Set("Content-Type", mergePatchContentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(`{"title":"金閣寺"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data":90}`, w.Body.String())
+
+	w = send(`{"duration":null}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data":null}`, w.Body.String())
+
+	w = send(`{"duration":0}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data":0}`, w.Body.String())
+
+	w = send(`{"duration":-1}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"duration"`)
+}
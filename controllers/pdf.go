@@ -0,0 +1,325 @@
+package controllers
+
+import (
+	"backend/models"
+	"backend/utils/pdf"
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 印刷用の行程表のレイアウト（ポイント）
+const (
+	pdfMargin       = 48.0  // 用紙の余白
+	pdfFooterHeight = 28.0  // ページ番号の領域
+	pdfTimeColumn   = 100.0 // 時刻の列の幅
+)
+
+// 行程表の文字の書式
+var (
+	pdfTitleStyle   = pdf.Style{Size: 20, Bold: true}
+	pdfHeadingStyle = pdf.Style{Size: 12, Bold: true}
+	pdfItemStyle    = pdf.Style{Size: 11, Bold: true}
+	pdfBodyStyle    = pdf.Style{Size: 9.5}
+	pdfMutedStyle   = pdf.Style{Size: 9, Gray: 0.4}
+	pdfFooterStyle  = pdf.Style{Size: 8, Gray: 0.5}
+)
+
+// itemTypeLabels アイテムの種類の表示名
+var itemTypeLabels = map[string]string{
+	models.ItemTypeVisit:     "訪問",
+	models.ItemTypeTransport: "移動",
+	models.ItemTypeMeal:      "食事",
+	models.ItemTypeLodging:   "宿泊",
+}
+
+// statusLabels プランのステータスの表示名
+var statusLabels = map[string]string{
+	"draft":     "下書き",
+	"confirmed": "確定",
+	"completed": "完了",
+	"cancelled": "中止",
+}
+
+var weekdayLabels = [...]string{"日", "月", "火", "水", "木", "金", "土"}
+
+// pdfFont 行程表のPDFに埋め込むフォント（nilの場合は埋め込まずに標準の日本語フォントを参照する）
+var pdfFont *pdf.Font
+
+// LoadPDFFont 行程表のPDFに埋め込むTrueTypeのフォントを読み込む（path が空の場合は埋め込まない）
+// 埋め込まない場合、日本語を表示できるのは Adobe-Japan1 のフォントを備えるPDFビューアに限られる
+func LoadPDFFont(path string) error {
+	if path == "" {
+		return nil
+	}
+	font, err := pdf.LoadFont(path)
+	if err != nil {
+		return err
+	}
+	pdfFont = font
+	return nil
+}
+
+// ExportPlanPDF プランを日ごとの行程表として印刷用のPDFで出力する
+func ExportPlanPDF(c *gin.Context) {
+	plan, ok := findViewablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	// 変更がなければ304を返す
+	if notModified(c, planViewETag(plan)) {
+		return
+	}
+
+	var b bytes.Buffer
+	if err := renderItineraryPDF(plan).Encode(&b); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "PDFの作成に失敗しました"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s.pdf"`, plan.ID))
+	c.Data(http.StatusOK, "application/pdf", b.Bytes())
+}
+
+// itineraryPDF 行程表を上から順に書き込み、ページに収まらなくなったら改ページする
+type itineraryPDF struct {
+	doc  *pdf.Document
+	page *pdf.Page
+	y    float64 // 次に書き込む位置（用紙の上端からの距離）
+}
+
+// renderItineraryPDF プランの行程表のPDFを作成する（プランは Localize 済みであること）
+func renderItineraryPDF(plan *models.TravelPlan) *pdf.Document {
+	w := &itineraryPDF{doc: pdf.New(pdf.A4Width, pdf.A4Height)}
+	w.doc.Title = plan.Title
+	w.doc.Font = pdfFont
+	w.doc.Created = plan.UpdatedAt.In(plan.Location())
+	w.newPage()
+
+	width := pdf.A4Width - pdfMargin*2
+	w.paragraph(pdfMargin, width, plan.Title, pdfTitleStyle, 1.3)
+	w.y += 4
+	w.paragraph(pdfMargin, width, planSummaryLine(plan), pdfMutedStyle, 1.5)
+	if plan.Description != "" {
+		w.y += 4
+		w.paragraph(pdfMargin, width, plan.Description, pdfBodyStyle, 1.6)
+	}
+	w.y += 8
+	w.page.Line(pdfMargin, w.y, pdfMargin+width, w.y, 0.8, 0)
+	w.y += 14
+
+	itemsCost := 0
+	days := plan.Days()
+	for _, day := range days {
+		w.day(plan, day)
+		itemsCost += day.TotalCost
+	}
+	if len(days) == 0 {
+		w.paragraph(pdfMargin, width, "予定はありません", pdfMutedStyle, 1.5)
+	}
+
+	// 合計費用
+	w.ensure(40)
+	w.y += 6
+	w.page.Line(pdfMargin, w.y, pdfMargin+width, w.y, 0.8, 0)
+	w.y += 20
+	total := "合計費用 " + formatYen(plan.TotalCost)
+	w.page.Text(pdfMargin+width-pdf.TextWidth(total, pdfHeadingStyle.Size), w.y, total, pdfHeadingStyle)
+	if itemsCost != plan.TotalCost {
+		w.y += 16
+		note := "（アイテムの費用の合計 " + formatYen(itemsCost) + "）"
+		w.page.Text(pdfMargin+width-pdf.TextWidth(note, pdfMutedStyle.Size), w.y, note, pdfMutedStyle)
+	}
+
+	w.footers()
+	return w.doc
+}
+
+// day 1日分の見出しとアイテムを書き込む
+func (w *itineraryPDF) day(plan *models.TravelPlan, day models.TripDay) {
+	width := pdf.A4Width - pdfMargin*2
+
+	// 見出しの直後で改ページしないように、最初のアイテムの分の余白も確保する
+	w.ensure(80)
+	w.page.Rect(pdfMargin, w.y, width, 22, 0.92)
+	heading := fmt.Sprintf("%d日目  %s", day.Day, formatJapaneseDate(day.Date))
+	w.page.Text(pdfMargin+8, w.y+15.5, heading, pdfHeadingStyle)
+	if day.TotalCost > 0 {
+		cost := formatYen(day.TotalCost)
+		w.page.Text(pdfMargin+width-8-pdf.TextWidth(cost, pdfBodyStyle.Size), w.y+15, cost, pdfBodyStyle)
+	}
+	w.y += 34
+
+	if len(day.Items) == 0 {
+		w.paragraph(pdfMargin+8, width-8, "予定はありません", pdfMutedStyle, 1.5)
+		w.y += 8
+		return
+	}
+
+	for i := range day.Items {
+		w.item(plan, &day.Items[i])
+	}
+	w.y += 4
+}
+
+// pdfLine アイテムの1行分の内容
+type pdfLine struct {
+	x      float64
+	text   string
+	style  pdf.Style
+	height float64
+}
+
+// item アイテムを左に時刻、右に内容の2列で書き込む
+// アイテムがページをまたがないように、収まらない場合は先に改ページする
+func (w *itineraryPDF) item(plan *models.TravelPlan, item *models.PlanItem) {
+	left := pdfMargin + 8
+	right := pdfMargin + pdfTimeColumn
+	width := pdf.A4Width - pdfMargin - right
+
+	var lines []pdfLine
+	add := func(x, width float64, s string, style pdf.Style, spacing float64) {
+		for _, text := range pdf.Wrap(s, style.Size, width) {
+			lines = append(lines, pdfLine{x: x, text: text, style: style, height: style.Size * spacing})
+		}
+	}
+
+	title := item.Title
+	if label := itemTypeLabel(item.Type); label != "" {
+		title += "  ［" + label + "］"
+	}
+	add(right, width, title, pdfItemStyle, 1.5)
+	if item.Location != "" {
+		add(right, width, "場所: "+item.Location, pdfBodyStyle, 1.55)
+	}
+	if item.Description != "" {
+		add(right, width, item.Description, pdfBodyStyle, 1.55)
+	}
+	if item.Cost > 0 {
+		add(right, width, "費用: "+formatYen(item.Cost), pdfBodyStyle, 1.55)
+	}
+	if item.Notes != "" {
+		add(right, width, "メモ: "+item.Notes, pdfBodyStyle, 1.55)
+	}
+
+	var height float64
+	for _, line := range lines {
+		height += line.height
+	}
+	if height < pdf.A4Height-pdfMargin*2-pdfFooterHeight {
+		w.ensure(height + 10)
+	}
+
+	// 時刻の列（アイテムのタイムゾーンがプランと異なる場合はタイムゾーンも表示する）
+	top := w.y
+	w.page.Text(left, top+pdfItemStyle.Size, formatItemTimeRange(item), pdf.Style{Size: 10})
+	if item.TimeZone != "" && item.TimeZone != plan.TimeZone {
+		w.page.Text(left, top+pdfItemStyle.Size+13, item.TimeZone, pdfFooterStyle)
+	}
+
+	for _, line := range lines {
+		w.ensure(line.height)
+		w.y += line.height
+		w.page.Text(line.x, w.y-line.height+line.style.Size, line.text, line.style)
+	}
+	w.y = max(w.y, top+30) + 10
+}
+
+// paragraph テキストを指定した幅で折り返して書き込む
+func (w *itineraryPDF) paragraph(x, width float64, s string, style pdf.Style, spacing float64) {
+	for _, line := range pdf.Wrap(s, style.Size, width) {
+		height := style.Size * spacing
+		w.ensure(height)
+		w.page.Text(x, w.y+style.Size, line, style)
+		w.y += height
+	}
+}
+
+// ensure 残りの高さが足りなければ改ページする
+func (w *itineraryPDF) ensure(height float64) {
+	if w.y+height > pdf.A4Height-pdfMargin-pdfFooterHeight {
+		w.newPage()
+	}
+}
+
+func (w *itineraryPDF) newPage() {
+	w.page = w.doc.AddPage()
+	w.y = pdfMargin
+}
+
+// footers すべてのページの下部にプラン名とページ番号を書き込む
+func (w *itineraryPDF) footers() {
+	pages := w.doc.PageCount()
+	for i, page := range w.doc.Pages() {
+		y := pdf.A4Height - pdfMargin + 10
+		number := fmt.Sprintf("%d / %d", i+1, pages)
+		page.Text(pdf.A4Width-pdfMargin-pdf.TextWidth(number, pdfFooterStyle.Size), y, number, pdfFooterStyle)
+		if i > 0 {
+			title := pdf.Wrap(w.doc.Title, pdfFooterStyle.Size, pdf.A4Width-pdfMargin*2-80)[0]
+			page.Text(pdfMargin, y, title, pdfFooterStyle)
+		}
+	}
+}
+
+// planSummaryLine 旅行期間・タイムゾーン・ステータスの1行を返す
+func planSummaryLine(plan *models.TravelPlan) string {
+	var parts []string
+	if !plan.StartDate.IsZero() {
+		period := "期間: " + formatJapaneseDate(plan.StartDate)
+		if !plan.EndDate.IsZero() && plan.EndDate != plan.StartDate {
+			period += " 〜 " + formatJapaneseDate(plan.EndDate)
+		}
+		if !plan.EndDate.IsZero() {
+			period += fmt.Sprintf("（%d日間）", plan.StartDate.DaysUntil(plan.EndDate)+1)
+		}
+		parts = append(parts, period)
+	}
+	parts = append(parts, "タイムゾーン: "+plan.Location().String())
+	if label, ok := statusLabels[plan.Status]; ok {
+		parts = append(parts, "ステータス: "+label)
+	}
+	return strings.Join(parts, "   ")
+}
+
+// itemTypeLabel アイテムの種類の表示名を返す（定義にない種類はそのまま返す）
+func itemTypeLabel(itemType string) string {
+	if label, ok := itemTypeLabels[itemType]; ok {
+		return label
+	}
+	return itemType
+}
+
+// formatItemTimeRange アイテムの開始・終了時刻を返す（終了が翌日以降の場合は日付も付ける）
+func formatItemTimeRange(item *models.PlanItem) string {
+	s := item.StartTime.Format("15:04") + "〜"
+	if item.EndTime.IsZero() {
+		return s
+	}
+	if models.DateOf(item.EndTime) != models.DateOf(item.StartTime) {
+		return s + item.EndTime.Format("1/2 15:04")
+	}
+	return s + item.EndTime.Format("15:04")
+}
+
+// formatJapaneseDate 日付を「2026年4月1日（水）」の形式で返す
+func formatJapaneseDate(d models.Date) string {
+	return fmt.Sprintf("%d年%d月%d日（%s）", d.Year(), d.Month(), d.Day(), weekdayLabels[d.Weekday()])
+}
+
+// formatYen 金額を「12,000円」の形式で返す
+func formatYen(n int) string {
+	s := strconv.Itoa(n)
+	sign := ""
+	if n < 0 {
+		sign, s = "-", s[1:]
+	}
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return sign + s + "円"
+}
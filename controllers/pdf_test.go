@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"backend/models"
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRenderItineraryPDF アイテムが多い場合に改ページされることを確認する
+func TestRenderItineraryPDF(t *testing.T) {
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	plan := &models.TravelPlan{
+		ID:          "plan",
+		Title:       "京都2日間",
+		Description: "紅葉の名所をめぐる",
+		TimeZone:    "Asia/Tokyo",
+		StartDate:   models.NewDate(2026, 11, 20),
+		EndDate:     models.NewDate(2026, 11, 21),
+		TotalCost:   12000,
+	}
+	doc := renderItineraryPDF(plan)
+	assert.Equal(t, 1, doc.PageCount())
+
+	for i := 0; i < 30; i++ {
+		start := time.Date(2026, 11, 20+i%2, 8+i/2%12, 0, 0, 0, tokyo)
+		plan.Items = append(plan.Items, models.PlanItem{
+			ID:        fmt.Sprint(i),
+			Type:      models.ItemTypeVisit,
+			Title:     fmt.Sprintf("観光地%d", i),
+			Location:  "京都市",
+			Notes:     "拝観料は現地で支払う。混雑する時間帯は避ける。",
+			Cost:      400,
+			StartTime: start,
+			EndTime:   start.Add(time.Hour),
+			Order:     i,
+		})
+	}
+	doc = renderItineraryPDF(plan)
+	assert.Greater(t, doc.PageCount(), 1)
+
+	var b bytes.Buffer
+	assert.NoError(t, doc.Encode(&b))
+	assert.True(t, bytes.HasPrefix(b.Bytes(), []byte("%PDF-")))
+}
+
+// TestItineraryFormats 日付・金額・時刻の表示形式を確認する
+func TestItineraryFormats(t *testing.T) {
+	assert.Equal(t, "2026年4月1日（水）", formatJapaneseDate(models.NewDate(2026, 4, 1)))
+	assert.Equal(t, "0円", formatYen(0))
+	assert.Equal(t, "1,234,567円", formatYen(1234567))
+	assert.Equal(t, "-12,000円", formatYen(-12000))
+
+	start := time.Date(2026, 4, 1, 22, 0, 0, 0, time.UTC)
+	assert.Equal(t, "22:00〜", formatItemTimeRange(&models.PlanItem{StartTime: start}))
+	assert.Equal(t, "22:00〜23:30", formatItemTimeRange(&models.PlanItem{StartTime: start, EndTime: start.Add(90 * time.Minute)}))
+	assert.Equal(t, "22:00〜4/2 07:00", formatItemTimeRange(&models.PlanItem{StartTime: start, EndTime: start.Add(9 * time.Hour)}))
+}
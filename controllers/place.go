@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"backend/models"
+	"backend/utils/geo"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPlaceLimit = 10
+	maxPlaceLimit     = 50
+)
+
+var errNearPoint = errors.New("lat と lng は両方とも有効な値を指定してください")
+
+// SearchPlaces 名前・住所から場所を検索する（アイテムの場所の入力候補）
+// lat・lng を指定すると、一致度が同じ場所は近い順に並べる
+func SearchPlaces(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "検索キーワードを指定してください"})
+		return
+	}
+
+	limit := defaultPlaceLimit
+	if s := c.Query("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errLimit.Error()})
+			return
+		}
+		limit = min(n, maxPlaceLimit)
+	}
+
+	near, err := parseNearPoint(c.Query("lat"), c.Query("lng"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	places, err := models.SearchPlaces(q, near, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "場所の検索に失敗しました"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": places})
+}
+
+// GetPlace 場所を取得する
+func GetPlace(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "場所が見つかりません"})
+		return
+	}
+
+	place, err := models.FindPlace(models.DB, uint(id))
+	if err != nil {
+		if errors.Is(err, models.ErrPlaceNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "場所の取得に失敗しました"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": place})
+}
+
+// CreatePlace 場所を登録する
+// 同じ場所（外部IDが一致するか、名前が同じで近くにある場所）が既にあればそれを変更せずに返す
+// 入力した外部IDは既存の場所の検索にだけ使い、新しい場所には設定しない
+func CreatePlace(c *gin.Context) {
+	var input models.PlaceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if strings.TrimSpace(input.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "場所の名前を指定してください"})
+		return
+	}
+	if !models.ValidCoordinates(input.Latitude, input.Longitude) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errCoordinates.Error()})
+		return
+	}
+	if input.ExternalID != "" && input.ExternalSource == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "外部IDの提供元を指定してください"})
+		return
+	}
+
+	// 利用者の入力では共有する既存の場所を変更しない
+	place, created, err := models.FindOrCreatePlace(models.DB, input, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "場所の登録に失敗しました"})
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, gin.H{"data": place})
+}
+
+// DedupePlaces 重複して登録された場所をまとめる
+func DedupePlaces(c *gin.Context) {
+	merged, err := models.DedupePlaces()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "場所の統合に失敗しました", "merged": merged})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"merged": merged}})
+}
+
+// parseNearPoint クエリパラメータの緯度・経度を取得する（両方とも未指定の場合はnil）
+func parseNearPoint(lat, lng string) (*geo.Point, error) {
+	if lat == "" && lng == "" {
+		return nil, nil
+	}
+	latitude, err := strconv.ParseFloat(lat, 64)
+	if err != nil {
+		return nil, errNearPoint
+	}
+	longitude, err := strconv.ParseFloat(lng, 64)
+	if err != nil {
+		return nil, errNearPoint
+	}
+	if !models.ValidCoordinates(&latitude, &longitude) {
+		return nil, errNearPoint
+	}
+	return &geo.Point{Latitude: latitude, Longitude: longitude}, nil
+}
@@ -2,11 +2,15 @@ package controllers
 
 import (
 	"backend/models"
+	"backend/utils/realtime"
 	"backend/utils/token"
+	"errors"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type TravelPlanInput struct {
@@ -15,10 +19,15 @@ type TravelPlanInput struct {
 	Description string          `json:"description" validate:"required"` // プランの説明
 	Items       []PlanItemInput `json:"items" validate:"required,dive"`  // プランの各項目
 	TotalCost   int             `json:"totalCost" validate:"required"`   // 合計費用
+	StartDate   models.Date     `json:"startDate"`                       // 旅行開始日
+	EndDate     models.Date     `json:"endDate"`                         // 旅行終了日
+	TimeZone    string          `json:"timeZone"`                        // IANAタイムゾーン（例："Asia/Tokyo"）
 	CreatedAt   time.Time       `json:"createdAt" validate:"required"`   // 作成日時
 	UpdatedAt   time.Time       `json:"updatedAt" validate:"required"`   // 更新日時
 	CreatorID   uint            `json:"creatorId" validate:"required"`   // プラン作成者のユーザーID
 	IsPublic    bool            `json:"isPublic" validate:"required"`    // プランの公開状態
+	CategoryID  *uint           `json:"categoryId"`                      // カテゴリID
+	Tags        []string        `json:"tags"`                            // タグ名の一覧
 }
 
 type PlanItemInput struct {
@@ -28,14 +37,24 @@ type PlanItemInput struct {
 	Title       string    `json:"title" validate:"required"`       // 例：「清水寺観光」
 	Description string    `json:"description" validate:"required"` // 詳細説明
 	Location    string    `json:"location" validate:"required"`    // 場所
+	Latitude    *float64  `json:"latitude"`                        // 緯度（未設定の場合はnull）
+	Longitude   *float64  `json:"longitude"`                       // 経度（未設定の場合はnull）
+	PlaceID     *uint     `json:"placeId"`                         // 参照する場所のID（未設定の場合はnull）
 	StartTime   time.Time `json:"startTime" validate:"required"`   // 開始時間
 	EndTime     time.Time `json:"endTime" validate:"required"`     // 終了時間
+	TimeZone    string    `json:"timeZone"`                        // IANAタイムゾーン（未設定の場合はプランのタイムゾーン）
 	Duration    int       `json:"duration" validate:"required"`    // 所要時間（分）
 	Cost        int       `json:"cost" validate:"required"`        // 費用（円）
 	Notes       string    `json:"notes" validate:"required"`       // メモ
 	Order       int       `json:"order" validate:"required"`       // 順序
 }
 
+// errCoordinates 緯度・経度が正しくない場合のエラー
+var errCoordinates = errors.New("緯度（-90〜90）と経度（-180〜180）は両方とも指定するか、両方とも省略してください")
+
+// validStatuses プランのステータスとして有効な値
+var validStatuses = map[string]bool{"draft": true, "confirmed": true, "completed": true, "cancelled": true}
+
 // CreatePlan プランを作成する
 func CreatePlan(c *gin.Context) {
 	var input TravelPlanInput
@@ -46,6 +65,33 @@ func CreatePlan(c *gin.Context) {
 		return
 	}
 
+	// タイトルのバリデーション
+	if strings.TrimSpace(input.Title) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "タイトルは必須です"})
+		return
+	}
+
+	// 旅行期間のバリデーション
+	if !validPlanDates(input.StartDate, input.EndDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "終了日は開始日以降の日付を指定してください"})
+		return
+	}
+
+	// タイムゾーンのバリデーション
+	if !models.ValidTimeZone(input.TimeZone) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なタイムゾーンです"})
+		return
+	}
+	if input.TimeZone == "" {
+		input.TimeZone = models.DefaultTimeZone
+	}
+
+	// カテゴリのバリデーション
+	if !validCategory(input.CategoryID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "カテゴリが見つかりません"})
+		return
+	}
+
 	// トークンからユーザーIDを取得
 	userId, err := token.ExtractTokenId(c)
 	if err != nil {
@@ -58,17 +104,33 @@ func CreatePlan(c *gin.Context) {
 		Title:       input.Title,
 		Description: input.Description,
 		TotalCost:   input.TotalCost,
+		StartDate:   input.StartDate,
+		EndDate:     input.EndDate,
+		TimeZone:    input.TimeZone,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 		CreatorID:   userId,
+		CategoryID:  input.CategoryID,
+		Status:      "draft",
 	}
 
-	// データベースに保存
-	err = models.DB.Create(&plan).Error
+	// データベースに保存（タグも合わせて作成する）
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		tags, err := models.FindOrCreateTags(tx, input.Tags)
+		if err != nil {
+			return err
+		}
+		plan.Tags = tags
+		if err := tx.Create(&plan).Error; err != nil {
+			return err
+		}
+		return models.RecordRevision(tx, plan.ID, userId, models.RevisionPlanCreated)
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "プランの作成に失敗しました"})
 		return
 	}
+	models.ReindexPlan(plan.ID)
 
 	c.JSON(http.StatusOK, gin.H{"data": plan})
 }
@@ -86,7 +148,7 @@ func CreatePlanItem(c *gin.Context) {
 
 	// プランを取得
 	var plan models.TravelPlan
-	if err := models.DB.First(&plan, id).Error; err != nil {
+	if err := models.DB.First(&plan, "id = ?", id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "プランが見つかりません"})
 		return
 	}
@@ -98,45 +160,25 @@ func CreatePlanItem(c *gin.Context) {
 		return
 	}
 
-	// プランアイテムオブジェクトを作成
-	item := models.PlanItem{
-		Type:        input.Type,
-		Title:       input.Title,
-		Description: input.Description,
-		Location:    input.Location,
-		StartTime:   input.StartTime,
-		EndTime:     input.EndTime,
-		Duration:    input.Duration,
-		Cost:        input.Cost,
-		Notes:       input.Notes,
-		Order:       input.Order,
+	item, ok := createPlanItem(c, &plan, input)
+	if !ok {
+		return
 	}
 
-	item.PlanID = id
-
-	models.DB.Create(&item)
 	c.JSON(http.StatusOK, gin.H{"data": item})
-
 }
 
 // GetPlan 指定されたIDのプランを取得する
 func GetPlan(c *gin.Context) {
-	id := c.Param("id")
-	var plan models.TravelPlan
-
 	// プランを取得 (リレーションを含む)
-	if err := models.DB.Preload("PlanItem").First(&plan, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "プランが見つかりません"})
+	plan, ok := findViewablePlan(c, c.Param("id"))
+	if !ok {
 		return
 	}
 
-	// 非公開プランの場合は作成者のみアクセス可能
-	if !plan.IsPublic {
-		userId, err := token.ExtractTokenId(c)
-		if err != nil || userId != plan.CreatorID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "このプランにアクセスする権限がありません"})
-			return
-		}
+	// 変更がなければ304を返す
+	if notModified(c, planViewETag(plan)) {
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"data": plan})
@@ -148,7 +190,7 @@ func UpdatePlan(c *gin.Context) {
 	var plan models.TravelPlan
 
 	// プランを取得
-	if err := models.DB.First(&plan, id).Error; err != nil {
+	if err := models.DB.First(&plan, "id = ?", id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "プランが見つかりません"})
 		return
 	}
@@ -160,6 +202,11 @@ func UpdatePlan(c *gin.Context) {
 		return
 	}
 
+	// 他のユーザーによる更新がないか確認
+	if !checkIfMatch(c, planETag(&plan)) {
+		return
+	}
+
 	// 入力を取得
 	var input TravelPlanInput
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -167,16 +214,79 @@ func UpdatePlan(c *gin.Context) {
 		return
 	}
 
+	// タイトルのバリデーション
+	if strings.TrimSpace(input.Title) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "タイトルは必須です"})
+		return
+	}
+
+	// 旅行期間のバリデーション
+	if !validPlanDates(input.StartDate, input.EndDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "終了日は開始日以降の日付を指定してください"})
+		return
+	}
+
+	// タイムゾーンのバリデーション
+	if !models.ValidTimeZone(input.TimeZone) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なタイムゾーンです"})
+		return
+	}
+	if input.TimeZone == "" {
+		input.TimeZone = models.DefaultTimeZone
+	}
+
+	// カテゴリのバリデーション
+	if !validCategory(input.CategoryID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "カテゴリが見つかりません"})
+		return
+	}
+
 	// プランを更新（StatusとCreatorIDは変更しない）
 	updatedPlan := models.TravelPlan{
 		Title:       input.Title,
 		Description: input.Description,
 		TotalCost:   input.TotalCost,
+		StartDate:   input.StartDate,
+		EndDate:     input.EndDate,
+		TimeZone:    input.TimeZone,
 		UpdatedAt:   time.Now(),
 		IsPublic:    input.IsPublic,
+		CategoryID:  input.CategoryID,
 	}
 
-	models.DB.Model(&plan).Updates(updatedPlan)
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := models.BumpPlanVersion(tx, plan.ID, plan.Version); err != nil {
+			return err
+		}
+		// ゼロ値（false・0）も更新できるように更新するフィールドを指定する
+		err := tx.Model(&plan).
+			Select("Title", "Description", "TotalCost", "StartDate", "EndDate", "TimeZone",
+				"UpdatedAt", "IsPublic", "CategoryID").
+			Updates(updatedPlan).Error
+		if err != nil {
+			return err
+		}
+		// タグが指定された場合のみ置き換える
+		if input.Tags != nil {
+			tags, err := models.FindOrCreateTags(tx, input.Tags)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&plan).Association("Tags").Replace(tags); err != nil {
+				return err
+			}
+		}
+		return models.RecordRevision(tx, plan.ID, userId, models.RevisionPlanUpdated)
+	})
+	if err != nil {
+		writeUpdateError(c, err, "プランの更新に失敗しました")
+		return
+	}
+	models.ReindexPlan(plan.ID)
+
+	plan.Version++
+	publishPlanEvent(plan.ID, userId, realtime.EventPlanUpdated, plan)
+	c.Header("ETag", planETag(&plan))
 	c.JSON(http.StatusOK, gin.H{"data": plan})
 }
 
@@ -186,7 +296,7 @@ func UpdatePlanStatus(c *gin.Context) {
 	var plan models.TravelPlan
 
 	// プランを取得
-	if err := models.DB.First(&plan, id).Error; err != nil {
+	if err := models.DB.First(&plan, "id = ?", id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "プランが見つかりません"})
 		return
 	}
@@ -198,6 +308,11 @@ func UpdatePlanStatus(c *gin.Context) {
 		return
 	}
 
+	// 他のユーザーによる更新がないか確認
+	if !checkIfMatch(c, planETag(&plan)) {
+		return
+	}
+
 	// 入力を取得
 	var input struct {
 		Status string `json:"status" binding:"required"`
@@ -208,14 +323,29 @@ func UpdatePlanStatus(c *gin.Context) {
 	}
 
 	// ステータスのバリデーション
-	validStatuses := map[string]bool{"draft": true, "confirmed": true, "completed": true, "cancelled": true}
 	if !validStatuses[input.Status] {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なステータスです"})
 		return
 	}
 
 	// ステータスのみ更新
-	models.DB.Model(&plan).Update("status", input.Status)
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := models.BumpPlanVersion(tx, plan.ID, plan.Version); err != nil {
+			return err
+		}
+		if err := tx.Model(&plan).Update("status", input.Status).Error; err != nil {
+			return err
+		}
+		return models.RecordRevision(tx, plan.ID, userId, models.RevisionStatusUpdated)
+	})
+	if err != nil {
+		writeUpdateError(c, err, "プランの更新に失敗しました")
+		return
+	}
+
+	plan.Version++
+	publishPlanEvent(plan.ID, userId, realtime.EventPlanUpdated, plan)
+	c.Header("ETag", planETag(&plan))
 	c.JSON(http.StatusOK, gin.H{"data": plan})
 }
 
@@ -225,7 +355,7 @@ func DeletePlan(c *gin.Context) {
 	var plan models.TravelPlan
 
 	// プランを取得
-	if err := models.DB.First(&plan, id).Error; err != nil {
+	if err := models.DB.First(&plan, "id = ?", id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "プランが見つかりません"})
 		return
 	}
@@ -237,8 +367,17 @@ func DeletePlan(c *gin.Context) {
 		return
 	}
 
-	// プランを削除
-	models.DB.Delete(&plan)
+	// 他のユーザーによる更新がないか確認
+	if !checkIfMatch(c, planETag(&plan)) {
+		return
+	}
+
+	// プランをアイテムと一緒にゴミ箱へ移動
+	if err := models.SoftDeletePlan(&plan); err != nil {
+		writeUpdateError(c, err, "プランの削除に失敗しました")
+		return
+	}
+	publishPlanEvent(plan.ID, userId, realtime.EventPlanDeleted, gin.H{"id": plan.ID})
 	c.JSON(http.StatusOK, gin.H{"data": "プランが削除されました"})
 }
 
@@ -251,27 +390,209 @@ func GetMyPlans(c *gin.Context) {
 		return
 	}
 
-	var plans []models.TravelPlan
-	// ユーザーIDに基づいてプランを取得
-	models.DB.Where("creator_id = ?", userId).Find(&plans)
+	// ユーザーIDに基づいてプランを取得（カテゴリ・タグでフィルタリング）
+	query := models.DB.Model(&models.TravelPlan{}).
+		Where("creator_id = ?", userId).
+		Scopes(models.WithCategory(c.Query("category")), models.WithTags(queryList(c, "tags"))).
+		Session(&gorm.Session{})
 
-	c.JSON(http.StatusOK, gin.H{"data": plans})
+	listPlans(c, query, "-createdAt")
 }
 
 // GetPublicPlans 公開プランを取得する
 func GetPublicPlans(c *gin.Context) {
-	var plans []models.TravelPlan
+	// カテゴリ・タグでフィルタリング（オプション）
+	query := models.DB.Model(&models.TravelPlan{}).
+		Where("is_public = ?", true).
+		Scopes(models.WithCategory(c.Query("category")), models.WithTags(queryList(c, "tags"))).
+		Session(&gorm.Session{})
 
-	// カテゴリでフィルタリング（オプション）
-	category := c.Query("category")
-	query := models.DB.Where("is_public = ?", true)
+	listPlans(c, query, "-createdAt")
+}
 
-	if category != "" {
-		query = query.Where("category = ?", category)
+// queryList カンマ区切りのクエリパラメータを一覧として取得する（同名パラメータの繰り返しにも対応）
+func queryList(c *gin.Context, key string) []string {
+	var values []string
+	for _, v := range c.QueryArray(key) {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				values = append(values, part)
+			}
+		}
+	}
+	return values
+}
+
+// orderItems アイテムを順序の昇順で取得する
+func orderItems(db *gorm.DB) *gorm.DB {
+	return db.Order("`order` asc, start_time asc")
+}
+
+// validPlanDates 開始日と終了日の前後関係が正しいかどうかを返す
+func validPlanDates(start, end models.Date) bool {
+	if start.IsZero() || end.IsZero() {
+		return true
+	}
+	return !end.Before(start.Time)
+}
+
+// createPlanItem 入力内容からプランアイテムを作成して保存する
+// 失敗した場合はエラーレスポンスを書き込み、falseを返す
+func createPlanItem(c *gin.Context, plan *models.TravelPlan, input PlanItemInput) (*models.PlanItem, bool) {
+	// 他のユーザーによる更新がないか確認
+	if !checkIfMatch(c, planETag(plan)) {
+		return nil, false
+	}
+
+	item, ok := newPlanItem(c, plan, input)
+	if !ok {
+		return nil, false
+	}
+
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := models.BumpPlanVersion(tx, plan.ID, plan.Version); err != nil {
+			return err
+		}
+		if err := tx.Create(item).Error; err != nil {
+			return err
+		}
+		return models.RecordRevision(tx, plan.ID, currentUserID(c), models.RevisionItemCreated)
+	})
+	if err != nil {
+		writeUpdateError(c, err, "アイテムの作成に失敗しました")
+		return nil, false
+	}
+	models.ReindexPlan(plan.ID)
+
+	plan.Version++
+	c.Header("ETag", itemETag(item))
+	plan.LocalizeItem(item)
+	publishPlanEvent(plan.ID, currentUserID(c), realtime.EventItemCreated, item)
+	return item, true
+}
+
+// newPlanItem 入力内容を検証してプランアイテムを組み立てる（保存はしない）
+// 失敗した場合はエラーレスポンスを書き込み、falseを返す
+func newPlanItem(c *gin.Context, plan *models.TravelPlan, input PlanItemInput) (*models.PlanItem, bool) {
+	item, err := buildPlanItem(plan, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	// 場所を指定した場合は、場所の名前と位置で空の場所・緯度経度を補う
+	if item.PlaceID != nil {
+		place, err := models.FindPlace(models.DB, *item.PlaceID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "場所が見つかりません"})
+			return nil, false
+		}
+		item.ApplyPlace(place)
+	}
+	return item, true
+}
+
+// buildPlanItem 入力内容を検証してプランアイテムを組み立てる（保存はしない）
+func buildPlanItem(plan *models.TravelPlan, input PlanItemInput) (*models.PlanItem, error) {
+	// タイムゾーンのバリデーション
+	if !models.ValidTimeZone(input.TimeZone) {
+		return nil, errors.New("無効なタイムゾーンです")
+	}
+
+	// 終了時間が開始時間より前でないか確認
+	if !input.EndTime.IsZero() && input.EndTime.Before(input.StartTime) {
+		return nil, errors.New("終了時間は開始時間以降を指定してください")
+	}
+
+	// 緯度・経度のバリデーション
+	if !models.ValidCoordinates(input.Latitude, input.Longitude) {
+		return nil, errCoordinates
+	}
+
+	// プランアイテムオブジェクトを作成
+	item := models.PlanItem{
+		PlanID:      plan.ID,
+		Type:        input.Type,
+		Title:       input.Title,
+		Description: input.Description,
+		Location:    input.Location,
+		Latitude:    input.Latitude,
+		Longitude:   input.Longitude,
+		PlaceID:     input.PlaceID,
+		StartTime:   input.StartTime,
+		EndTime:     input.EndTime,
+		TimeZone:    input.TimeZone,
+		Duration:    input.Duration,
+		Cost:        input.Cost,
+		Notes:       input.Notes,
+		Order:       input.Order,
+	}
+
+	// アイテムの開始日（アイテムのタイムゾーンでの日付）がプランの期間内か確認
+	if !plan.ContainsDate(plan.LocalDate(&item)) {
+		return nil, errors.New("アイテムの開始時間がプランの期間外です")
+	}
+
+	// 所要時間が未指定の場合は開始・終了時間から求める
+	if item.Duration == 0 {
+		item.Duration = item.DurationMinutes()
 	}
 
-	// 日付でソート
-	query.Order("start_date desc").Find(&plans)
+	return &item, nil
+}
+
+// currentUserID ログイン中のユーザーIDを返す（未ログインの場合は0）
+// イベント配信の接続用チケットで認証した場合はチケットのユーザーIDを返す
+func currentUserID(c *gin.Context) uint {
+	if id, ok := c.Get(streamUserKey); ok {
+		return id.(uint)
+	}
+	userId, err := token.ExtractTokenId(c)
+	if err != nil {
+		return 0
+	}
+	return userId
+}
+
+// findViewablePlan アイテムを含めてプランを取得し、閲覧権限を確認する
+// 失敗した場合はエラーレスポンスを書き込み、falseを返す
+func findViewablePlan(c *gin.Context, id string) (*models.TravelPlan, bool) {
+	var plan models.TravelPlan
+	err := models.DB.Preload("Items", orderItems).Preload("Items.Place").Preload("Category").Preload("Tags").
+		First(&plan, "id = ?", id).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "プランが見つかりません"})
+		return nil, false
+	}
+
+	// 非公開プランの場合は作成者とメンバーのみアクセス可能
+	if !plan.IsPublic {
+		if !models.IsPlanMember(&plan, currentUserID(c)) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "このプランにアクセスする権限がありません"})
+			return nil, false
+		}
+	}
+
+	// アイテムの時刻を現地時間で表示する
+	plan.Localize()
+
+	return &plan, true
+}
+
+// findEditablePlan プランを取得し、作成者本人かどうかを確認する
+// 失敗した場合はエラーレスポンスを書き込み、falseを返す
+func findEditablePlan(c *gin.Context, id string) (*models.TravelPlan, bool) {
+	var plan models.TravelPlan
+	if err := models.DB.First(&plan, "id = ?", id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "プランが見つかりません"})
+		return nil, false
+	}
+
+	userId, err := token.ExtractTokenId(c)
+	if err != nil || userId != plan.CreatorID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "このプランを更新する権限がありません"})
+		return nil, false
+	}
 
-	c.JSON(http.StatusOK, gin.H{"data": plans})
+	return &plan, true
 }
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"backend/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ReactionStatus いいね・ブックマークの状態
+type ReactionStatus struct {
+	Liked         bool `json:"liked"`         // 自分がいいねしているかどうか
+	LikeCount     int  `json:"likeCount"`     // いいねの数
+	Bookmarked    bool `json:"bookmarked"`    // 自分がブックマークしているかどうか
+	BookmarkCount int  `json:"bookmarkCount"` // ブックマークの数
+}
+
+// LikePlan 公開プランにいいねする
+func LikePlan(c *gin.Context) {
+	updateReaction(c, models.LikePlan, true)
+}
+
+// UnlikePlan プランのいいねを取り消す（いいねした後に非公開になったプランでも取り消せる）
+func UnlikePlan(c *gin.Context) {
+	updateReaction(c, models.UnlikePlan, false)
+}
+
+// BookmarkPlan 公開プランをブックマークする
+func BookmarkPlan(c *gin.Context) {
+	updateReaction(c, models.BookmarkPlan, true)
+}
+
+// UnbookmarkPlan プランのブックマークを取り消す（ブックマークした後に非公開になったプランでも取り消せる）
+func UnbookmarkPlan(c *gin.Context) {
+	updateReaction(c, models.UnbookmarkPlan, false)
+}
+
+// GetMyBookmarks 自分がブックマークしたプランを取得する
+// ブックマーク後に非公開になったプランは、作成者かメンバーでなければ含めない
+func GetMyBookmarks(c *gin.Context) {
+	userId := currentUserID(c)
+
+	query := models.DB.Model(&models.TravelPlan{}).
+		Joins("JOIN plan_bookmarks ON plan_bookmarks.plan_id = travel_plans.id AND plan_bookmarks.user_id = ?", userId).
+		Where("travel_plans.is_public = ? OR travel_plans.creator_id = ? OR travel_plans.id IN (?)", true, userId,
+			models.DB.Model(&models.PlanMember{}).Select("plan_id").Where("user_id = ?", userId)).
+		Scopes(models.WithCategory(c.Query("category")), models.WithTags(queryList(c, "tags"))).
+		Session(&gorm.Session{})
+
+	listPlans(c, query, "-createdAt")
+}
+
+// updateReaction いいね・ブックマークを更新し、更新後の状態を返す
+// 同じ操作を繰り返しても件数は変わらない
+// 追加（adding が true）は公開プランのみ、取り消しは非公開になったプランでも行える
+func updateReaction(c *gin.Context, update func(plan *models.TravelPlan, userID uint) error, adding bool) {
+	var plan *models.TravelPlan
+	if adding {
+		viewable, ok := findViewablePlan(c, c.Param("id"))
+		if !ok {
+			return
+		}
+		if !viewable.IsPublic {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "公開されているプランのみいいね・ブックマークできます"})
+			return
+		}
+		plan = viewable
+	} else {
+		plan = &models.TravelPlan{}
+		if err := models.DB.First(plan, "id = ?", c.Param("id")).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "プランが見つかりません"})
+			return
+		}
+	}
+
+	userId := currentUserID(c)
+	if err := update(plan, userId); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "プランの更新に失敗しました"})
+		return
+	}
+
+	var counts models.TravelPlan
+	if err := models.DB.Select("like_count", "bookmark_count").First(&counts, "id = ?", plan.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "プランの取得に失敗しました"})
+		return
+	}
+	liked, bookmarked := models.HasReaction(plan, userId)
+
+	c.JSON(http.StatusOK, gin.H{"data": ReactionStatus{
+		Liked:         liked,
+		LikeCount:     counts.LikeCount,
+		Bookmarked:    bookmarked,
+		BookmarkCount: counts.BookmarkCount,
+	}})
+}
@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"backend/models"
+	"errors"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxReviewLength レビュー本文の最大文字数
+const maxReviewLength = 2000
+
+// ReviewInput レビューの投稿・編集の入力
+type ReviewInput struct {
+	Rating int    `json:"rating" binding:"required"` // 評価（1〜5）
+	Body   string `json:"body"`                      // レビュー本文
+}
+
+// GetPlanReviews プランのレビューを新しい順に取得する
+func GetPlanReviews(c *gin.Context) {
+	plan, ok := findViewablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	limit, offset, err := parseLimitOffset(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var reviews []models.PlanReview
+	err = models.DB.Where("plan_id = ?", plan.ID).Order("created_at desc, id desc").
+		Limit(limit).Offset(offset).Find(&reviews).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "レビューの取得に失敗しました"})
+		return
+	}
+	if err := fillReviewUsernames(reviews); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "レビューの取得に失敗しました"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":          reviews,
+		"reviewCount":   plan.ReviewCount,
+		"ratingAverage": plan.RatingAverage,
+	})
+}
+
+// CreatePlanReview 完了した公開プランに評価とレビューを投稿する
+// プランを複製したか、ブックマークしたユーザーのみ投稿でき、1人1件まで
+func CreatePlanReview(c *gin.Context) {
+	var input ReviewInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, ok := findViewablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	body, ok := validReview(c, input)
+	if !ok {
+		return
+	}
+
+	review := models.PlanReview{UserID: currentUserID(c), Rating: input.Rating, Body: body}
+	err := models.CreateReview(plan, &review)
+	if errors.Is(err, models.ErrReviewNotAllowed) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "完了した公開プランを複製またはブックマークしたユーザーのみレビューできます"})
+		return
+	}
+	if errors.Is(err, models.ErrAlreadyReviewed) {
+		c.JSON(http.StatusConflict, gin.H{"error": "このプランはすでにレビューしています"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "レビューの投稿に失敗しました"})
+		return
+	}
+
+	writeReview(c, http.StatusCreated, &review)
+}
+
+// UpdatePlanReview 自分のレビューを編集する
+func UpdatePlanReview(c *gin.Context) {
+	var input ReviewInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	review, ok := findOwnReview(c)
+	if !ok {
+		return
+	}
+
+	body, ok := validReview(c, input)
+	if !ok {
+		return
+	}
+
+	if err := models.UpdateReview(review, input.Rating, body); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "レビューの更新に失敗しました"})
+		return
+	}
+
+	writeReview(c, http.StatusOK, review)
+}
+
+// DeletePlanReview 自分のレビューを削除する
+func DeletePlanReview(c *gin.Context) {
+	review, ok := findOwnReview(c)
+	if !ok {
+		return
+	}
+
+	if err := models.DeleteReview(review); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "レビューの削除に失敗しました"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": "レビューが削除されました"})
+}
+
+// validReview 評価の範囲とレビュー本文を検証し、前後の空白を取り除いた本文を返す
+// 失敗した場合はエラーレスポンスを書き込み、falseを返す
+func validReview(c *gin.Context, input ReviewInput) (string, bool) {
+	if input.Rating < 1 || input.Rating > 5 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "評価は1から5の整数で指定してください"})
+		return "", false
+	}
+	body := strings.TrimSpace(input.Body)
+	if utf8.RuneCountInString(body) > maxReviewLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "レビューは2000文字以内で入力してください"})
+		return "", false
+	}
+	return body, true
+}
+
+// findOwnReview プランの自分のレビューを取得する
+// 失敗した場合はエラーレスポンスを書き込み、falseを返す
+func findOwnReview(c *gin.Context) (*models.PlanReview, bool) {
+	plan, ok := findViewablePlan(c, c.Param("id"))
+	if !ok {
+		return nil, false
+	}
+
+	var review models.PlanReview
+	if err := models.DB.First(&review, "id = ? AND plan_id = ?", c.Param("reviewId"), plan.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "レビューが見つかりません"})
+		return nil, false
+	}
+	if review.UserID != currentUserID(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "このレビューを変更する権限がありません"})
+		return nil, false
+	}
+	return &review, true
+}
+
+// writeReview 投稿者のユーザー名を補ってレビューを返す
+func writeReview(c *gin.Context, status int, review *models.PlanReview) {
+	reviews := []models.PlanReview{*review}
+	if err := fillReviewUsernames(reviews); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "レビューの取得に失敗しました"})
+		return
+	}
+	c.JSON(status, gin.H{"data": reviews[0]})
+}
+
+// fillReviewUsernames レビューの投稿者のユーザー名を設定する
+func fillReviewUsernames(reviews []models.PlanReview) error {
+	ids := make([]uint, len(reviews))
+	for i, review := range reviews {
+		ids[i] = review.UserID
+	}
+	names, err := models.Usernames(models.DB, ids)
+	if err != nil {
+		return err
+	}
+	for i := range reviews {
+		reviews[i].Username = names[reviews[i].UserID]
+	}
+	return nil
+}
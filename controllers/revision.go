@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"backend/models"
+	"backend/utils/realtime"
+	"backend/utils/token"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPlanRevisions プランの変更履歴を新しい順に取得する
+func GetPlanRevisions(c *gin.Context) {
+	plan, ok := findEditablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var revisions []models.PlanRevision
+	if err := models.DB.Where("plan_id = ?", plan.ID).Order("number desc").Find(&revisions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "変更履歴の取得に失敗しました"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": revisions})
+}
+
+// GetPlanRevision 指定したリビジョンのプランの内容を取得する
+func GetPlanRevision(c *gin.Context) {
+	plan, ok := findEditablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	revision, ok := findRevision(c, plan.ID, c.Param("number"))
+	if !ok {
+		return
+	}
+
+	snapshot, err := revision.Decode()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "変更履歴の読み込みに失敗しました"})
+		return
+	}
+	revision.Plan = &snapshot
+
+	c.JSON(http.StatusOK, gin.H{"data": revision})
+}
+
+// DiffPlanRevisions 2つのリビジョン（from・toクエリパラメータ）の差分を取得する
+func DiffPlanRevisions(c *gin.Context) {
+	plan, ok := findEditablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	from, ok := findRevision(c, plan.ID, c.Query("from"))
+	if !ok {
+		return
+	}
+	to, ok := findRevision(c, plan.ID, c.Query("to"))
+	if !ok {
+		return
+	}
+
+	fromSnapshot, err := from.Decode()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "変更履歴の読み込みに失敗しました"})
+		return
+	}
+	toSnapshot, err := to.Decode()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "変更履歴の読み込みに失敗しました"})
+		return
+	}
+
+	planChanges, itemChanges := models.DiffSnapshots(fromSnapshot, toSnapshot)
+	c.JSON(http.StatusOK, gin.H{"data": models.PlanDiff{
+		From:  from.Number,
+		To:    to.Number,
+		Plan:  planChanges,
+		Items: itemChanges,
+	}})
+}
+
+// RestorePlanRevision プランを指定したリビジョンの内容に戻す
+func RestorePlanRevision(c *gin.Context) {
+	plan, ok := findEditablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	revision, ok := findRevision(c, plan.ID, c.Param("number"))
+	if !ok {
+		return
+	}
+
+	userId, err := token.ExtractTokenId(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "認証に失敗しました"})
+		return
+	}
+
+	// 他のユーザーによる更新がないか確認
+	if !checkIfMatch(c, planETag(plan)) {
+		return
+	}
+
+	if err := models.RestoreRevision(plan, revision, userId); err != nil {
+		writeUpdateError(c, err, "プランの復元に失敗しました")
+		return
+	}
+	models.ReindexPlan(plan.ID)
+
+	restored, ok := findViewablePlan(c, plan.ID)
+	if !ok {
+		return
+	}
+
+	publishPlanEvent(plan.ID, userId, realtime.EventPlanUpdated, restored)
+	c.Header("ETag", planETag(restored))
+	c.JSON(http.StatusOK, gin.H{"data": restored})
+}
+
+// findRevision プランのリビジョンを番号で取得する
+// 失敗した場合はエラーレスポンスを書き込み、falseを返す
+func findRevision(c *gin.Context, planID string, number string) (*models.PlanRevision, bool) {
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリビジョン番号です"})
+		return nil, false
+	}
+
+	var revision models.PlanRevision
+	if err := models.DB.First(&revision, "plan_id = ? AND number = ?", planID, n).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "リビジョンが見つかりません"})
+		return nil, false
+	}
+
+	return &revision, true
+}
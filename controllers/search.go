@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"backend/models"
+	"backend/utils/token"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// searchCandidateLimit 検索インデックスから取得する候補の最大件数
+const searchCandidateLimit = 1000
+
+// SearchResult 検索結果の1件
+type SearchResult struct {
+	Plan       models.TravelPlan   `json:"plan"`
+	Score      float64             `json:"score"`      // 関連度
+	Highlights map[string][]string `json:"highlights"` // フィールド名ごとの一致箇所の抜粋
+}
+
+// SearchPlans プランとアイテムを全文検索する
+// 公開プランと自分のプランが対象で、mine=true の場合は自分のプランのみを検索する
+func SearchPlans(c *gin.Context) {
+	q := c.Query("q")
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "検索語を指定してください"})
+		return
+	}
+
+	limit, offset, err := parseLimitOffset(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filters, err := parseListFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// ログインしている場合は自分の非公開プランも検索対象にする
+	userId, authErr := token.ExtractTokenId(c)
+	mine := c.Query("mine") == "true"
+	if mine && authErr != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "認証に失敗しました"})
+		return
+	}
+
+	hits, err := models.SearchIndex.Search(q, searchCandidateLimit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "検索に失敗しました"})
+		return
+	}
+	if len(hits) == 0 {
+		c.JSON(http.StatusOK, gin.H{"data": []SearchResult{}, "total": 0})
+		return
+	}
+
+	ids := make([]string, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+
+	// 閲覧権限と絞り込み条件をデータベースで適用する
+	query := models.DB.Model(&models.TravelPlan{}).Where("travel_plans.id IN ?", ids)
+	switch {
+	case mine:
+		query = query.Where("travel_plans.creator_id = ?", userId)
+	case authErr == nil:
+		query = query.Where("travel_plans.is_public = ? OR travel_plans.creator_id = ?", true, userId)
+	default:
+		query = query.Where("travel_plans.is_public = ?", true)
+	}
+	query = query.Scopes(
+		models.WithCategory(c.Query("category")),
+		models.WithTags(queryList(c, "tags")),
+		filters.scope(),
+	)
+
+	var plans []models.TravelPlan
+	if err := query.Preload("Category").Preload("Tags").Find(&plans).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "検索に失敗しました"})
+		return
+	}
+	found := make(map[string]models.TravelPlan, len(plans))
+	for _, plan := range plans {
+		found[plan.ID] = plan
+	}
+
+	// 関連度の順を保ったまま絞り込み後の結果をページに分ける
+	results := []SearchResult{}
+	for _, hit := range hits {
+		if plan, ok := found[hit.ID]; ok {
+			results = append(results, SearchResult{Plan: plan, Score: hit.Score, Highlights: hit.Highlights})
+		}
+	}
+	total := len(results)
+	results = results[min(offset, total):min(offset+limit, total)]
+
+	c.JSON(http.StatusOK, gin.H{"data": results, "total": total})
+}
+
+// parseLimitOffset クエリパラメータから件数と開始位置を取得する
+func parseLimitOffset(c *gin.Context) (int, int, error) {
+	limit, offset := defaultPageLimit, 0
+	if s := c.Query("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return 0, 0, errLimit
+		}
+		limit = min(n, maxPageLimit)
+	}
+	if s := c.Query("offset"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return 0, 0, errOffset
+		}
+		offset = n
+	}
+	return limit, offset, nil
+}
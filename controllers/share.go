@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"backend/models"
+	"backend/utils/render"
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	siteName                = "my_home_backend"
+	maxPreviewDescription   = 120 // リンクプレビューの説明文の最大文字数
+	publicPlanCacheControl  = "public, max-age=300"
+	publicPlanNotFoundTitle = "プランが見つかりません"
+)
+
+// ExportPlanMarkdown プランの行程表をMarkdownで出力する（チャットやブログへの貼り付け用）
+func ExportPlanMarkdown(c *gin.Context) {
+	plan, ok := findViewablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	// 変更がなければ304を返す
+	if notModified(c, planViewETag(plan)) {
+		return
+	}
+
+	var b bytes.Buffer
+	if err := render.Markdown(&b, itineraryView(plan)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Markdownの作成に失敗しました"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s.md"`, plan.ID))
+	c.Data(http.StatusOK, "text/markdown; charset=utf-8", b.Bytes())
+}
+
+// ExportPlanHTML プランの行程表を単体で表示できるHTMLページとして出力する
+func ExportPlanHTML(c *gin.Context) {
+	plan, ok := findViewablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	// 変更がなければ304を返す
+	if notModified(c, planViewETag(plan)) {
+		return
+	}
+
+	var b bytes.Buffer
+	if err := render.HTML(&b, itineraryView(plan)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "HTMLの作成に失敗しました"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s.html"`, plan.ID))
+	c.Data(http.StatusOK, "text/html; charset=utf-8", b.Bytes())
+}
+
+// ShowPublicPlan 公開プランの行程表をHTMLページとして表示する
+// リンクを共有したときにプレビューが表示されるように Open Graph のメタタグを出力する
+// 非公開のプランは作成者からのアクセスでも表示しない（認証のないブラウザやクローラーが開くため）
+func ShowPublicPlan(c *gin.Context) {
+	var plan models.TravelPlan
+	err := models.DB.Preload("Items", orderItems).Preload("Items.Place").Preload("Category").Preload("Tags").
+		First(&plan, "id = ? AND is_public = ?", c.Param("id"), true).Error
+	if err != nil {
+		c.Data(http.StatusNotFound, "text/html; charset=utf-8", []byte(
+			"<!DOCTYPE html><html lang=\"ja\"><head><meta charset=\"utf-8\"><title>"+publicPlanNotFoundTitle+
+				"</title></head><body><p>"+publicPlanNotFoundTitle+"</p></body></html>"))
+		return
+	}
+	plan.Localize()
+
+	// 変更がなければ304を返す
+	c.Header("Cache-Control", publicPlanCacheControl)
+	if notModified(c, planViewETag(&plan)) {
+		return
+	}
+
+	view := itineraryView(&plan)
+	view.Page = &render.Page{
+		URL:         publicBaseURL + "/p/" + plan.ID,
+		SiteName:    siteName,
+		Description: previewDescription(&plan, view.Summary),
+	}
+
+	var b bytes.Buffer
+	if err := render.HTML(&b, view); err != nil {
+		c.String(http.StatusInternalServerError, "ページの作成に失敗しました")
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", b.Bytes())
+}
+
+// itineraryView プランを行程表の表示内容に変換する（プランは Localize 済みであること）
+func itineraryView(plan *models.TravelPlan) *render.Itinerary {
+	view := &render.Itinerary{
+		Title:       plan.Title,
+		Summary:     planSummaryLine(plan),
+		Description: plan.Description,
+		TotalCost:   formatYen(plan.TotalCost),
+		Days:        []render.Day{},
+	}
+	if plan.Category != nil {
+		view.Category = plan.Category.Name
+	}
+	for _, tag := range plan.Tags {
+		view.Tags = append(view.Tags, tag.Name)
+	}
+
+	itemsCost := 0
+	for _, day := range plan.Days() {
+		d := render.Day{
+			Heading: fmt.Sprintf("%d日目 %s", day.Day, formatJapaneseDate(day.Date)),
+			Items:   []render.Item{},
+		}
+		if day.TotalCost > 0 {
+			d.Cost = formatYen(day.TotalCost)
+		}
+		for _, item := range day.Items {
+			i := render.Item{
+				Time:        formatItemTimeRange(&item),
+				Title:       item.Title,
+				Type:        itemTypeLabel(item.Type),
+				Location:    item.Location,
+				Description: item.Description,
+				Notes:       item.Notes,
+			}
+			if item.TimeZone != "" && item.TimeZone != plan.TimeZone {
+				i.TimeZone = item.TimeZone
+			}
+			if item.Cost > 0 {
+				i.Cost = formatYen(item.Cost)
+			}
+			d.Items = append(d.Items, i)
+		}
+		view.Days = append(view.Days, d)
+		itemsCost += day.TotalCost
+	}
+	if itemsCost != plan.TotalCost {
+		view.ItemsCost = formatYen(itemsCost)
+	}
+	return view
+}
+
+// previewDescription リンクプレビューの説明文を返す（説明がない場合は旅行期間など）
+func previewDescription(plan *models.TravelPlan, summary string) string {
+	s := strings.Join(strings.Fields(plan.Description), " ")
+	if s == "" {
+		s = summary
+	}
+	if utf8.RuneCountInString(s) > maxPreviewDescription {
+		s = string([]rune(s)[:maxPreviewDescription-1]) + "…"
+	}
+	return s
+}
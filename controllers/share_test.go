@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"backend/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestItineraryView プランが日ごとの表示内容に変換されることを確認する
+func TestItineraryView(t *testing.T) {
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	seoul, _ := time.LoadLocation("Asia/Seoul")
+	plan := &models.TravelPlan{
+		Title:     "ソウル旅行",
+		TimeZone:  "Asia/Tokyo",
+		StartDate: models.NewDate(2026, 4, 1),
+		EndDate:   models.NewDate(2026, 4, 2),
+		TotalCost: 35000,
+		Category:  &models.Category{Name: "海外旅行"},
+		Tags:      []models.Tag{{Name: "韓国"}},
+		Items: []models.PlanItem{
+			{Type: "transport", Title: "成田→仁川", Cost: 32000,
+				StartTime: time.Date(2026, 4, 1, 9, 0, 0, 0, tokyo), EndTime: time.Date(2026, 4, 1, 11, 30, 0, 0, tokyo)},
+			{Type: "sightseeing", Title: "景福宮", Cost: 3000, TimeZone: "Asia/Seoul",
+				StartTime: time.Date(2026, 4, 2, 10, 0, 0, 0, seoul)},
+		},
+	}
+
+	view := itineraryView(plan)
+	assert.Equal(t, "海外旅行", view.Category)
+	assert.Equal(t, []string{"韓国"}, view.Tags)
+	assert.Equal(t, "35,000円", view.TotalCost)
+	assert.Equal(t, "", view.ItemsCost)
+	assert.Len(t, view.Days, 2)
+	assert.Equal(t, "1日目 2026年4月1日（水）", view.Days[0].Heading)
+	assert.Equal(t, "09:00〜11:30", view.Days[0].Items[0].Time)
+	assert.Equal(t, "移動", view.Days[0].Items[0].Type)
+	assert.Equal(t, "", view.Days[0].Items[0].TimeZone)
+	assert.Equal(t, "sightseeing", view.Days[1].Items[0].Type)
+	assert.Equal(t, "Asia/Seoul", view.Days[1].Items[0].TimeZone)
+	assert.Equal(t, "10:00〜", view.Days[1].Items[0].Time)
+}
+
+// TestPreviewDescription リンクプレビューの説明文が1行にまとめられ、長い場合は省略されることを確認する
+func TestPreviewDescription(t *testing.T) {
+	plan := &models.TravelPlan{Description: "東山を\n歩く"}
+	assert.Equal(t, "東山を 歩く", previewDescription(plan, "期間"))
+	assert.Equal(t, "期間", previewDescription(&models.TravelPlan{}, "期間"))
+
+	plan.Description = strings.Repeat("あ", 200)
+	s := previewDescription(plan, "")
+	assert.Equal(t, maxPreviewDescription, len([]rune(s)))
+	assert.True(t, strings.HasSuffix(s, "…"))
+}
@@ -0,0 +1,199 @@
+package controllers
+
+import (
+	"backend/models"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// TemplateInput プランからテンプレートを作成するときの入力
+type TemplateInput struct {
+	Title       string `json:"title"`       // テンプレート名（省略時はプランのタイトル）
+	Description string `json:"description"` // テンプレートの説明（省略時はプランの説明）
+	IsPublic    bool   `json:"isPublic"`    // テンプレートを公開するかどうか
+}
+
+// InstantiateTemplateInput テンプレートからプランを作成するときの入力
+type InstantiateTemplateInput struct {
+	StartDate models.Date `json:"startDate"` // 旅行開始日
+	TimeZone  string      `json:"timeZone"`  // IANAタイムゾーン（省略時はテンプレートのタイムゾーン）
+}
+
+// CreatePlanTemplate プランを自分のテンプレートとして保存する
+// 他のユーザーのプランも閲覧できればテンプレートにできるが、公開できるのは作成者・メンバーのプランのみ
+func CreatePlanTemplate(c *gin.Context) {
+	var input TemplateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, ok := findViewablePlan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	// 他のユーザーのプランを自分のテンプレートとして公開できないようにする
+	if input.IsPublic && !models.IsPlanMember(plan, currentUserID(c)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "他のユーザーのプランから作成したテンプレートは公開できません"})
+		return
+	}
+
+	template := models.NewTemplate(plan, currentUserID(c))
+	if title := strings.TrimSpace(input.Title); title != "" {
+		template.Title = title
+	}
+	if input.Description != "" {
+		template.Description = input.Description
+	}
+	template.IsPublic = input.IsPublic
+
+	if err := models.DB.Create(&template).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "テンプレートの作成に失敗しました"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": template})
+}
+
+// GetPublicTemplates 公開テンプレートの一覧を取得する
+// sort=popular を指定すると利用回数の多い順に並べる
+func GetPublicTemplates(c *gin.Context) {
+	query := models.DB.Where("is_public = ?", true).
+		Scopes(templateCategory(c.Query("category")))
+	listTemplates(c, query)
+}
+
+// GetMyTemplates 自分のテンプレートの一覧を取得する
+func GetMyTemplates(c *gin.Context) {
+	query := models.DB.Where("creator_id = ?", currentUserID(c)).
+		Scopes(templateCategory(c.Query("category")))
+	listTemplates(c, query)
+}
+
+// GetTemplate テンプレートを取得する（非公開のテンプレートは作成者のみ）
+func GetTemplate(c *gin.Context) {
+	template, ok := findViewableTemplate(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": template})
+}
+
+// InstantiateTemplate テンプレートから指定した開始日の新しい下書きのプランを作成する
+func InstantiateTemplate(c *gin.Context) {
+	var input InstantiateTemplateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.StartDate.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "開始日を指定してください"})
+		return
+	}
+	if !models.ValidTimeZone(input.TimeZone) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なタイムゾーンです"})
+		return
+	}
+
+	template, ok := findViewableTemplate(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	plan, err := models.InstantiateTemplate(template, currentUserID(c), input.StartDate, input.TimeZone)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "プランの作成に失敗しました"})
+		return
+	}
+	models.ReindexPlan(plan.ID)
+
+	plan.Localize()
+	c.JSON(http.StatusCreated, gin.H{"data": plan})
+}
+
+// DeleteTemplate 自分のテンプレートを削除する
+func DeleteTemplate(c *gin.Context) {
+	var template models.PlanTemplate
+	if err := models.DB.First(&template, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "テンプレートが見つかりません"})
+		return
+	}
+	if template.CreatorID != currentUserID(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "このテンプレートを削除する権限がありません"})
+		return
+	}
+
+	err := models.DB.Select("Items", "Tags").Delete(&template).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "テンプレートの削除に失敗しました"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": "テンプレートが削除されました"})
+}
+
+// listTemplates テンプレートの一覧をlimit・offsetで返す
+func listTemplates(c *gin.Context, query *gorm.DB) {
+	limit, offset, err := parseLimitOffset(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var total int64
+	if err := query.Model(&models.PlanTemplate{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "テンプレートの取得に失敗しました"})
+		return
+	}
+
+	order := "created_at desc, id desc"
+	if c.Query("sort") == "popular" {
+		order = "use_count desc, id desc"
+	}
+
+	var templates []models.PlanTemplate
+	err = query.Preload("Category").Preload("Tags").
+		Order(order).Limit(limit).Offset(offset).Find(&templates).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "テンプレートの取得に失敗しました"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": templates, "total": total})
+}
+
+// templateCategory カテゴリのスラッグでテンプレートを絞り込むスコープを返す（空の場合は絞り込まない）
+func templateCategory(slug string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if slug == "" {
+			return db
+		}
+		return db.Where("category_id IN (?)",
+			models.DB.Model(&models.Category{}).Select("id").Where("slug = ?", slug))
+	}
+}
+
+// findViewableTemplate アイテムを含めてテンプレートを取得する
+// 非公開のテンプレートは作成者のみ取得できる。失敗した場合はエラーレスポンスを書き込み、falseを返す
+func findViewableTemplate(c *gin.Context, id string) (*models.PlanTemplate, bool) {
+	var template models.PlanTemplate
+	err := models.DB.Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("day, start_minute, `order`") }).
+		Preload("Category").Preload("Tags").
+		First(&template, "id = ?", id).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "テンプレートが見つかりません"})
+		return nil, false
+	}
+
+	if !template.IsPublic && template.CreatorID != currentUserID(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "このテンプレートにアクセスする権限がありません"})
+		return nil, false
+	}
+
+	return &template, true
+}
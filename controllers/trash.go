@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"backend/models"
+	"backend/utils/token"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TrashedPlan ゴミ箱に入っているプラン
+type TrashedPlan struct {
+	models.TravelPlan
+	PurgeAt time.Time `json:"purgeAt"` // 完全に削除される予定日時
+}
+
+// GetTrash ゴミ箱に入っている自分のプランを削除日時の新しい順に取得する
+func GetTrash(c *gin.Context) {
+	userId, err := token.ExtractTokenId(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "認証に失敗しました"})
+		return
+	}
+
+	var plans []models.TravelPlan
+	err = models.DB.Unscoped().
+		Where("creator_id = ? AND deleted_at IS NOT NULL", userId).
+		Order("deleted_at desc").
+		Find(&plans).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "プランの取得に失敗しました"})
+		return
+	}
+
+	retention := models.TrashRetention()
+	trashed := make([]TrashedPlan, len(plans))
+	for i, plan := range plans {
+		trashed[i] = TrashedPlan{TravelPlan: plan, PurgeAt: plan.DeletedAt.Time.Add(retention)}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": trashed})
+}
+
+// RestorePlan ゴミ箱のプランを元に戻す
+func RestorePlan(c *gin.Context) {
+	var plan models.TravelPlan
+	err := models.DB.Unscoped().
+		Where("deleted_at IS NOT NULL").
+		First(&plan, "id = ?", c.Param("id")).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ゴミ箱にプランが見つかりません"})
+		return
+	}
+
+	userId, err := token.ExtractTokenId(c)
+	if err != nil || userId != plan.CreatorID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "このプランを復元する権限がありません"})
+		return
+	}
+
+	if err := models.RestorePlan(&plan); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "プランの復元に失敗しました"})
+		return
+	}
+
+	restored, ok := findViewablePlan(c, plan.ID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": restored})
+}
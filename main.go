@@ -4,12 +4,30 @@ import (
 	"backend/controllers"
 	"backend/middlewares"
 	"backend/models"
+	"log"
+	"os"
+	"time"
+
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
 	models.ConnectDataBase()
 
+	// PDF_FONT_PATH にTrueTypeのフォント（例：IPAexゴシック）を指定すると行程表のPDFに埋め込む
+	if err := controllers.LoadPDFFont(os.Getenv("PDF_FONT_PATH")); err != nil {
+		log.Println("Could not load the PDF font", err)
+	}
+	// PUBLIC_BASE_URL に公開URL（例：https://example.com）を指定すると共有ページや購読用URLに使う
+	controllers.SetPublicBaseURL(os.Getenv("PUBLIC_BASE_URL"))
+
+	// 保持期間を過ぎたゴミ箱のプランを定期的に完全削除する
+	go models.PurgeTrashPeriodically(time.Hour)
+	// ジオコーダーが設定されている場合は緯度・経度が未設定のアイテムを定期的に補完する
+	if models.Geocoder != nil {
+		go models.BackfillCoordinatesPeriodically(models.Geocoder, 6*time.Hour)
+	}
+
 	router := gin.Default()
 
 	err := router.SetTrustedProxies([]string{"127.0.0.1", "::1"})
@@ -21,6 +39,94 @@ func main() {
 
 	public.POST("/register", controllers.Register)
 	public.POST("/login", controllers.Login)
+	public.GET("/public-plans", controllers.GetPublicPlans)
+	public.GET("/categories", controllers.GetCategories)
+	public.GET("/search", controllers.SearchPlans)
+	public.GET("/templates", controllers.GetPublicTemplates)
+	public.GET("/templates/:id", controllers.GetTemplate)
+	public.GET("/places", controllers.SearchPlaces)
+	public.GET("/places/:id", controllers.GetPlace)
+
+	// カレンダーアプリからの購読（URLに含まれる秘密のトークンで認証する）
+	public.GET("/feeds/:token/plans.ics", controllers.ServeCalendarFeed)
+
+	// プランの閲覧（非公開プランはハンドラー内で作成者か確認する）
+	public.GET("/plans/:id", controllers.GetPlan)
+	public.GET("/plans/:id/days", controllers.GetPlanDays)
+	public.GET("/plans/:id/days/:day", controllers.GetPlanDay)
+	public.GET("/plans/:id/legs", controllers.GetPlanLegs)
+	public.GET("/plans/:id/items/:itemId", controllers.GetPlanItem)
+	public.GET("/plans/:id/events", controllers.StreamPlanEvents)
+	public.GET("/plans/:id/comments", controllers.GetPlanComments)
+	public.GET("/plans/:id/members", controllers.GetPlanMembers)
+	public.GET("/plans/:id/reviews", controllers.GetPlanReviews)
+	public.GET("/plans/:id/export.ics", controllers.ExportPlanICS)
+	public.GET("/plans/:id/export.csv", controllers.ExportPlanCSV)
+	public.GET("/plans/:id/export.pdf", controllers.ExportPlanPDF)
+	public.GET("/plans/:id/export.md", controllers.ExportPlanMarkdown)
+	public.GET("/plans/:id/export.html", controllers.ExportPlanHTML)
+	public.GET("/plans/:id/export.geojson", controllers.ExportPlanGeoJSON)
+	public.GET("/plans/:id/export.gpx", controllers.ExportPlanGPX)
+	public.GET("/plans/:id/export.kml", controllers.ExportPlanKML)
+
+	// 公開プランの共有用ページ（リンクプレビュー用のメタタグ付きHTML）
+	router.GET("/p/:id", controllers.ShowPublicPlan)
+
+	plans := router.Group("/api/plans")
+	plans.Use(middlewares.JwtAuthMiddleware())
+	plans.GET("", controllers.GetMyPlans)
+	plans.GET("/trash", controllers.GetTrash)
+	plans.GET("/bookmarks", controllers.GetMyBookmarks)
+	plans.POST("", controllers.CreatePlan)
+	plans.POST("/import", controllers.ImportPlanICS)
+	plans.PUT("/:id", controllers.UpdatePlan)
+	plans.PATCH("/:id", controllers.PatchPlan)
+	plans.PATCH("/:id/status", controllers.UpdatePlanStatus)
+	plans.DELETE("/:id", controllers.DeletePlan)
+	plans.POST("/:id/restore", controllers.RestorePlan)
+	plans.POST("/:id/items", controllers.CreatePlanItem)
+	plans.PUT("/:id/items/order", controllers.ReorderPlanItems)
+	plans.POST("/:id/items/import", controllers.ImportPlanItemsCSV)
+	plans.PUT("/:id/items/:itemId", controllers.UpdatePlanItem)
+	plans.PATCH("/:id/items/:itemId", controllers.PatchPlanItem)
+	plans.DELETE("/:id/items/:itemId", controllers.DeletePlanItem)
+	plans.POST("/:id/days/:day/items", controllers.CreatePlanDayItem)
+	plans.POST("/:id/fork", controllers.ForkPlan)
+	plans.POST("/:id/events/ticket", controllers.CreateEventTicket)
+	plans.POST("/:id/template", controllers.CreatePlanTemplate)
+	plans.PUT("/:id/like", controllers.LikePlan)
+	plans.DELETE("/:id/like", controllers.UnlikePlan)
+	plans.PUT("/:id/bookmark", controllers.BookmarkPlan)
+	plans.DELETE("/:id/bookmark", controllers.UnbookmarkPlan)
+	plans.POST("/:id/reviews", controllers.CreatePlanReview)
+	plans.PUT("/:id/reviews/:reviewId", controllers.UpdatePlanReview)
+	plans.DELETE("/:id/reviews/:reviewId", controllers.DeletePlanReview)
+	plans.POST("/:id/comments", controllers.CreatePlanComment)
+	plans.PUT("/:id/comments/:commentId", controllers.UpdatePlanComment)
+	plans.DELETE("/:id/comments/:commentId", controllers.DeletePlanComment)
+	plans.POST("/:id/members", controllers.AddPlanMember)
+	plans.DELETE("/:id/members/:userId", controllers.RemovePlanMember)
+	plans.GET("/:id/revisions", controllers.GetPlanRevisions)
+	plans.GET("/:id/revisions/diff", controllers.DiffPlanRevisions)
+	plans.GET("/:id/revisions/:number", controllers.GetPlanRevision)
+	plans.POST("/:id/revisions/:number/restore", controllers.RestorePlanRevision)
+
+	templates := router.Group("/api/templates")
+	templates.Use(middlewares.JwtAuthMiddleware())
+	templates.GET("/mine", controllers.GetMyTemplates)
+	templates.POST("/:id/instantiate", controllers.InstantiateTemplate)
+	templates.DELETE("/:id", controllers.DeleteTemplate)
+
+	places := router.Group("/api/places")
+	places.Use(middlewares.JwtAuthMiddleware())
+	places.POST("", controllers.CreatePlace)
+	places.GET("/geocode", controllers.GeocodePlace)
+
+	feed := router.Group("/api/calendar-feed")
+	feed.Use(middlewares.JwtAuthMiddleware())
+	feed.GET("", controllers.GetCalendarFeed)
+	feed.POST("", controllers.CreateCalendarFeed)
+	feed.DELETE("", controllers.DeleteCalendarFeed)
 
 	protected := router.Group("/api/admin")
 	// JWT認証ミドルウェアを適用
@@ -28,6 +134,18 @@ func main() {
 	// 認証されたユーザー情報を取得するルートを定義
 	protected.GET("/user", controllers.CurrentUser)
 
+	// 管理者のみが使えるルート
+	admin := protected.Group("")
+	admin.Use(middlewares.AdminMiddleware())
+	// カテゴリの管理
+	admin.POST("/categories", controllers.CreateCategory)
+	admin.PUT("/categories/:id", controllers.UpdateCategory)
+	admin.DELETE("/categories/:id", controllers.DeleteCategory)
+	// 重複した場所の統合
+	admin.POST("/places/dedupe", controllers.DedupePlaces)
+	// 緯度・経度が未設定のアイテムの補完
+	admin.POST("/geocode/backfill", controllers.BackfillCoordinates)
+
 	err = router.Run(":8080")
 	if err != nil {
 		return
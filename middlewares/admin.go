@@ -0,0 +1,23 @@
+package middlewares
+
+import (
+	"backend/models"
+	"backend/utils/token"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminMiddleware 管理者のみ許可するミドルウェアを返します（JwtAuthMiddleware の後に適用する）
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, err := token.ExtractTokenId(c)
+		if err != nil || !models.IsAdmin(userId) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "管理者権限が必要です"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,129 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+)
+
+// Category 管理されたプランのカテゴリ
+type Category struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"size:100;not null" json:"name"`             // 表示名（例：「国内旅行」）
+	Slug      string    `gorm:"size:100;not null;uniqueIndex" json:"slug"` // URLやフィルタで使う識別子（例："domestic"）
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Tag プランに付ける自由入力のタグ
+type Tag struct {
+	ID   uint   `gorm:"primaryKey" json:"id"`
+	Name string `gorm:"size:100;not null;uniqueIndex" json:"name"` // 正規化されたタグ名
+}
+
+// FacetCount ファセットの値ごとの件数
+type FacetCount struct {
+	Value string `json:"value"` // カテゴリのスラッグまたはタグ名
+	Name  string `json:"name"`  // 表示名
+	Count int64  `json:"count"` // 該当するプラン数
+}
+
+// Facets 一覧に含まれるプランのカテゴリ・タグごとの件数
+type Facets struct {
+	Categories []FacetCount `json:"categories"`
+	Tags       []FacetCount `json:"tags"`
+}
+
+// NormalizeTagName タグ名を正規化する（全角英数の半角化、小文字化、先頭の#と余分な空白の除去）
+func NormalizeTagName(name string) string {
+	name = norm.NFKC.String(name)
+	name = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(name), "#"))
+	name = strings.Join(strings.Fields(name), " ")
+	return strings.ToLower(name)
+}
+
+// NormalizeTagNames タグ名の一覧を正規化し、空の値と重複を取り除く
+func NormalizeTagNames(names []string) []string {
+	seen := map[string]bool{}
+	result := []string{}
+	for _, name := range names {
+		name = NormalizeTagName(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, name)
+	}
+	return result
+}
+
+// FindOrCreateTags タグ名に対応するタグを取得し、存在しないものは作成する
+func FindOrCreateTags(tx *gorm.DB, names []string) ([]Tag, error) {
+	tags := []Tag{}
+	for _, name := range NormalizeTagNames(names) {
+		tag := Tag{Name: name}
+		if err := tx.Where(Tag{Name: name}).FirstOrCreate(&tag).Error; err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// WithCategory 指定したスラッグのカテゴリに属するプランに絞り込むスコープ
+func WithCategory(slug string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if slug == "" {
+			return db
+		}
+		return db.Where("travel_plans.category_id IN (?)",
+			DB.Model(&Category{}).Select("id").Where("slug = ?", slug))
+	}
+}
+
+// WithTags 指定したタグをすべて持つプランに絞り込むスコープ
+func WithTags(names []string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		names = NormalizeTagNames(names)
+		if len(names) == 0 {
+			return db
+		}
+		return db.Where("travel_plans.id IN (?)",
+			DB.Table("plan_tags").
+				Select("plan_tags.travel_plan_id").
+				Joins("JOIN tags ON tags.id = plan_tags.tag_id").
+				Where("tags.name IN ?", names).
+				Group("plan_tags.travel_plan_id").
+				Having("COUNT(DISTINCT tags.id) = ?", len(names)))
+	}
+}
+
+// CountFacets 条件に一致するプランのカテゴリ・タグごとの件数を集計する
+// plans はtravel_plansテーブルに対する絞り込み済みのクエリ
+func CountFacets(plans *gorm.DB) (Facets, error) {
+	facets := Facets{Categories: []FacetCount{}, Tags: []FacetCount{}}
+	ids := plans.Session(&gorm.Session{}).Select("travel_plans.id")
+
+	err := DB.Table("travel_plans").
+		Select("categories.slug AS value, categories.name AS name, COUNT(*) AS count").
+		Joins("JOIN categories ON categories.id = travel_plans.category_id").
+		Where("travel_plans.id IN (?)", ids).
+		Group("categories.id, categories.slug, categories.name").
+		Order("count DESC, categories.slug ASC").
+		Scan(&facets.Categories).Error
+	if err != nil {
+		return facets, err
+	}
+
+	err = DB.Table("plan_tags").
+		Select("tags.name AS value, tags.name AS name, COUNT(DISTINCT plan_tags.travel_plan_id) AS count").
+		Joins("JOIN tags ON tags.id = plan_tags.tag_id").
+		Where("plan_tags.travel_plan_id IN (?)", ids).
+		Group("tags.id, tags.name").
+		Order("count DESC, tags.name ASC").
+		Scan(&facets.Tags).Error
+
+	return facets, err
+}
@@ -0,0 +1,14 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNormalizeTagNames タグ名が正規化され、重複が取り除かれることを確認する
+func TestNormalizeTagNames(t *testing.T) {
+	names := NormalizeTagNames([]string{"#京都", " 京都 ", "ＫＹＯＴＯ", "kyoto", "紅葉  狩り", "", "#"})
+
+	assert.Equal(t, []string{"京都", "kyoto", "紅葉 狩り"}, names)
+}
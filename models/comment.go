@@ -0,0 +1,127 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// mentionPattern 本文中のメンション（例："@taro"）
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9_.\-]+)`)
+
+// Comment プランまたはアイテムに付けるコメント
+type Comment struct {
+	ID        uint             `gorm:"primaryKey" json:"id"`
+	PlanID    string           `gorm:"size:32;not null;index" json:"planId"`
+	ItemID    *string          `gorm:"size:32;index" json:"itemId"`    // アイテムへのコメントの場合のアイテムID
+	ParentID  *uint            `gorm:"index" json:"parentId"`          // 返信先のコメントID
+	AuthorID  uint             `gorm:"not null;index" json:"authorId"` // 投稿したユーザーのID
+	Author    string           `gorm:"-" json:"author"`                // 投稿したユーザーのユーザー名
+	Body      string           `gorm:"type:text;not null" json:"body"`
+	Mentions  []CommentMention `gorm:"foreignKey:CommentID" json:"mentions"`
+	CreatedAt time.Time        `json:"createdAt"`
+	UpdatedAt time.Time        `json:"updatedAt"`
+	Edited    bool             `json:"edited"`           // 投稿後に編集されたかどうか
+	DeletedAt gorm.DeletedAt   `gorm:"index" json:"-"`   // 削除日時
+	Deleted   bool             `gorm:"-" json:"deleted"` // 削除済み（返信が残っているため本文を伏せて表示している）
+	Replies   []*Comment       `gorm:"-" json:"replies"` // 返信
+}
+
+// CommentMention コメントでメンションされたユーザー
+type CommentMention struct {
+	ID        uint   `gorm:"primaryKey" json:"-"`
+	CommentID uint   `gorm:"not null;index" json:"-"`
+	UserID    uint   `gorm:"not null;index" json:"userId"`
+	Username  string `gorm:"-" json:"username"`
+}
+
+// MentionedUsernames 本文中でメンションされたユーザー名を出現順に重複なく返す（小文字に揃える）
+func MentionedUsernames(body string) []string {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, m := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		name := strings.ToLower(strings.TrimRight(m[1], ".-"))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// ResolveMentions 本文中のメンションのうち、プランの作成者またはメンバーであるユーザーを返す
+// メンバーでないユーザーへのメンションは無視する
+func ResolveMentions(tx *gorm.DB, plan *TravelPlan, body string) ([]CommentMention, error) {
+	names := MentionedUsernames(body)
+	if len(names) == 0 {
+		return []CommentMention{}, nil
+	}
+
+	var users []User
+	err := tx.Select("id", "username").
+		Where("username IN ?", names).
+		Where("id = ? OR id IN (?)", plan.CreatorID,
+			tx.Model(&PlanMember{}).Select("user_id").Where("plan_id = ?", plan.ID)).
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byName := map[string]uint{}
+	for _, u := range users {
+		byName[u.Username] = u.ID
+	}
+	mentions := []CommentMention{}
+	for _, name := range names {
+		if id, ok := byName[name]; ok {
+			mentions = append(mentions, CommentMention{UserID: id, Username: name})
+		}
+	}
+	return mentions, nil
+}
+
+// CommentThreads コメントを返信のツリーにまとめ、投稿日時の順に並べたスレッドの一覧を返す
+// 削除されたコメントは、返信が残っている場合のみ本文を伏せて残す
+func CommentThreads(comments []Comment) []*Comment {
+	byID := map[uint]*Comment{}
+	for i := range comments {
+		c := &comments[i]
+		c.Replies = []*Comment{}
+		if c.DeletedAt.Valid {
+			c.Deleted = true
+			c.Body = ""
+			c.Mentions = []CommentMention{}
+		}
+		byID[c.ID] = c
+	}
+
+	roots := []*Comment{}
+	for i := range comments {
+		c := &comments[i]
+		if c.ParentID != nil {
+			if parent, ok := byID[*c.ParentID]; ok {
+				parent.Replies = append(parent.Replies, c)
+				continue
+			}
+		}
+		roots = append(roots, c)
+	}
+
+	return pruneDeleted(roots)
+}
+
+// pruneDeleted 返信のない削除済みコメントを取り除く
+func pruneDeleted(comments []*Comment) []*Comment {
+	kept := []*Comment{}
+	for _, c := range comments {
+		c.Replies = pruneDeleted(c.Replies)
+		if c.Deleted && len(c.Replies) == 0 {
+			continue
+		}
+		kept = append(kept, c)
+	}
+	return kept
+}
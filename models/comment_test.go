@@ -0,0 +1,43 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// TestMentionedUsernames 本文からメンションを重複なく取り出すことを確認する
+func TestMentionedUsernames(t *testing.T) {
+	assert.Equal(t, []string{"taro", "hanako.y"},
+		MentionedUsernames("@Taro 清水寺の予約お願い。@hanako.y も確認して @taro."))
+	assert.Empty(t, MentionedUsernames("連絡先は mail@example.com です"))
+	assert.Empty(t, MentionedUsernames("@@"))
+}
+
+// TestCommentThreads 返信がツリーにまとめられ、返信のない削除済みコメントが取り除かれることを確認する
+func TestCommentThreads(t *testing.T) {
+	parent := func(id uint) *uint { return &id }
+	deleted := gorm.DeletedAt{Valid: true}
+
+	threads := CommentThreads([]Comment{
+		{ID: 1, Body: "集合は何時？"},
+		{ID: 2, Body: "削除済み", DeletedAt: deleted},
+		{ID: 3, ParentID: parent(1), Body: "9時にしよう"},
+		{ID: 4, ParentID: parent(2), Body: "返信"},
+		{ID: 5, Body: "返信なしの削除済み", DeletedAt: deleted},
+		{ID: 6, ParentID: parent(3), Body: "了解"},
+		{ID: 7, ParentID: parent(1), Body: "削除済みの返信", DeletedAt: deleted},
+	})
+
+	assert.Len(t, threads, 2)
+	assert.Equal(t, uint(1), threads[0].ID)
+	assert.Len(t, threads[0].Replies, 1)
+	assert.Equal(t, "了解", threads[0].Replies[0].Replies[0].Body)
+
+	// 返信が残っている削除済みのコメントは本文を伏せて残す
+	assert.Equal(t, uint(2), threads[1].ID)
+	assert.True(t, threads[1].Deleted)
+	assert.Empty(t, threads[1].Body)
+	assert.Equal(t, "返信", threads[1].Replies[0].Body)
+}
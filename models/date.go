@@ -0,0 +1,120 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// Date 時刻を持たない日付（YYYY-MM-DD）
+type Date struct {
+	time.Time
+}
+
+// NewDate 年月日から日付を作成する
+func NewDate(year int, month time.Month, day int) Date {
+	return Date{time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+// DateOf 時刻をその時刻が持つロケーションでの日付に変換する
+func DateOf(t time.Time) Date {
+	return NewDate(t.Date())
+}
+
+// ParseDate "YYYY-MM-DD" 形式の文字列を日付に変換する
+func ParseDate(s string) (Date, error) {
+	t, err := time.Parse(dateLayout, s)
+	if err != nil {
+		return Date{}, fmt.Errorf("invalid date %q: expected YYYY-MM-DD", s)
+	}
+	return Date{t}, nil
+}
+
+// String "YYYY-MM-DD" 形式で返す（ゼロ値の場合は空文字）
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.Format(dateLayout)
+}
+
+// AddDays 指定した日数を加算した日付を返す
+func (d Date) AddDays(n int) Date {
+	return Date{d.AddDate(0, 0, n)}
+}
+
+// DaysUntil 指定した日付までの日数を返す
+func (d Date) DaysUntil(other Date) int {
+	return int(other.Sub(d.Time).Hours() / 24)
+}
+
+// MarshalJSON 日付を "YYYY-MM-DD" 形式で出力する
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON "YYYY-MM-DD" 形式の日付を読み込む
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var s *string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == nil || *s == "" {
+		*d = Date{}
+		return nil
+	}
+	parsed, err := ParseDate(*s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Value データベースへ書き込む値を返す
+func (d Date) Value() (driver.Value, error) {
+	if d.IsZero() {
+		return nil, nil
+	}
+	return d.String(), nil
+}
+
+// Scan データベースから読み込んだ値を日付に変換する
+func (d *Date) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*d = Date{}
+	case time.Time:
+		*d = DateOf(v)
+	case []byte:
+		return d.scanString(string(v))
+	case string:
+		return d.scanString(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Date", value)
+	}
+	return nil
+}
+
+func (d *Date) scanString(s string) error {
+	if len(s) > len(dateLayout) {
+		s = s[:len(dateLayout)]
+	}
+	parsed, err := ParseDate(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// GormDataType カラムの型を返す
+func (Date) GormDataType() string {
+	return "date"
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// openTestDB テスト用のMySQLに接続し、ロールバックするトランザクションを返す
+// 環境変数 TEST_MYSQL_DSN が未設定の場合はテストをスキップする
+func openTestDB(t *testing.T) *gorm.DB {
+	dsn := os.Getenv("TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("TEST_MYSQL_DSN is not set")
+	}
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&Category{}, &Tag{}, &TravelPlan{}, &PlanItem{}, &Place{}, &TemplateItem{}, &PlanRevision{}); err != nil {
+		t.Fatal(err)
+	}
+	tx := db.Begin()
+	t.Cleanup(func() { tx.Rollback() })
+	return tx
+}
+
+// TestMergePlacesExternalID 外部IDを持つ場所を外部IDのない古い場所に統合できることを確認する
+func TestMergePlacesExternalID(t *testing.T) {
+	tx := openTestDB(t)
+
+	osm := "way/123"
+	older := placeAt(0, "清水寺", "", 34.994856, 135.785046)
+	newer := placeAt(0, "清水寺", "京都市東山区清水1丁目294", 34.9949, 135.7851)
+	newer.ExternalSource, newer.ExternalID = "osm", &osm
+	if !assert.NoError(t, tx.Create(&older).Error) || !assert.NoError(t, tx.Create(&newer).Error) {
+		return
+	}
+	plan := TravelPlan{Title: "京都", CreatorID: 1, Items: []PlanItem{{Title: "清水寺観光", PlaceID: &newer.ID}}}
+	if !assert.NoError(t, tx.Create(&plan).Error) {
+		return
+	}
+
+	assert.NoError(t, MergePlaces(tx, &newer, &older))
+
+	var merged Place
+	assert.NoError(t, tx.First(&merged, older.ID).Error)
+	assert.Equal(t, "osm", merged.ExternalSource)
+	assert.Equal(t, osm, *merged.ExternalID)
+	assert.Equal(t, "京都市東山区清水1丁目294", merged.Address)
+
+	var item PlanItem
+	assert.NoError(t, tx.First(&item, "plan_id = ?", plan.ID).Error)
+	assert.Equal(t, older.ID, *item.PlaceID)
+
+	var count int64
+	tx.Model(&Place{}).Where("id = ?", newer.ID).Count(&count)
+	assert.Zero(t, count)
+}
+
+// TestRestoreRevisionDeletedCategory リビジョンの保存後に削除されたカテゴリを参照せずに復元できることを確認する
+func TestRestoreRevisionDeletedCategory(t *testing.T) {
+	tx := openTestDB(t)
+	original := DB
+	DB = tx
+	defer func() { DB = original }()
+
+	category := Category{Name: "国内旅行", Slug: "restore-test-domestic"}
+	if !assert.NoError(t, tx.Create(&category).Error) {
+		return
+	}
+	plan := TravelPlan{Title: "京都", CreatorID: 1, CategoryID: &category.ID}
+	if !assert.NoError(t, tx.Create(&plan).Error) || !assert.NoError(t, RecordRevision(tx, plan.ID, 1, RevisionPlanCreated)) {
+		return
+	}
+	var revision PlanRevision
+	if !assert.NoError(t, tx.First(&revision, "plan_id = ?", plan.ID).Error) {
+		return
+	}
+
+	assert.NoError(t, tx.Model(&plan).Update("category_id", nil).Error)
+	assert.NoError(t, tx.Delete(&category).Error)
+	assert.NoError(t, tx.First(&plan, "id = ?", plan.ID).Error)
+
+	assert.NoError(t, RestoreRevision(&plan, &revision, 1))
+	var restored TravelPlan
+	assert.NoError(t, tx.First(&restored, "id = ?", plan.ID).Error)
+	assert.Nil(t, restored.CategoryID)
+}
+
+// TestFindOrCreatePlaceUntrusted 利用者の入力では既存の場所を変更せず、外部IDを設定しないことを確認する
+func TestFindOrCreatePlaceUntrusted(t *testing.T) {
+	tx := openTestDB(t)
+
+	existing := placeAt(0, "清水寺", "", 34.994856, 135.785046)
+	if !assert.NoError(t, tx.Create(&existing).Error) {
+		return
+	}
+
+	lat, lng := 34.9949, 135.7851
+	place, created, err := FindOrCreatePlace(tx, PlaceInput{
+		Name: "清水寺", Address: "偽の住所", Latitude: &lat, Longitude: &lng, ExternalSource: "osm", ExternalID: "way/123",
+	}, false)
+	if !assert.NoError(t, err) {
+		return
+	}
+	// 外部IDの異なる場所として扱われず、既存の場所を変更せずに返す
+	assert.False(t, created)
+	assert.Equal(t, existing.ID, place.ID)
+	var reloaded Place
+	assert.NoError(t, tx.First(&reloaded, existing.ID).Error)
+	assert.Empty(t, reloaded.Address)
+	assert.Nil(t, reloaded.ExternalID)
+
+	// 新しく作成する場所にも外部IDを設定しない
+	place, created, err = FindOrCreatePlace(tx, PlaceInput{Name: "金閣寺", ExternalSource: "osm", ExternalID: "way/456"}, false)
+	if assert.NoError(t, err) {
+		assert.True(t, created)
+		assert.Nil(t, place.ExternalID)
+	}
+
+	// ジオコーダーの結果では補完する
+	_, _, err = FindOrCreatePlace(tx, PlaceInput{Name: "清水寺", Address: "京都市東山区清水1丁目294", Latitude: &lat, Longitude: &lng}, true)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.First(&reloaded, existing.ID).Error)
+	assert.Equal(t, "京都市東山区清水1丁目294", reloaded.Address)
+}
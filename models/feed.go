@@ -0,0 +1,72 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CalendarFeedToken カレンダーアプリから購読するためのユーザーごとの秘密のURLのトークン
+// トークン自体は保存せずハッシュのみを保存するため、URLを再表示することはできず、作り直すと以前のURLは使えなくなる
+type CalendarFeedToken struct {
+	ID             uint       `gorm:"primaryKey" json:"-"`
+	UserID         uint       `gorm:"not null;uniqueIndex" json:"-"`
+	TokenHash      string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	LastAccessedAt *time.Time `json:"lastAccessedAt"` // 最後に購読された日時
+}
+
+// IssueCalendarFeedToken ユーザーの購読用トークンを発行する（以前のトークンは無効になる）
+func IssueCalendarFeedToken(userID uint) (string, *CalendarFeedToken, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+	secret := base64.RawURLEncoding.EncodeToString(b)
+
+	feed := CalendarFeedToken{UserID: userID, TokenHash: hashFeedToken(secret)}
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&CalendarFeedToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&feed).Error
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return secret, &feed, nil
+}
+
+// RevokeCalendarFeedToken ユーザーの購読用トークンを無効にする
+func RevokeCalendarFeedToken(userID uint) error {
+	return DB.Where("user_id = ?", userID).Delete(&CalendarFeedToken{}).Error
+}
+
+// FindCalendarFeedToken トークンに対応する購読を取得し、最終アクセス日時を記録する
+func FindCalendarFeedToken(secret string) (*CalendarFeedToken, error) {
+	var feed CalendarFeedToken
+	if err := DB.First(&feed, "token_hash = ?", hashFeedToken(secret)).Error; err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	DB.Model(&feed).UpdateColumn("last_accessed_at", now)
+	feed.LastAccessedAt = &now
+	return &feed, nil
+}
+
+// CalendarFeedPlans ユーザーが作成したか、メンバーになっている確定済みのプランを絞り込むクエリを返す
+func CalendarFeedPlans(userID uint) *gorm.DB {
+	return DB.Model(&TravelPlan{}).
+		Where("status = ?", "confirmed").
+		Where("creator_id = ? OR id IN (?)", userID,
+			DB.Model(&PlanMember{}).Select("plan_id").Where("user_id = ?", userID))
+}
+
+func hashFeedToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// firstDate プランの開始日を返す（未設定の場合は最初のアイテムの日付）
+func (p *TravelPlan) firstDate() Date {
+	if !p.StartDate.IsZero() {
+		return p.StartDate
+	}
+	var first Date
+	for i := range p.Items {
+		d := p.LocalDate(&p.Items[i])
+		if first.IsZero() || d.Before(first.Time) {
+			first = d
+		}
+	}
+	return first
+}
+
+// shiftDays 時刻を指定したタイムゾーンでの日数だけずらす（現地の時刻は変えない）
+func shiftDays(t time.Time, loc *time.Location, days int) time.Time {
+	if t.IsZero() || days == 0 {
+		return t
+	}
+	return t.In(loc).AddDate(0, 0, days)
+}
+
+// Copy プランとアイテムを新しい下書きとして複製する（保存はしない）
+// startDate を指定した場合は、各アイテムを現地時刻を保ったまま日単位でずらす
+func (p *TravelPlan) Copy(ownerID uint, startDate Date) TravelPlan {
+	days := 0
+	if first := p.firstDate(); !startDate.IsZero() && !first.IsZero() {
+		days = first.DaysUntil(startDate)
+	}
+
+	copied := TravelPlan{
+		Title:       p.Title,
+		Description: p.Description,
+		TotalCost:   p.TotalCost,
+		TimeZone:    p.TimeZone,
+		CreatorID:   ownerID,
+		IsPublic:    false,
+		Status:      "draft",
+		CategoryID:  p.CategoryID,
+		Tags:        p.Tags,
+	}
+	if !p.StartDate.IsZero() {
+		copied.StartDate = p.StartDate.AddDays(days)
+	}
+	if !p.EndDate.IsZero() {
+		copied.EndDate = p.EndDate.AddDays(days)
+	}
+
+	for i := range p.Items {
+		item := p.Items[i]
+		loc := p.ItemLocation(&item)
+		item.ID = ""
+		item.PlanID = ""
+		item.Place = nil // 場所は共有するためIDのみ引き継ぐ
+		item.StartTime = shiftDays(item.StartTime, loc, days)
+		item.EndTime = shiftDays(item.EndTime, loc, days)
+		item.Duration = item.DurationMinutes()
+		copied.Items = append(copied.Items, item)
+	}
+
+	return copied
+}
+
+// ForkPlan 元のプランを複製してユーザーのプランとして保存し、元のプランのフォーク数を増やす
+func ForkPlan(source *TravelPlan, ownerID uint, startDate Date) (*TravelPlan, error) {
+	forked := source.Copy(ownerID, startDate)
+	forked.SourcePlanID = &source.ID
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&forked).Error; err != nil {
+			return err
+		}
+		if err := RecordRevision(tx, forked.ID, ownerID, RevisionPlanForked); err != nil {
+			return err
+		}
+		return tx.Model(&TravelPlan{}).Where("id = ?", source.ID).
+			UpdateColumn("fork_count", gorm.Expr("fork_count + ?", 1)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &forked, nil
+}
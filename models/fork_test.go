@@ -0,0 +1,57 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCopyShiftsItems 複製時に新しい開始日へ現地時刻を保ったままずれることを確認する
+func TestCopyShiftsItems(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+	source := TravelPlan{
+		ID:        "source",
+		Title:     "ニューヨーク旅行",
+		TimeZone:  "America/New_York",
+		StartDate: NewDate(2026, 10, 30),
+		EndDate:   NewDate(2026, 10, 31),
+		CreatorID: 1,
+		IsPublic:  true,
+		Status:    "completed",
+		Items: []PlanItem{
+			{
+				ID:        "item",
+				PlanID:    "source",
+				Title:     "自由の女神",
+				StartTime: time.Date(2026, 10, 31, 9, 0, 0, 0, newYork),
+				EndTime:   time.Date(2026, 10, 31, 12, 0, 0, 0, newYork),
+			},
+		},
+	}
+
+	// 夏時間の終了（11/1）をまたいで2日後にずらす
+	copied := source.Copy(2, NewDate(2026, 11, 1))
+
+	assert.Equal(t, uint(2), copied.CreatorID)
+	assert.False(t, copied.IsPublic)
+	assert.Equal(t, "draft", copied.Status)
+	assert.Equal(t, "2026-11-01", copied.StartDate.String())
+	assert.Equal(t, "2026-11-02", copied.EndDate.String())
+	assert.Equal(t, "", copied.Items[0].ID)
+	assert.Equal(t, "2026-11-02T09:00:00-05:00", copied.Items[0].StartTime.Format(time.RFC3339))
+	assert.Equal(t, 180, copied.Items[0].Duration)
+	// 元のプランは変更されない
+	assert.Equal(t, "item", source.Items[0].ID)
+}
+
+// TestCopyWithoutStartDate 開始日を指定しない場合は同じ日程で複製されることを確認する
+func TestCopyWithoutStartDate(t *testing.T) {
+	start := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	source := TravelPlan{Items: []PlanItem{{StartTime: start}}}
+
+	copied := source.Copy(1, Date{})
+
+	assert.True(t, start.Equal(copied.Items[0].StartTime))
+	assert.True(t, copied.StartDate.IsZero())
+}
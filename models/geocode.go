@@ -0,0 +1,324 @@
+package models
+
+import (
+	"backend/utils/geocode"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	geocodeCacheTTL         = 30 * 24 * time.Hour // 見つかった結果をキャッシュする期間
+	geocodeNegativeCacheTTL = 24 * time.Hour      // 見つからなかったことをキャッシュする期間
+	geocodeBatchSize        = 100
+)
+
+// Geocoder アイテムの場所を緯度・経度に変換するジオコーダー（未設定の場合はnil）
+// ConnectDataBaseで環境変数 GEOCODER に応じて設定される
+var Geocoder geocode.Geocoder
+
+// ErrBackfillRunning 緯度・経度の補完が既に実行中の場合のエラー
+var ErrBackfillRunning = errors.New("緯度・経度の補完は実行中です")
+
+// backfillMu 緯度・経度の補完が同時に実行されないようにする
+var backfillMu sync.Mutex
+
+// NewGeocoderFromEnv 環境変数の設定からジオコーダーを作成する（GEOCODER が未設定の場合はnil）
+//
+//	GEOCODER=nominatim  Nominatim互換のAPI（NOMINATIM_URL、GEOCODER_USER_AGENT、GEOCODER_EMAIL）
+//	GEOCODER=gazetteer  ローカルの地名辞書（GAZETTEER_PATH）
+//
+// 検索結果はデータベースにキャッシュする
+func NewGeocoderFromEnv(db *gorm.DB) (geocode.Geocoder, error) {
+	var g geocode.Geocoder
+	switch provider := os.Getenv("GEOCODER"); provider {
+	case "":
+		return nil, nil
+	case "nominatim":
+		userAgent := os.Getenv("GEOCODER_USER_AGENT")
+		if userAgent == "" {
+			userAgent = "my_home_backend"
+		}
+		nominatim := geocode.NewNominatim(os.Getenv("NOMINATIM_URL"), userAgent)
+		nominatim.Email = os.Getenv("GEOCODER_EMAIL")
+		g = nominatim
+	case "gazetteer":
+		gazetteer, err := geocode.OpenGazetteer(os.Getenv("GAZETTEER_PATH"))
+		if err != nil {
+			return nil, err
+		}
+		g = gazetteer
+	default:
+		return nil, fmt.Errorf("unknown geocoder %q", provider)
+	}
+	return geocode.NewCached(g, NewMySQLGeocodeCache(db), geocodeCacheTTL, geocodeNegativeCacheTTL), nil
+}
+
+// GeocodeCacheEntry ジオコーディングの検索結果のキャッシュ
+type GeocodeCacheEntry struct {
+	Key      string    `gorm:"primaryKey;size:64"` // キャッシュのキーのSHA-256（長い文字列もキーにできるようにする）
+	Query    string    `gorm:"type:text"`          // キャッシュのキー（プロバイダ名と正規化した検索文字列）
+	Results  string    `gorm:"type:mediumtext"`    // 検索結果のJSON
+	StoredAt time.Time // 保存日時
+}
+
+// MySQLGeocodeCache データベースに保存するジオコーディングのキャッシュ
+type MySQLGeocodeCache struct {
+	db *gorm.DB
+}
+
+// NewMySQLGeocodeCache データベースのキャッシュを作成する
+func NewMySQLGeocodeCache(db *gorm.DB) *MySQLGeocodeCache {
+	return &MySQLGeocodeCache{db: db}
+}
+
+func geocodeCacheKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Get キーに対応する結果を返す
+func (m *MySQLGeocodeCache) Get(key string) (geocode.Entry, bool, error) {
+	var row GeocodeCacheEntry
+	err := m.db.Take(&row, "`key` = ?", geocodeCacheKey(key)).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return geocode.Entry{}, false, nil
+	}
+	if err != nil {
+		return geocode.Entry{}, false, err
+	}
+
+	entry := geocode.Entry{Stored: row.StoredAt}
+	if err := json.Unmarshal([]byte(row.Results), &entry.Results); err != nil {
+		return geocode.Entry{}, false, err
+	}
+	return entry, true, nil
+}
+
+// Set キーに対応する結果を保存する
+func (m *MySQLGeocodeCache) Set(key string, entry geocode.Entry) error {
+	results, err := json.Marshal(entry.Results)
+	if err != nil {
+		return err
+	}
+	row := GeocodeCacheEntry{
+		Key:      geocodeCacheKey(key),
+		Query:    key,
+		Results:  string(results),
+		StoredAt: entry.Stored,
+	}
+	return m.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&row).Error
+}
+
+// GeocodeReport 緯度・経度の補完の結果
+type GeocodeReport struct {
+	Checked   int `json:"checked"`   // 対象のアイテム数
+	Geocoded  int `json:"geocoded"`  // ジオコーディングで緯度・経度を設定したアイテム数
+	FromPlace int `json:"fromPlace"` // 参照している場所の緯度・経度を設定したアイテム数
+	NotFound  int `json:"notFound"`  // 場所が見つからなかったアイテム数
+	Failed    int `json:"failed"`    // ジオコーダーのエラーで補完できなかったアイテム数
+	Conflicts int `json:"conflicts"` // 補完中に更新されたため次回に回したアイテム数
+}
+
+// geocodedItem 緯度・経度を補完するアイテムと設定する場所
+type geocodedItem struct {
+	item  *PlanItem
+	place *Place
+}
+
+// BackfillCoordinates 緯度・経度が未設定で場所（Location）が入力されたアイテムに緯度・経度を設定する
+// 参照している場所に緯度・経度があればそれを使い、なければ Geocoder で場所を検索し、最も一致する場所を参照させる
+// プランごとにバージョンを進め、リビジョンを記録する（ユーザーによる変更と同じ扱いにする）
+// 既に実行中の場合は ErrBackfillRunning を返す
+func BackfillCoordinates(ctx context.Context, g geocode.Geocoder) (GeocodeReport, error) {
+	if !backfillMu.TryLock() {
+		return GeocodeReport{}, ErrBackfillRunning
+	}
+	defer backfillMu.Unlock()
+	return backfillCoordinates(ctx, g)
+}
+
+// StartBackfillCoordinates 緯度・経度の補完をバックグラウンドで開始する
+// 既に実行中の場合は ErrBackfillRunning を返す
+func StartBackfillCoordinates(g geocode.Geocoder) error {
+	if !backfillMu.TryLock() {
+		return ErrBackfillRunning
+	}
+	go func() {
+		defer backfillMu.Unlock()
+		logBackfillReport(backfillCoordinates(context.Background(), g))
+	}()
+	return nil
+}
+
+func backfillCoordinates(ctx context.Context, g geocode.Geocoder) (GeocodeReport, error) {
+	var report GeocodeReport
+
+	// 同じ場所の文字列は1回だけ検索する（見つからなかった場合はnil）
+	places := map[string]*Place{}
+	cursor := ""
+	for {
+		var items []PlanItem
+		if err := DB.Preload("Place").
+			Where("latitude IS NULL AND location <> '' AND id > ?", cursor).
+			Order("id").Limit(geocodeBatchSize).Find(&items).Error; err != nil {
+			return report, err
+		}
+		if len(items) == 0 {
+			return report, nil
+		}
+		cursor = items[len(items)-1].ID
+
+		byPlan := map[string][]geocodedItem{}
+		var planIDs []string
+		for i := range items {
+			item := &items[i]
+			report.Checked++
+
+			var place *Place
+			if item.Place != nil && item.Place.HasCoordinates() {
+				place = item.Place
+				report.FromPlace++
+			} else {
+				var err error
+				place, err = geocodeLocation(ctx, g, item.Location, places)
+				if err != nil {
+					if ctx.Err() != nil {
+						return report, ctx.Err()
+					}
+					log.Printf("geocode: failed to geocode %q: %v", item.Location, err)
+					report.Failed++
+					continue
+				}
+				if place == nil {
+					report.NotFound++
+					continue
+				}
+				report.Geocoded++
+			}
+
+			if _, ok := byPlan[item.PlanID]; !ok {
+				planIDs = append(planIDs, item.PlanID)
+			}
+			byPlan[item.PlanID] = append(byPlan[item.PlanID], geocodedItem{item, place})
+		}
+
+		for _, planID := range planIDs {
+			err := applyGeocodedItems(planID, byPlan[planID])
+			if errors.Is(err, ErrVersionConflict) || errors.Is(err, gorm.ErrRecordNotFound) {
+				report.Conflicts += len(byPlan[planID])
+				continue
+			}
+			if err != nil {
+				return report, err
+			}
+		}
+	}
+}
+
+// geocodeLocation 場所の文字列を検索し、最も一致する場所を返す（見つからない場合はnil）
+// 見つかった場所は Place として登録し、他のアイテムやプランと共有する
+func geocodeLocation(ctx context.Context, g geocode.Geocoder, location string, places map[string]*Place) (*Place, error) {
+	key := geocode.NormalizeQuery(location)
+	if place, ok := places[key]; ok {
+		return place, nil
+	}
+
+	results, err := g.Geocode(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		places[key] = nil
+		return nil, nil
+	}
+
+	r := results[0]
+	input := PlaceInput{
+		Name:      r.Name,
+		Address:   r.Address,
+		Latitude:  &r.Latitude,
+		Longitude: &r.Longitude,
+	}
+	if strings.TrimSpace(input.Name) == "" {
+		input.Name = location
+	}
+	if r.ExternalID != "" {
+		input.ExternalSource, input.ExternalID = r.Source, r.ExternalID
+	}
+	place, _, err := FindOrCreatePlace(DB, input, true)
+	if err != nil {
+		return nil, err
+	}
+	// 既存の場所に緯度・経度がなかった場合は検索結果で補完されている
+	places[key] = place
+	return place, nil
+}
+
+// applyGeocodedItems プランのアイテムに場所の緯度・経度を設定し、リビジョンを記録する
+// 補完中にユーザーが緯度・経度を設定したアイテムは変更しない
+func applyGeocodedItems(planID string, items []geocodedItem) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var plan TravelPlan
+		if err := tx.Select("id", "version").First(&plan, "id = ?", planID).Error; err != nil {
+			return err
+		}
+		if err := BumpPlanVersion(tx, plan.ID, plan.Version); err != nil {
+			return err
+		}
+
+		for _, g := range items {
+			if err := BumpItemVersion(tx, g.item.ID, g.item.Version); err != nil {
+				return err
+			}
+			updates := map[string]interface{}{
+				"latitude":  g.place.Latitude,
+				"longitude": g.place.Longitude,
+			}
+			if g.item.PlaceID == nil {
+				updates["place_id"] = g.place.ID
+			}
+			if err := tx.Model(&PlanItem{}).Where("id = ? AND latitude IS NULL", g.item.ID).
+				Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		return RecordRevision(tx, plan.ID, 0, RevisionItemsGeocoded)
+	})
+}
+
+// BackfillCoordinatesPeriodically 一定間隔で緯度・経度が未設定のアイテムを補完する
+// バックグラウンドのゴルーチンとして起動する
+func BackfillCoordinatesPeriodically(g geocode.Geocoder, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		report, err := BackfillCoordinates(context.Background(), g)
+		if !errors.Is(err, ErrBackfillRunning) {
+			logBackfillReport(report, err)
+		}
+		<-ticker.C
+	}
+}
+
+// logBackfillReport 緯度・経度の補完の結果をログに残す
+func logBackfillReport(report GeocodeReport, err error) {
+	if err != nil {
+		log.Println("Could not backfill item coordinates", err)
+	} else if report.Geocoded+report.FromPlace > 0 {
+		log.Printf("Backfilled coordinates of %d items (%d not found, %d failed)",
+			report.Geocoded+report.FromPlace, report.NotFound, report.Failed)
+	}
+}
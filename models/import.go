@@ -0,0 +1,116 @@
+package models
+
+import (
+	"backend/utils/ical"
+	"fmt"
+	"sort"
+)
+
+// ImportIssue 取り込み時に読み飛ばした、または確認が必要な予定
+type ImportIssue struct {
+	Line    int    `json:"line"`             // 予定の行番号
+	UID     string `json:"uid"`              // 予定のUID
+	Summary string `json:"summary"`          // 予定の件名
+	ItemID  string `json:"itemId,omitempty"` // 取り込んだアイテムのID
+	Reason  string `json:"reason"`           // 理由
+	index   int    // 取り込んだアイテムの位置（保存後にItemIDを設定するため）
+}
+
+// ImportReport カレンダーの取り込み結果
+type ImportReport struct {
+	Imported  int           `json:"imported"`  // 取り込んだ予定の数
+	Skipped   []ImportIssue `json:"skipped"`   // 読み飛ばした予定
+	Ambiguous []ImportIssue `json:"ambiguous"` // 取り込んだが内容を推定した予定
+}
+
+// PlanFromCalendar カレンダーの予定から新しい下書きのプランを作成する（保存はしない）
+// アイテムは開始日時の順に並べ、旅行期間は最初と最後の予定の日付とする
+// 終日の予定・中止された予定・日時のない予定は読み飛ばす
+func PlanFromCalendar(cal *ical.ParsedCalendar, ownerID uint, title, timeZone string) (TravelPlan, ImportReport) {
+	plan := TravelPlan{
+		Title:     title,
+		TimeZone:  timeZone,
+		CreatorID: ownerID,
+		IsPublic:  false,
+		Status:    "draft",
+	}
+	report := ImportReport{Skipped: []ImportIssue{}, Ambiguous: []ImportIssue{}}
+
+	// 開始日時の順にアイテムを並べる
+	events := make([]ical.ParsedEvent, len(cal.Events))
+	copy(events, cal.Events)
+	sort.SliceStable(events, func(a, b int) bool { return events[a].Start.Before(events[b].Start) })
+
+	for _, e := range events {
+		issue := ImportIssue{Line: e.Line, UID: e.UID, Summary: e.Summary}
+		switch {
+		case e.Cancelled:
+			issue.Reason = "中止された予定です"
+		case e.Start.IsZero():
+			issue.Reason = "開始日時を読み込めません"
+		case e.AllDay:
+			issue.Reason = "終日の予定は取り込めません"
+		case e.Summary == "":
+			issue.Reason = "件名がありません"
+		}
+		if issue.Reason != "" {
+			report.Skipped = append(report.Skipped, issue)
+			continue
+		}
+
+		itemType, ambiguous := GuessItemType(e.Categories, e.Summary+" "+e.Location)
+		item := PlanItem{
+			Type:        itemType,
+			Title:       e.Summary,
+			Description: e.Description,
+			Location:    e.Location,
+			StartTime:   e.Start,
+			EndTime:     e.End,
+			Order:       len(plan.Items) + 1,
+		}
+		// IANAのタイムゾーンで指定された予定で、プランと異なる場合はアイテムにタイムゾーンを設定する
+		if name := e.Start.Location().String(); name != timeZone && name != "UTC" && ValidTimeZone(name) {
+			item.TimeZone = name
+		}
+		item.Duration = item.DurationMinutes()
+
+		var reasons []string
+		if ambiguous {
+			reasons = append(reasons, fmt.Sprintf("種類を %q と推定しました", itemType))
+		}
+		if e.Recurring {
+			reasons = append(reasons, "繰り返しの予定のため、最初の1回のみ取り込みました")
+		}
+		reasons = append(reasons, e.Warnings...)
+		for _, reason := range reasons {
+			issue := issue
+			issue.Reason = reason
+			issue.index = len(plan.Items)
+			report.Ambiguous = append(report.Ambiguous, issue)
+		}
+
+		plan.Items = append(plan.Items, item)
+	}
+
+	for i := range plan.Items {
+		d := plan.LocalDate(&plan.Items[i])
+		if plan.StartDate.IsZero() || d.Before(plan.StartDate.Time) {
+			plan.StartDate = d
+		}
+		if plan.EndDate.IsZero() || d.After(plan.EndDate.Time) {
+			plan.EndDate = d
+		}
+	}
+
+	report.Imported = len(plan.Items)
+	return plan, report
+}
+
+// AssignItemIDs 保存後のプランのアイテムIDを取り込み結果に設定する
+func (r *ImportReport) AssignItemIDs(plan *TravelPlan) {
+	for i := range r.Ambiguous {
+		if idx := r.Ambiguous[i].index; idx < len(plan.Items) {
+			r.Ambiguous[i].ItemID = plan.Items[idx].ID
+		}
+	}
+}
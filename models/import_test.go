@@ -0,0 +1,79 @@
+package models
+
+import (
+	"backend/utils/ical"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGuessItemType カテゴリとタイトルからアイテムの種類を推定できることを確認する
+func TestGuessItemType(t *testing.T) {
+	tests := []struct {
+		categories []string
+		title      string
+		want       string
+		ambiguous  bool
+	}{
+		{nil, "羽田→伊丹 JAL123便", ItemTypeTransport, false},
+		{nil, "Dinner at Kikunoi", ItemTypeMeal, false},
+		{nil, "ホテルにチェックイン", ItemTypeLodging, false},
+		{nil, "清水寺", ItemTypeVisit, false},
+		{[]string{"Flight"}, "夕食", ItemTypeTransport, false},
+		{nil, "打ち合わせ", ItemTypeVisit, true},
+		{nil, "空港のレストランで昼食", ItemTypeMeal, true},
+	}
+	for _, tt := range tests {
+		got, ambiguous := GuessItemType(tt.categories, tt.title)
+		assert.Equal(t, tt.want, got, tt.title)
+		assert.Equal(t, tt.ambiguous, ambiguous, tt.title)
+	}
+}
+
+// TestPlanFromCalendar 予定がアイテムとして並び、取り込めない予定が報告されることを確認する
+func TestPlanFromCalendar(t *testing.T) {
+	tokyo := mustLoad(t, "Asia/Tokyo")
+	seoul := mustLoad(t, "Asia/Seoul")
+	cal := &ical.ParsedCalendar{Events: []ical.ParsedEvent{
+		{UID: "b", Summary: "景福宮", Start: time.Date(2026, 4, 2, 10, 0, 0, 0, seoul), End: time.Date(2026, 4, 2, 12, 0, 0, 0, seoul), Line: 20},
+		{UID: "a", Summary: "成田→仁川 便", Start: time.Date(2026, 4, 1, 9, 0, 0, 0, tokyo), End: time.Date(2026, 4, 1, 11, 30, 0, 0, tokyo), Line: 10},
+		{UID: "c", Summary: "ホテル", AllDay: true, Start: time.Date(2026, 4, 1, 0, 0, 0, 0, tokyo), Line: 30},
+		{UID: "d", Summary: "中止", Cancelled: true, Start: time.Date(2026, 4, 1, 0, 0, 0, 0, tokyo), Line: 40},
+		{UID: "e", Summary: "朝の散歩", Recurring: true, Start: time.Date(2026, 4, 3, 7, 0, 0, 0, tokyo), End: time.Date(2026, 4, 3, 7, 30, 0, 0, tokyo), Line: 50},
+	}}
+
+	plan, report := PlanFromCalendar(cal, 7, "ソウル旅行", "Asia/Tokyo")
+	assert.Equal(t, uint(7), plan.CreatorID)
+	assert.Equal(t, "draft", plan.Status)
+	assert.False(t, plan.IsPublic)
+	assert.Equal(t, NewDate(2026, 4, 1), plan.StartDate)
+	assert.Equal(t, NewDate(2026, 4, 3), plan.EndDate)
+
+	assert.Len(t, plan.Items, 3)
+	assert.Equal(t, "成田→仁川 便", plan.Items[0].Title)
+	assert.Equal(t, ItemTypeTransport, plan.Items[0].Type)
+	assert.Equal(t, 150, plan.Items[0].Duration)
+	assert.Equal(t, "", plan.Items[0].TimeZone)
+	assert.Equal(t, "Asia/Seoul", plan.Items[1].TimeZone)
+	assert.Equal(t, 3, plan.Items[2].Order)
+
+	assert.Equal(t, 3, report.Imported)
+	assert.Len(t, report.Skipped, 2)
+	assert.Equal(t, "c", report.Skipped[0].UID)
+	assert.Equal(t, "d", report.Skipped[1].UID)
+
+	// 種類を推定した予定と繰り返しの予定が確認対象になる
+	uids := []string{}
+	for _, issue := range report.Ambiguous {
+		uids = append(uids, issue.UID)
+	}
+	assert.Equal(t, []string{"b", "e", "e"}, uids)
+
+	for i := range plan.Items {
+		plan.Items[i].ID = string(rune('x' + i))
+	}
+	report.AssignItemIDs(&plan)
+	assert.Equal(t, "y", report.Ambiguous[0].ItemID)
+	assert.Equal(t, "z", report.Ambiguous[2].ItemID)
+}
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"sort"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// アイテムの種類
+const (
+	ItemTypeVisit     = "visit"     // 訪問
+	ItemTypeTransport = "transport" // 移動
+	ItemTypeMeal      = "meal"      // 食事
+	ItemTypeLodging   = "lodging"   // 宿泊
+)
+
+// itemTypeKeywords 種類ごとのキーワード（小文字・NFKC正規化済みの文字列に対して部分一致で判定する）
+var itemTypeKeywords = map[string][]string{
+	ItemTypeTransport: {
+		"transport", "flight", "train", "bus", "taxi", "ferry", "airport", "shinkansen", "transfer",
+		"移動", "飛行機", "フライト", "便", "新幹線", "電車", "列車", "特急", "バス", "タクシー", "フェリー", "空港", "乗車", "搭乗",
+	},
+	ItemTypeMeal: {
+		"meal", "breakfast", "lunch", "dinner", "restaurant", "cafe", "café", "brunch",
+		"食事", "朝食", "昼食", "夕食", "ランチ", "ディナー", "レストラン", "カフェ", "ごはん", "ご飯", "居酒屋", "予約席",
+	},
+	ItemTypeLodging: {
+		"lodging", "hotel", "hostel", "inn", "check-in", "checkin", "check-out", "checkout", "airbnb", "accommodation",
+		"宿泊", "ホテル", "旅館", "民宿", "宿", "チェックイン", "チェックアウト",
+	},
+	ItemTypeVisit: {
+		"visit", "sightseeing", "tour", "museum", "temple", "shrine", "park",
+		"観光", "見学", "訪問", "美術館", "博物館", "寺", "神社", "公園", "散策",
+	},
+}
+
+// GuessItemType カテゴリとタイトルからアイテムの種類を推定する
+// カテゴリで判定できればカテゴリを優先する。判定できない場合は "visit" とし、
+// ambiguous は候補が複数あった場合と、どの種類にも当てはまらなかった場合に true になる
+func GuessItemType(categories []string, title string) (itemType string, ambiguous bool) {
+	if types := matchItemTypes(strings.Join(categories, " ")); len(types) == 1 {
+		return types[0], false
+	} else if len(types) > 1 {
+		return types[0], true
+	}
+
+	types := matchItemTypes(title)
+	switch len(types) {
+	case 0:
+		return ItemTypeVisit, true
+	case 1:
+		return types[0], false
+	default:
+		return types[0], true
+	}
+}
+
+// matchItemTypes テキストに含まれるキーワードの種類を、一致したキーワードの多い順に返す
+func matchItemTypes(text string) []string {
+	k := newKeywordText(text)
+	if k == nil {
+		return nil
+	}
+
+	scores := map[string]int{}
+	for itemType, keywords := range itemTypeKeywords {
+		for _, keyword := range keywords {
+			if k.contains(keyword) {
+				scores[itemType]++
+			}
+		}
+	}
+
+	types := make([]string, 0, len(scores))
+	for itemType := range scores {
+		types = append(types, itemType)
+	}
+	sort.Slice(types, func(a, b int) bool {
+		if scores[types[a]] != scores[types[b]] {
+			return scores[types[a]] > scores[types[b]]
+		}
+		return types[a] < types[b]
+	})
+	return types
+}
+
+// keywordText キーワードとの照合用に正規化したテキスト
+type keywordText struct {
+	text  string
+	words map[string]bool
+}
+
+// newKeywordText テキストを小文字・NFKC正規化する（空の場合はnil）
+func newKeywordText(text string) *keywordText {
+	text = strings.ToLower(norm.NFKC.String(text))
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+
+	// 英語のキーワードは単語単位で比べる（"dinner" が "inn" に一致しないように）
+	words := map[string]bool{}
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == 'é')
+	}) {
+		words[word] = true
+	}
+	return &keywordText{text: text, words: words}
+}
+
+// contains キーワードを含むかどうかを返す
+func (k *keywordText) contains(keyword string) bool {
+	if isASCIIWord(keyword) {
+		return k.words[keyword]
+	}
+	return strings.Contains(k.text, keyword)
+}
+
+func isASCIIWord(s string) bool {
+	for _, r := range s {
+		if r > 0x7f && r != 'é' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,195 @@
+package models
+
+import (
+	"backend/utils/geo"
+	"context"
+	"math"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// Router アイテム間の移動を見積もる Router（標準では直線距離と移動手段ごとの平均速度で見積もる）
+var Router geo.Router = geo.NewStraightLineRouter()
+
+// 移動区間の判定
+const (
+	LegOK         = "ok"         // 余裕がある
+	LegTight      = "tight"      // 間に合うが余裕が少ない
+	LegInfeasible = "infeasible" // 見積もった移動時間が空き時間より長い
+)
+
+// 移動手段をどのように決めたか
+const (
+	LegModeRequest  = "request"  // 指定された移動手段
+	LegModeItem     = "item"     // 間にある移動アイテムから推定した移動手段
+	LegModeDistance = "distance" // 距離から選んだ移動手段
+)
+
+// tightLegMargin 移動時間の見積もりに対してこれより余裕が少ない区間を tight とする
+const tightLegMargin = 10 * time.Minute
+
+// travelModeKeywords 移動手段ごとのキーワードとパターン（上から順に判定する）
+// 「便」だけではバスの便や宅配便にも一致するため、飛行機は航空会社のコードと便名の形（例："JAL123便"）だけを判定する
+var travelModeKeywords = []struct {
+	mode     geo.Mode
+	keywords []string
+	pattern  *regexp.Regexp
+}{
+	{geo.ModeWalk, []string{"walk", "walking", "徒歩", "歩いて", "散歩"}, nil},
+	{geo.ModeBicycle, []string{"bicycle", "bike", "cycling", "自転車", "サイクリング", "レンタサイクル"}, nil},
+	{geo.ModeFerry, []string{"ferry", "boat", "ship", "フェリー", "船"}, nil},
+	{geo.ModeTrain, []string{"shinkansen", "新幹線", "特急", "列車", "寝台"}, nil},
+	{geo.ModeTransit, []string{"train", "subway", "metro", "bus", "tram", "jr", "電車", "地下鉄", "バス", "路面電車", "モノレール"}, nil},
+	{geo.ModeFlight, []string{"flight", "plane", "飛行機", "フライト", "搭乗"}, flightNumberPattern},
+	{geo.ModeCar, []string{"car", "taxi", "drive", "タクシー", "レンタカー", "ドライブ", "送迎", "車"}, nil},
+}
+
+// flightNumberPattern 便名（航空会社の2〜3文字のコードと数字、例："JAL123便"、"NH 5便"）
+// 判定する文字列は NFKC 正規化と小文字化をしたものなので、大文字・小文字を区別しない
+var flightNumberPattern = regexp.MustCompile(`(?i)\b([a-z]{2,3}|[a-z][0-9]|[0-9][a-z]) ?[0-9]{1,4}便`)
+
+// Leg 位置のわかる連続した2つのアイテムの間の移動
+type Leg struct {
+	FromItemID string    `json:"fromItemId"`
+	FromTitle  string    `json:"fromTitle"`
+	ToItemID   string    `json:"toItemId"`
+	ToTitle    string    `json:"toTitle"`
+	Via        []string  `json:"via"`        // 間にある移動アイテムのID
+	Departure  time.Time `json:"departure"`  // 出発できる時刻（前のアイテムの終了時間）
+	Arrival    time.Time `json:"arrival"`    // 到着が必要な時刻（次のアイテムの開始時間）
+	Mode       geo.Mode  `json:"mode"`       // 移動手段
+	ModeSource string    `json:"modeSource"` // 移動手段の決め方（"request"、"item"、"distance"）
+	Distance   int       `json:"distance"`   // 移動距離の見積もり（メートル）
+	Duration   int       `json:"duration"`   // 移動時間の見積もり（分）
+	Available  int       `json:"available"`  // 使える時間（分。予定が重なっている場合は負）
+	Slack      int       `json:"slack"`      // 余裕（分。Available - Duration）
+	Status     string    `json:"status"`     // "ok"、"tight"、"infeasible"
+}
+
+// LegReport プラン全体の移動の見積もり
+type LegReport struct {
+	Provider     string `json:"provider"`     // 見積もりの提供元
+	Legs         []Leg  `json:"legs"`         // 時間順の移動区間
+	Infeasible   int    `json:"infeasible"`   // 間に合わない区間の数
+	Tight        int    `json:"tight"`        // 余裕の少ない区間の数
+	SkippedItems int    `json:"skippedItems"` // 位置がないため区間を作れなかったアイテムの数（移動アイテムを除く）
+}
+
+// GuessTravelMode 移動アイテムのタイトルや説明から移動手段を推定する
+func GuessTravelMode(text string) (geo.Mode, bool) {
+	k := newKeywordText(text)
+	if k == nil {
+		return "", false
+	}
+	for _, m := range travelModeKeywords {
+		for _, keyword := range m.keywords {
+			if k.contains(keyword) {
+				return m.mode, true
+			}
+		}
+		if m.pattern != nil && m.pattern.MatchString(k.text) {
+			return m.mode, true
+		}
+	}
+	return "", false
+}
+
+// Legs 開始時間の順に並べたアイテムのうち、位置のわかる連続した2つのアイテムの間の移動を見積もる
+// 移動アイテム（type が "transport"）は区間の端点にせず、区間の移動手段の推定に使う
+// 位置のわからない移動以外のアイテムがあると、その前後の区間は作らない（どこにいるかわからないため）
+// mode を指定した場合はすべての区間をその移動手段で見積もる
+func (p *TravelPlan) Legs(ctx context.Context, router geo.Router, mode geo.Mode) (*LegReport, error) {
+	items := make([]PlanItem, len(p.Items))
+	copy(items, p.Items)
+	sort.SliceStable(items, func(a, b int) bool {
+		if !items[a].StartTime.Equal(items[b].StartTime) {
+			return items[a].StartTime.Before(items[b].StartTime)
+		}
+		return items[a].Order < items[b].Order
+	})
+
+	report := &LegReport{Provider: router.Name(), Legs: []Leg{}}
+	var from *PlanItem
+	var via []*PlanItem
+	for i := range items {
+		item := &items[i]
+		if item.Type == ItemTypeTransport {
+			via = append(via, item)
+			continue
+		}
+		if !item.HasCoordinates() {
+			report.SkippedItems++
+			from, via = nil, nil
+			continue
+		}
+		if from != nil {
+			leg, err := estimateLeg(ctx, router, from, item, via, mode)
+			if err != nil {
+				return nil, err
+			}
+			switch leg.Status {
+			case LegInfeasible:
+				report.Infeasible++
+			case LegTight:
+				report.Tight++
+			}
+			report.Legs = append(report.Legs, leg)
+		}
+		from, via = item, nil
+	}
+	return report, nil
+}
+
+// estimateLeg 2つのアイテムの間の移動を見積もり、空き時間と比べる
+func estimateLeg(ctx context.Context, router geo.Router, from, to *PlanItem, via []*PlanItem, mode geo.Mode) (Leg, error) {
+	a := geo.Point{Latitude: *from.Latitude, Longitude: *from.Longitude}
+	b := geo.Point{Latitude: *to.Latitude, Longitude: *to.Longitude}
+
+	leg := Leg{
+		FromItemID: from.ID,
+		FromTitle:  from.Title,
+		ToItemID:   to.ID,
+		ToTitle:    to.Title,
+		Via:        []string{},
+		Departure:  from.EndTime,
+		Arrival:    to.StartTime,
+		Mode:       mode,
+		ModeSource: LegModeRequest,
+	}
+	if leg.Departure.IsZero() {
+		leg.Departure = from.StartTime
+	}
+	for _, item := range via {
+		leg.Via = append(leg.Via, item.ID)
+		if leg.Mode == "" {
+			if m, ok := GuessTravelMode(item.Title + " " + item.Description); ok {
+				leg.Mode, leg.ModeSource = m, LegModeItem
+			}
+		}
+	}
+	if leg.Mode == "" {
+		leg.Mode, leg.ModeSource = geo.DefaultMode(geo.Distance(a, b)), LegModeDistance
+	}
+
+	estimate, err := router.Estimate(ctx, a, b, leg.Mode)
+	if err != nil {
+		return Leg{}, err
+	}
+	available := leg.Arrival.Sub(leg.Departure)
+	slack := available - estimate.Duration
+
+	leg.Distance = int(math.Round(estimate.Distance))
+	leg.Duration = int(estimate.Duration.Minutes())
+	leg.Available = int(math.Floor(available.Minutes()))
+	leg.Slack = int(math.Floor(slack.Minutes()))
+	switch {
+	case slack < 0:
+		leg.Status = LegInfeasible
+	case slack < tightLegMargin:
+		leg.Status = LegTight
+	default:
+		leg.Status = LegOK
+	}
+	return leg, nil
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"backend/utils/geo"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func locatedItem(id, itemType string, start, end time.Time, lat, lng float64) PlanItem {
+	return PlanItem{ID: id, Type: itemType, Title: id, StartTime: start, EndTime: end, Latitude: &lat, Longitude: &lng}
+}
+
+// TestGuessTravelMode 移動アイテムのタイトルから移動手段を推定できることを確認する
+func TestGuessTravelMode(t *testing.T) {
+	tests := map[string]geo.Mode{
+		"のぞみ号で東京へ（新幹線）": geo.ModeTrain,
+		"JAL123便":           geo.ModeFlight,
+		"ｎｈ 5便で那覇へ":         geo.ModeFlight,
+		"高速バス 3便":           geo.ModeTransit,
+		"市バスで移動":            geo.ModeTransit,
+		"電車で移動":             geo.ModeTransit,
+		"Taxi to the hotel": geo.ModeCar,
+		"レンタサイクル":           geo.ModeBicycle,
+		"徒歩":                geo.ModeWalk,
+	}
+	for title, want := range tests {
+		got, ok := GuessTravelMode(title)
+		assert.True(t, ok, title)
+		assert.Equal(t, want, got, title)
+	}
+
+	_, ok := GuessTravelMode("移動")
+	assert.False(t, ok)
+
+	// 便名の形でない「便」は飛行機と判定しない
+	_, ok = GuessTravelMode("宅配便を出す")
+	assert.False(t, ok)
+}
+
+// TestLegs 位置のわかる連続したアイテムの間の移動を見積もり、間に合わない区間を判定できることを確認する
+func TestLegs(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	at := func(day, hour, minute int) time.Time { return time.Date(2026, 4, day, hour, minute, 0, 0, jst) }
+
+	plan := TravelPlan{Items: []PlanItem{
+		locatedItem("kiyomizu", ItemTypeVisit, at(1, 9, 0), at(1, 10, 30), 34.994856, 135.785046),
+		// 清水寺から八坂神社（約1km）は徒歩で十分間に合う
+		locatedItem("yasaka", ItemTypeVisit, at(1, 11, 0), at(1, 12, 0), 35.003674, 135.778522),
+		// 京都駅まで（約3km）15分では間に合わない
+		locatedItem("station", ItemTypeVisit, at(1, 12, 15), at(1, 12, 30), 34.985849, 135.758767),
+		{ID: "shinkansen", Type: ItemTypeTransport, Title: "新幹線で東京へ", StartTime: at(1, 12, 40), EndTime: at(1, 14, 50)},
+		locatedItem("tokyo", ItemTypeVisit, at(1, 15, 0), at(1, 16, 0), 35.681236, 139.767125),
+		{ID: "unknown", Type: ItemTypeMeal, Title: "夕食", StartTime: at(1, 18, 0), EndTime: at(1, 19, 0)},
+		locatedItem("hotel", ItemTypeLodging, at(1, 20, 0), at(2, 9, 0), 35.6812, 139.7671),
+	}}
+
+	report, err := plan.Legs(context.Background(), geo.NewStraightLineRouter(), "")
+	if !assert.NoError(t, err) || !assert.Len(t, report.Legs, 3) {
+		return
+	}
+	assert.Equal(t, "straight-line", report.Provider)
+	assert.Equal(t, 1, report.SkippedItems)
+
+	walk := report.Legs[0]
+	assert.Equal(t, "kiyomizu", walk.FromItemID)
+	assert.Equal(t, "yasaka", walk.ToItemID)
+	assert.Equal(t, geo.ModeWalk, walk.Mode)
+	assert.Equal(t, LegModeDistance, walk.ModeSource)
+	assert.Equal(t, 30, walk.Available)
+	assert.Equal(t, LegOK, walk.Status)
+
+	short := report.Legs[1]
+	assert.Equal(t, geo.ModeTransit, short.Mode)
+	assert.Equal(t, 15, short.Available)
+	assert.Equal(t, LegInfeasible, short.Status)
+	assert.Less(t, short.Slack, 0)
+
+	// 間にある移動アイテムから移動手段を推定し、移動アイテムの時間も含めて判定する
+	train := report.Legs[2]
+	assert.Equal(t, []string{"shinkansen"}, train.Via)
+	assert.Equal(t, geo.ModeTrain, train.Mode)
+	assert.Equal(t, LegModeItem, train.ModeSource)
+	assert.Equal(t, 150, train.Available)
+	assert.Equal(t, LegOK, train.Status)
+
+	assert.Equal(t, 1, report.Infeasible)
+
+	// 移動手段を指定するとすべての区間をその移動手段で見積もる
+	report, _ = plan.Legs(context.Background(), geo.NewStraightLineRouter(), geo.ModeWalk)
+	assert.Equal(t, LegModeRequest, report.Legs[2].ModeSource)
+	assert.Equal(t, LegInfeasible, report.Legs[2].Status)
+}
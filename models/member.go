@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PlanMember プランを共有しているメンバー（作成者を除く）
+// メンバーは非公開のプランも閲覧でき、コメントでメンションできる
+type PlanMember struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	PlanID    string    `gorm:"size:32;not null;uniqueIndex:idx_plan_member" json:"planId"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_plan_member;index" json:"userId"`
+	Username  string    `gorm:"-" json:"username"` // 表示用のユーザー名
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// IsPlanMember ユーザーがプランの作成者またはメンバーかどうかを返す
+func IsPlanMember(plan *TravelPlan, userID uint) bool {
+	if userID == 0 {
+		return false
+	}
+	if userID == plan.CreatorID {
+		return true
+	}
+	var count int64
+	DB.Model(&PlanMember{}).Where("plan_id = ? AND user_id = ?", plan.ID, userID).Count(&count)
+	return count > 0
+}
+
+// CanViewPlan ユーザーがプランを閲覧できるかどうかを返す（公開プラン、または作成者・メンバー）
+// 削除されたプランは閲覧できない
+func CanViewPlan(planID string, userID uint) bool {
+	var plan TravelPlan
+	if err := DB.Select("id", "creator_id", "is_public").First(&plan, "id = ?", planID).Error; err != nil {
+		return false
+	}
+	return plan.IsPublic || IsPlanMember(&plan, userID)
+}
+
+// PlanMembers プランのメンバーを作成者を先頭にして返す
+func PlanMembers(plan *TravelPlan) ([]PlanMember, error) {
+	var members []PlanMember
+	if err := DB.Where("plan_id = ?", plan.ID).Order("id").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	members = append([]PlanMember{{PlanID: plan.ID, UserID: plan.CreatorID, CreatedAt: plan.CreatedAt}}, members...)
+
+	ids := make([]uint, len(members))
+	for i, m := range members {
+		ids[i] = m.UserID
+	}
+	names, err := Usernames(DB, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range members {
+		members[i].Username = names[members[i].UserID]
+	}
+	return members, nil
+}
+
+// Usernames ユーザーIDごとのユーザー名を返す
+func Usernames(tx *gorm.DB, ids []uint) (map[uint]string, error) {
+	names := map[uint]string{}
+	if len(ids) == 0 {
+		return names, nil
+	}
+	var users []User
+	if err := tx.Select("id", "username").Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		names[u.ID] = u.Username
+	}
+	return names, nil
+}
@@ -0,0 +1,422 @@
+package models
+
+import (
+	"backend/utils/geo"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+)
+
+// placeDuplicateDistance 同じ名前の場所を同一とみなす距離（メートル）
+const placeDuplicateDistance = 150
+
+// ErrPlaceNotFound 指定した場所が存在しない
+var ErrPlaceNotFound = errors.New("場所が見つかりません")
+
+// Place プランのアイテムが参照する場所
+// 同じ場所を複数のプランで共有し、名前と位置（または外部ID）が一致する場所は1件にまとめる
+type Place struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	Name           string    `gorm:"size:255;not null" json:"name"`                                          // 名前（例：「清水寺」）
+	NameKey        string    `gorm:"size:255;not null;index" json:"-"`                                       // 検索と重複判定に使う正規化した名前
+	Address        string    `gorm:"size:500" json:"address"`                                                // 住所
+	AddressKey     string    `gorm:"size:500" json:"-"`                                                      // 正規化した住所
+	Latitude       *float64  `json:"latitude"`                                                               // 緯度（未設定の場合はnull）
+	Longitude      *float64  `json:"longitude"`                                                              // 経度（未設定の場合はnull）
+	ExternalSource string    `gorm:"size:50;uniqueIndex:idx_place_external" json:"externalSource,omitempty"` // 外部IDの提供元（例："osm"）
+	ExternalID     *string   `gorm:"size:255;uniqueIndex:idx_place_external" json:"externalId,omitempty"`    // 提供元での場所のID
+	UseCount       int64     `gorm:"-" json:"useCount"`                                                      // 参照しているアイテムの数（検索結果のみ）
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// PlaceInput 場所の作成・検索に使う内容
+type PlaceInput struct {
+	Name           string   `json:"name" binding:"required"`
+	Address        string   `json:"address"`
+	Latitude       *float64 `json:"latitude"`
+	Longitude      *float64 `json:"longitude"`
+	ExternalSource string   `json:"externalSource"`
+	ExternalID     string   `json:"externalId"`
+}
+
+// NormalizePlaceText 場所の名前・住所を比較用に正規化する（全角英数の半角化、小文字化、空白の統一）
+func NormalizePlaceText(s string) string {
+	s = norm.NFKC.String(s)
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// BeforeSave 場所が保存される前に正規化した名前・住所を設定する
+func (p *Place) BeforeSave(*gorm.DB) error {
+	p.NameKey = truncateRunes(NormalizePlaceText(p.Name), 255)
+	p.AddressKey = truncateRunes(NormalizePlaceText(p.Address), 500)
+	return nil
+}
+
+// HasCoordinates 場所に緯度・経度が設定されているかどうかを返す
+func (p *Place) HasCoordinates() bool {
+	return p.Latitude != nil && p.Longitude != nil
+}
+
+// Point 場所の位置を返す（緯度・経度が設定されていること）
+func (p *Place) Point() geo.Point {
+	return geo.Point{Latitude: *p.Latitude, Longitude: *p.Longitude}
+}
+
+// SamePlace 2つの場所が同じ場所かどうかを返す
+// 名前が同じで、両方に位置があれば一定の距離以内、位置がない場合は住所が同じ（または片方が未設定）であれば同じとみなす
+func (p *Place) SamePlace(other *Place) bool {
+	if NormalizePlaceText(p.Name) != NormalizePlaceText(other.Name) {
+		return false
+	}
+	if p.HasCoordinates() && other.HasCoordinates() {
+		return geo.Distance(p.Point(), other.Point()) <= placeDuplicateDistance
+	}
+	a, b := NormalizePlaceText(p.Address), NormalizePlaceText(other.Address)
+	return a == "" || b == "" || a == b
+}
+
+// FindOrCreatePlace 入力内容と同じ場所があればそれを返し、なければ作成する。作成した場合は created が true になる
+// trusted が true（ジオコーダーの検索結果）の場合は、既存の場所に位置や住所・外部IDがなければ入力内容で補完する
+// trusted が false（利用者の入力）の場合は、他の利用者と共有する既存の場所を変更せず、
+// 外部IDも本来の場所の登録を妨げないように既存の場所の検索にだけ使い、作成する場所には設定しない
+func FindOrCreatePlace(tx *gorm.DB, input PlaceInput, trusted bool) (place *Place, created bool, err error) {
+	candidate := Place{
+		Name:      strings.TrimSpace(input.Name),
+		Address:   strings.TrimSpace(input.Address),
+		Latitude:  input.Latitude,
+		Longitude: input.Longitude,
+	}
+	if input.ExternalID != "" {
+		candidate.ExternalSource = input.ExternalSource
+		candidate.ExternalID = &input.ExternalID
+	}
+
+	// 外部IDが一致する場所
+	if candidate.ExternalID != nil {
+		var existing Place
+		err := tx.Where("external_source = ? AND external_id = ?", candidate.ExternalSource, *candidate.ExternalID).
+			Take(&existing).Error
+		if err == nil {
+			if !trusted {
+				return &existing, false, nil
+			}
+			return &existing, false, completePlace(tx, &existing, &candidate)
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, err
+		}
+	}
+
+	// 名前と位置（または住所）が一致する場所
+	var sameName []Place
+	if err := tx.Where("name_key = ?", truncateRunes(NormalizePlaceText(candidate.Name), 255)).
+		Order("id").Find(&sameName).Error; err != nil {
+		return nil, false, err
+	}
+	for i := range sameName {
+		existing := &sameName[i]
+		// 別の外部IDを持つ場所は同じ名前でも別の場所とする
+		if existing.ExternalID != nil && candidate.ExternalID != nil {
+			continue
+		}
+		if existing.SamePlace(&candidate) {
+			if !trusted {
+				return existing, false, nil
+			}
+			return existing, false, completePlace(tx, existing, &candidate)
+		}
+	}
+
+	if !trusted {
+		candidate.ExternalSource, candidate.ExternalID = "", nil
+	}
+	if err := tx.Create(&candidate).Error; err != nil {
+		return nil, false, err
+	}
+	return &candidate, true, nil
+}
+
+// completePlace 既存の場所にない情報（住所・位置・外部ID）を新しい内容で補完する
+func completePlace(tx *gorm.DB, existing, candidate *Place) error {
+	updates := map[string]interface{}{}
+	if existing.Address == "" && candidate.Address != "" {
+		existing.Address = candidate.Address
+		updates["address"] = candidate.Address
+		updates["address_key"] = truncateRunes(NormalizePlaceText(candidate.Address), 500)
+	}
+	if !existing.HasCoordinates() && candidate.HasCoordinates() {
+		existing.Latitude, existing.Longitude = candidate.Latitude, candidate.Longitude
+		updates["latitude"] = candidate.Latitude
+		updates["longitude"] = candidate.Longitude
+	}
+	if existing.ExternalID == nil && candidate.ExternalID != nil {
+		existing.ExternalSource, existing.ExternalID = candidate.ExternalSource, candidate.ExternalID
+		updates["external_source"] = candidate.ExternalSource
+		updates["external_id"] = candidate.ExternalID
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	return tx.Model(&Place{}).Where("id = ?", existing.ID).Updates(updates).Error
+}
+
+// SearchPlaces 名前・住所から場所を検索する（入力途中の候補表示に使う）
+// 名前の前方一致を優先し、次に参照しているアイテムの多い順、near を指定した場合は近い順に並べる
+func SearchPlaces(q string, near *geo.Point, limit int) ([]Place, error) {
+	key := NormalizePlaceText(q)
+	if key == "" {
+		return []Place{}, nil
+	}
+	pattern := "%" + escapeLike(key) + "%"
+
+	// 候補を多めに取得してから並べ替える
+	var places []Place
+	err := DB.Where("name_key LIKE ? OR address_key LIKE ?", pattern, pattern).
+		Order("(SELECT COUNT(*) FROM plan_items WHERE plan_items.place_id = places.id AND plan_items.deleted_at IS NULL) DESC").
+		Order("id").
+		Limit(limit * 5).
+		Find(&places).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if err := fillPlaceUseCounts(places); err != nil {
+		return nil, err
+	}
+	RankPlaces(places, key, near)
+	if len(places) > limit {
+		places = places[:limit]
+	}
+	return places, nil
+}
+
+// RankPlaces 検索結果を名前の一致度・使用回数・距離の順に並べる
+func RankPlaces(places []Place, key string, near *geo.Point) {
+	rank := func(p *Place) int {
+		name := NormalizePlaceText(p.Name)
+		switch {
+		case name == key:
+			return 0
+		case strings.HasPrefix(name, key):
+			return 1
+		case strings.Contains(name, key):
+			return 2
+		default:
+			return 3
+		}
+	}
+	distance := func(p *Place) float64 {
+		if near == nil || !p.HasCoordinates() {
+			return -1
+		}
+		return geo.Distance(*near, p.Point())
+	}
+	sort.SliceStable(places, func(a, b int) bool {
+		if ra, rb := rank(&places[a]), rank(&places[b]); ra != rb {
+			return ra < rb
+		}
+		if near != nil {
+			da, db := distance(&places[a]), distance(&places[b])
+			if (da < 0) != (db < 0) {
+				return db < 0
+			}
+			if da != db {
+				return da < db
+			}
+		}
+		return places[a].UseCount > places[b].UseCount
+	})
+}
+
+// fillPlaceUseCounts 各場所を参照している（削除されていない）アイテムの数を設定する
+func fillPlaceUseCounts(places []Place) error {
+	if len(places) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(places))
+	for i := range places {
+		ids[i] = places[i].ID
+	}
+	var counts []struct {
+		PlaceID uint
+		Count   int64
+	}
+	if err := DB.Model(&PlanItem{}).Select("place_id, COUNT(*) AS count").
+		Where("place_id IN ?", ids).Group("place_id").Scan(&counts).Error; err != nil {
+		return err
+	}
+	byID := map[uint]int64{}
+	for _, c := range counts {
+		byID[c.PlaceID] = c.Count
+	}
+	for i := range places {
+		places[i].UseCount = byID[places[i].ID]
+	}
+	return nil
+}
+
+// MergePlaces 重複した場所 from を into にまとめ、from を参照しているアイテム・テンプレートを付け替えて削除する
+func MergePlaces(tx *gorm.DB, from, into *Place) error {
+	if from.ID == into.ID {
+		return nil
+	}
+	// 外部IDを統合先に引き継ぐ前に統合元から外す（外部IDの一意インデックスが重複しないように）
+	if from.ExternalID != nil {
+		if err := tx.Model(&Place{}).Where("id = ?", from.ID).
+			UpdateColumns(map[string]interface{}{"external_source": "", "external_id": nil}).Error; err != nil {
+			return err
+		}
+	}
+	if err := completePlace(tx, into, from); err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Model(&PlanItem{}).Where("place_id = ?", from.ID).
+		Update("place_id", into.ID).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&TemplateItem{}).Where("place_id = ?", from.ID).
+		Update("place_id", into.ID).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&Place{}, from.ID).Error
+}
+
+// DedupePlaces 同じ場所とみなせる場所をまとめ、まとめた場所の数を返す
+// 名前が同じ場所ごとに、最も古い場所から順に同じ場所を探して統合する
+func DedupePlaces() (int, error) {
+	var keys []string
+	if err := DB.Model(&Place{}).Group("name_key").Having("COUNT(*) > 1").
+		Pluck("name_key", &keys).Error; err != nil {
+		return 0, err
+	}
+
+	merged := 0
+	for _, key := range keys {
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			var places []Place
+			if err := tx.Where("name_key = ?", key).Order("id").Find(&places).Error; err != nil {
+				return err
+			}
+			for _, group := range GroupDuplicatePlaces(places) {
+				for _, duplicate := range group[1:] {
+					if err := MergePlaces(tx, &duplicate, &group[0]); err != nil {
+						return err
+					}
+					merged++
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return merged, err
+		}
+	}
+	return merged, nil
+}
+
+// GroupDuplicatePlaces 同じ場所とみなせる場所をまとめる（各グループの先頭は統合先となる最も古い場所）
+// 異なる外部IDを持つ場所は同じグループにしない
+func GroupDuplicatePlaces(places []Place) [][]Place {
+	var groups [][]Place
+	used := make([]bool, len(places))
+	for i := range places {
+		if used[i] {
+			continue
+		}
+		group := []Place{places[i]}
+		used[i] = true
+		// グループ内の外部ID（グループには同じ外部IDの場所しか入れない）
+		external := places[i].externalKey()
+		for j := i + 1; j < len(places); j++ {
+			if used[j] || !places[i].SamePlace(&places[j]) {
+				continue
+			}
+			key := places[j].externalKey()
+			if key != "" && external != "" && key != external {
+				continue
+			}
+			if key != "" {
+				external = key
+			}
+			group = append(group, places[j])
+			used[j] = true
+		}
+		if len(group) > 1 {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// externalKey 外部IDの提供元とIDを連結した文字列（外部IDがない場合は空）
+func (p *Place) externalKey() string {
+	if p.ExternalID == nil {
+		return ""
+	}
+	return p.ExternalSource + ":" + *p.ExternalID
+}
+
+// FindPlace IDから場所を取得する
+func FindPlace(tx *gorm.DB, id uint) (*Place, error) {
+	var place Place
+	if err := tx.First(&place, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPlaceNotFound
+		}
+		return nil, err
+	}
+	return &place, nil
+}
+
+// existingPlaceIDs スナップショットのアイテムが参照している場所のうち、存在するもののIDを返す
+func existingPlaceIDs(tx *gorm.DB, items []ItemSnapshot) (map[uint]bool, error) {
+	var ids []uint
+	for _, item := range items {
+		if item.PlaceID != nil {
+			ids = append(ids, *item.PlaceID)
+		}
+	}
+	existing := map[uint]bool{}
+	if len(ids) == 0 {
+		return existing, nil
+	}
+	var found []uint
+	if err := tx.Model(&Place{}).Where("id IN ?", ids).Pluck("id", &found).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range found {
+		existing[id] = true
+	}
+	return existing, nil
+}
+
+// ApplyPlace アイテムに場所の名前と位置を反映する
+// 場所（Location）が空の場合は場所の名前を、アイテムに位置がない場合は場所の位置を設定する
+func (i *PlanItem) ApplyPlace(place *Place) {
+	i.PlaceID = &place.ID
+	if strings.TrimSpace(i.Location) == "" {
+		i.Location = place.Name
+	}
+	if !i.HasCoordinates() && place.HasCoordinates() {
+		i.Latitude, i.Longitude = place.Latitude, place.Longitude
+	}
+}
+
+// escapeLike LIKE のパターンで特別な意味を持つ文字をエスケープする
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// truncateRunes 文字列を指定した文字数までに切り詰める
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
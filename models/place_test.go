@@ -0,0 +1,118 @@
+package models
+
+import (
+	"backend/utils/geo"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func placeAt(id uint, name, address string, lat, lng float64) Place {
+	return Place{ID: id, Name: name, Address: address, Latitude: &lat, Longitude: &lng}
+}
+
+// TestNormalizePlaceText 全角・半角や大文字・小文字、空白の違いを吸収できることを確認する
+func TestNormalizePlaceText(t *testing.T) {
+	assert.Equal(t, "tokyo tower", NormalizePlaceText("  ＴＯＫＹＯ　 Tower "))
+	assert.Equal(t, "清水寺", NormalizePlaceText("清水寺"))
+	assert.Equal(t, "", NormalizePlaceText(" 　"))
+}
+
+// TestSamePlace 名前と位置（または住所）から同じ場所かどうかを判定できることを確認する
+func TestSamePlace(t *testing.T) {
+	kiyomizu := placeAt(1, "清水寺", "", 34.994856, 135.785046)
+
+	near := placeAt(2, "清水寺", "", 34.995300, 135.785600)
+	assert.True(t, kiyomizu.SamePlace(&near))
+
+	far := placeAt(3, "清水寺", "", 35.011564, 135.768149)
+	assert.False(t, kiyomizu.SamePlace(&far))
+
+	other := placeAt(4, "八坂神社", "", 34.994856, 135.785046)
+	assert.False(t, kiyomizu.SamePlace(&other))
+
+	// 位置がない場合は住所で判定する
+	noCoordinates := Place{Name: "清水寺", Address: "京都市東山区清水1丁目294"}
+	assert.True(t, kiyomizu.SamePlace(&noCoordinates))
+	sameAddress := Place{Name: "清水寺", Address: "京都市東山区清水１丁目２９４"}
+	assert.True(t, noCoordinates.SamePlace(&sameAddress))
+	otherAddress := Place{Name: "清水寺", Address: "兵庫県加東市平木1194"}
+	assert.False(t, noCoordinates.SamePlace(&otherAddress))
+}
+
+// TestGroupDuplicatePlaces 同じ場所を最も古い場所を先頭にまとめられることを確認する
+func TestGroupDuplicatePlaces(t *testing.T) {
+	osm := "node/123"
+	otherOSM := "node/456"
+	places := []Place{
+		placeAt(1, "京都駅", "", 34.985849, 135.758767),
+		placeAt(2, "京都駅", "", 34.985900, 135.758800),
+		placeAt(3, "京都駅", "", 34.985849, 135.758767),
+		placeAt(4, "京都駅", "", 35.000000, 135.800000),
+		placeAt(5, "京都駅", "", 34.985849, 135.758767),
+	}
+	places[2].ExternalSource, places[2].ExternalID = "osm", &osm
+	places[4].ExternalSource, places[4].ExternalID = "osm", &otherOSM
+
+	groups := GroupDuplicatePlaces(places)
+	if assert.Len(t, groups, 1) {
+		var ids []uint
+		for _, p := range groups[0] {
+			ids = append(ids, p.ID)
+		}
+		// 外部IDを持つ場所は、外部IDを持たない場所と同じグループにできるが、異なる外部IDの場所とは同じグループにしない
+		assert.Equal(t, []uint{1, 2, 3}, ids)
+	}
+}
+
+// TestRankPlaces 名前の一致度・距離・使用回数の順に並べられることを確認する
+func TestRankPlaces(t *testing.T) {
+	places := []Place{
+		placeAt(1, "東京スカイツリー", "", 35.710063, 139.8107),
+		placeAt(2, "ザ・東京タワーホテル", "", 35.6586, 139.7454),
+		placeAt(3, "東京タワー", "", 35.658581, 139.745433),
+		placeAt(4, "東京駅", "", 35.681236, 139.767125),
+	}
+	places[0].UseCount = 1
+	places[3].UseCount = 10
+
+	RankPlaces(places, NormalizePlaceText("東京"), nil)
+	assert.Equal(t, []uint{4, 1, 3, 2}, placeIDs(places))
+
+	tower := geo.Point{Latitude: 35.6586, Longitude: 139.7454}
+	RankPlaces(places, NormalizePlaceText("東京"), &tower)
+	assert.Equal(t, []uint{3, 4, 1, 2}, placeIDs(places))
+
+	RankPlaces(places, NormalizePlaceText("東京タワー"), nil)
+	assert.Equal(t, uint(3), places[0].ID)
+}
+
+func placeIDs(places []Place) []uint {
+	ids := make([]uint, len(places))
+	for i := range places {
+		ids[i] = places[i].ID
+	}
+	return ids
+}
+
+// TestApplyPlace 空の場所名と緯度・経度だけを場所の内容で補うことを確認する
+func TestApplyPlace(t *testing.T) {
+	place := placeAt(7, "清水寺", "京都市東山区清水1丁目294", 34.994856, 135.785046)
+
+	var item PlanItem
+	item.ApplyPlace(&place)
+	assert.Equal(t, uint(7), *item.PlaceID)
+	assert.Equal(t, "清水寺", item.Location)
+	assert.Equal(t, 34.994856, *item.Latitude)
+
+	lat, lng := 35.0, 135.0
+	item = PlanItem{Location: "清水の舞台", Latitude: &lat, Longitude: &lng}
+	item.ApplyPlace(&place)
+	assert.Equal(t, "清水の舞台", item.Location)
+	assert.Equal(t, 35.0, *item.Latitude)
+}
+
+// TestEscapeLike LIKEのワイルドカードをエスケープできることを確認する
+func TestEscapeLike(t *testing.T) {
+	assert.Equal(t, `100\%\_off\\`, escapeLike(`100%_off\`))
+}
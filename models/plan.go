@@ -1,30 +1,181 @@
 package models
 
-import "time"
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type TravelPlan struct {
-	ID          string     `json:"id" validate:"required"`          // プランID
-	Title       string     `json:"title" validate:"required"`       // 例：「京都1日観光プラン」
-	Description string     `json:"description" validate:"required"` // プランの説明
-	Items       []PlanItem `json:"items" validate:"required,dive"`  // プランの各項目
-	TotalCost   int        `json:"totalCost" validate:"required"`   // 合計費用
-	CreatedAt   time.Time  `json:"createdAt" validate:"required"`   // 作成日時
-	UpdatedAt   time.Time  `json:"updatedAt" validate:"required"`   // 更新日時
-	CreatorID   uint       `json:"creatorId" validate:"required"`   // プラン作成者のユーザーID
-	IsPublic    bool       `json:"isPublic" validate:"required"`    // プランの公開状態
+	ID            string         `gorm:"primaryKey;size:32" json:"id" validate:"required"`        // プランID
+	Title         string         `json:"title" validate:"required"`                               // 例：「京都1日観光プラン」
+	Description   string         `json:"description" validate:"required"`                         // プランの説明
+	Items         []PlanItem     `gorm:"foreignKey:PlanID" json:"items" validate:"required,dive"` // プランの各項目
+	TotalCost     int            `json:"totalCost" validate:"required"`                           // 合計費用
+	StartDate     Date           `gorm:"index" json:"startDate"`                                  // 旅行開始日
+	EndDate       Date           `json:"endDate"`                                                 // 旅行終了日
+	TimeZone      string         `gorm:"size:64" json:"timeZone"`                                 // IANAタイムゾーン（例："Asia/Tokyo"）
+	CreatedAt     time.Time      `json:"createdAt" validate:"required"`                           // 作成日時
+	UpdatedAt     time.Time      `json:"updatedAt" validate:"required"`                           // 更新日時
+	CreatorID     uint           `gorm:"index" json:"creatorId" validate:"required"`              // プラン作成者のユーザーID
+	IsPublic      bool           `json:"isPublic" validate:"required"`                            // プランの公開状態
+	Status        string         `gorm:"size:20;default:draft;index" json:"status"`               // "draft"(下書き)、"confirmed"(確定)、"completed"(完了)、"cancelled"(中止)
+	CategoryID    *uint          `gorm:"index" json:"categoryId"`                                 // カテゴリID
+	Category      *Category      `json:"category,omitempty"`                                      // カテゴリ
+	Tags          []Tag          `gorm:"many2many:plan_tags" json:"tags"`                         // タグ
+	SourcePlanID  *string        `gorm:"size:32;index" json:"sourcePlanId"`                       // 複製元のプランID
+	ForkCount     int            `gorm:"not null;default:0" json:"forkCount"`                     // このプランが複製された回数
+	LikeCount     int            `gorm:"not null;default:0" json:"likeCount"`                     // いいねの数
+	BookmarkCount int            `gorm:"not null;default:0" json:"bookmarkCount"`                 // ブックマークの数
+	TrendingScore float64        `gorm:"not null;default:0;index" json:"-"`                       // 注目度（いいね・ブックマークの時間減衰付きの合計の対数）
+	ReviewCount   int            `gorm:"not null;default:0" json:"reviewCount"`                   // レビューの数
+	RatingAverage float64        `gorm:"not null;default:0" json:"ratingAverage"`                 // 評価の平均（1〜5、レビューがない場合は0）
+	RatingScore   float64        `gorm:"not null;default:0;index" json:"-"`                       // 評価順の並び替えに使うベイズ平均
+	Version       int            `gorm:"not null;default:1" json:"version"`                       // 楽観的排他制御のためのバージョン（アイテムの変更でも進む）
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"deletedAt"`                                  // 削除日時（ゴミ箱に入っている場合）
 }
 
 type PlanItem struct {
-	ID          string    `json:"id" validate:"required"`          // アクティビティID
-	PlanID      string    `json:"planId" validate:"required"`      // プランID
-	Type        string    `json:"type" validate:"required"`        // "visit"(訪問)、"transport"(移動)、"meal"(食事)など
-	Title       string    `json:"title" validate:"required"`       // 例：「清水寺観光」
-	Description string    `json:"description" validate:"required"` // 詳細説明
-	Location    string    `json:"location" validate:"required"`    // 場所
-	StartTime   time.Time `json:"startTime" validate:"required"`   // 開始時間
-	EndTime     time.Time `json:"endTime" validate:"required"`     // 終了時間
-	Duration    int       `json:"duration" validate:"required"`    // 所要時間（分）
-	Cost        int       `json:"cost" validate:"required"`        // 費用（円）
-	Notes       string    `json:"notes" validate:"required"`       // メモ
-	Order       int       `json:"order" validate:"required"`       // 順序
+	ID          string         `gorm:"primaryKey;size:32" json:"id" validate:"required"` // アクティビティID
+	PlanID      string         `gorm:"size:32;index" json:"planId" validate:"required"`  // プランID
+	Type        string         `json:"type" validate:"required"`                         // "visit"(訪問)、"transport"(移動)、"meal"(食事)など
+	Title       string         `json:"title" validate:"required"`                        // 例：「清水寺観光」
+	Description string         `json:"description" validate:"required"`                  // 詳細説明
+	Location    string         `json:"location" validate:"required"`                     // 場所
+	Latitude    *float64       `json:"latitude"`                                         // 緯度（未設定の場合はnull）
+	Longitude   *float64       `json:"longitude"`                                        // 経度（未設定の場合はnull）
+	PlaceID     *uint          `gorm:"index" json:"placeId"`                             // 参照する場所のID（未設定の場合はnull）
+	Place       *Place         `json:"place,omitempty"`                                  // 参照する場所
+	StartTime   time.Time      `json:"startTime" validate:"required"`                    // 開始時間
+	EndTime     time.Time      `json:"endTime" validate:"required"`                      // 終了時間
+	TimeZone    string         `gorm:"size:64" json:"timeZone"`                          // IANAタイムゾーン（未設定の場合はプランのタイムゾーン）
+	Duration    int            `json:"duration" validate:"required"`                     // 所要時間（分）
+	Cost        int            `json:"cost" validate:"required"`                         // 費用（円）
+	Notes       string         `json:"notes" validate:"required"`                        // メモ
+	Order       int            `json:"order" validate:"required"`                        // 順序
+	Version     int            `gorm:"not null;default:1" json:"version"`                // 楽観的排他制御のためのバージョン
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`                                   // 削除日時
+}
+
+// TripDay 旅行の1日分の行程
+type TripDay struct {
+	Day       int        `json:"day"`       // 何日目か（1始まり）
+	Date      Date       `json:"date"`      // 日付
+	Items     []PlanItem `json:"items"`     // その日のアイテム
+	TotalCost int        `json:"totalCost"` // その日の合計費用
+}
+
+// newID ランダムなIDを生成する
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// BeforeCreate プランが作成される前にIDを採番し、バージョンを初期化する
+func (p *TravelPlan) BeforeCreate(*gorm.DB) error {
+	if p.ID == "" {
+		p.ID = newID()
+	}
+	if p.Version == 0 {
+		p.Version = 1
+	}
+	return nil
+}
+
+// BeforeCreate アイテムが作成される前にIDを採番し、バージョンを初期化する
+func (i *PlanItem) BeforeCreate(*gorm.DB) error {
+	if i.ID == "" {
+		i.ID = newID()
+	}
+	if i.Version == 0 {
+		i.Version = 1
+	}
+	return nil
+}
+
+// BeforeSave アイテムが保存される前に時刻をUTCに揃える
+func (i *PlanItem) BeforeSave(*gorm.DB) error {
+	i.StartTime = i.StartTime.UTC()
+	i.EndTime = i.EndTime.UTC()
+	return nil
+}
+
+// HasCoordinates アイテムに緯度・経度が設定されているかどうかを返す
+func (i *PlanItem) HasCoordinates() bool {
+	return i.Latitude != nil && i.Longitude != nil
+}
+
+// ValidCoordinates 緯度・経度の組み合わせが正しいかどうかを返す（両方とも未設定の場合も正しいとする）
+func ValidCoordinates(latitude, longitude *float64) bool {
+	if latitude == nil || longitude == nil {
+		return latitude == nil && longitude == nil
+	}
+	return *latitude >= -90 && *latitude <= 90 && *longitude >= -180 && *longitude <= 180
+}
+
+// ContainsDate 指定した日付がプランの期間内かどうかを返す（期間未設定の場合は常にtrue）
+func (p *TravelPlan) ContainsDate(d Date) bool {
+	if !p.StartDate.IsZero() && d.Before(p.StartDate.Time) {
+		return false
+	}
+	if !p.EndDate.IsZero() && d.After(p.EndDate.Time) {
+		return false
+	}
+	return true
+}
+
+// Days プランのアイテムを日ごとにまとめる
+// 期間が設定されている場合はアイテムのない日も含めて開始日から終了日まで並べる
+// 各アイテムの日付はアイテムのタイムゾーンでの開始日とする
+func (p *TravelPlan) Days() []TripDay {
+	first, last := p.StartDate, p.EndDate
+	for _, item := range p.Items {
+		d := p.LocalDate(&item)
+		if first.IsZero() || d.Before(first.Time) {
+			first = d
+		}
+		if last.IsZero() || d.After(last.Time) {
+			last = d
+		}
+	}
+	if first.IsZero() {
+		return []TripDay{}
+	}
+	if last.IsZero() || last.Before(first.Time) {
+		last = first
+	}
+
+	days := make([]TripDay, first.DaysUntil(last)+1)
+	for i := range days {
+		days[i] = TripDay{Day: i + 1, Date: first.AddDays(i), Items: []PlanItem{}}
+	}
+
+	for _, item := range p.Items {
+		p.LocalizeItem(&item)
+		i := first.DaysUntil(p.LocalDate(&item))
+		days[i].Items = append(days[i].Items, item)
+		days[i].TotalCost += item.Cost
+	}
+
+	for i := range days {
+		sortItems(days[i].Items)
+	}
+
+	return days
+}
+
+// sortItems アイテムを順序・開始時間の順に並べる
+func sortItems(items []PlanItem) {
+	sort.SliceStable(items, func(a, b int) bool {
+		if items[a].Order != items[b].Order {
+			return items[a].Order < items[b].Order
+		}
+		return items[a].StartTime.Before(items[b].StartTime)
+	})
 }
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDays 5日間のプランが日ごとにまとめられることを確認する
+func TestDays(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	plan := TravelPlan{
+		StartDate: NewDate(2026, 11, 1),
+		EndDate:   NewDate(2026, 11, 5),
+		Items: []PlanItem{
+			{ID: "c", Title: "伏見稲荷大社", StartTime: time.Date(2026, 11, 3, 9, 0, 0, 0, jst), Cost: 0, Order: 1},
+			{ID: "b", Title: "金閣寺", StartTime: time.Date(2026, 11, 1, 13, 0, 0, 0, jst), Cost: 500, Order: 2},
+			{ID: "a", Title: "清水寺観光", StartTime: time.Date(2026, 11, 1, 9, 0, 0, 0, jst), Cost: 400, Order: 1},
+		},
+	}
+
+	days := plan.Days()
+
+	assert.Equal(t, 5, len(days))
+	assert.Equal(t, 1, days[0].Day)
+	assert.Equal(t, "2026-11-01", days[0].Date.String())
+	assert.Equal(t, []string{"a", "b"}, []string{days[0].Items[0].ID, days[0].Items[1].ID})
+	assert.Equal(t, 900, days[0].TotalCost)
+	assert.Empty(t, days[1].Items)
+	assert.Equal(t, "c", days[2].Items[0].ID)
+	assert.Equal(t, "2026-11-05", days[4].Date.String())
+}
+
+// TestDaysWithoutDates 期間未設定の場合はアイテムから期間を求めることを確認する
+func TestDaysWithoutDates(t *testing.T) {
+	plan := TravelPlan{
+		Items: []PlanItem{
+			{ID: "a", StartTime: time.Date(2026, 11, 2, 9, 0, 0, 0, time.UTC)},
+			{ID: "b", StartTime: time.Date(2026, 11, 3, 9, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	days := plan.Days()
+
+	assert.Equal(t, 2, len(days))
+	assert.Equal(t, "2026-11-02", days[0].Date.String())
+	assert.Empty(t, (&TravelPlan{}).Days())
+}
+
+// TestDateJSON 日付がYYYY-MM-DD形式で入出力されることを確認する
+func TestDateJSON(t *testing.T) {
+	var d Date
+	assert.NoError(t, d.UnmarshalJSON([]byte(`"2026-11-01"`)))
+	out, err := d.MarshalJSON()
+	assert.NoError(t, err)
+	assert.Equal(t, `"2026-11-01"`, string(out))
+	assert.Error(t, d.UnmarshalJSON([]byte(`"2026/11/01"`)))
+}
+
+// TestValidCoordinates 緯度・経度は両方とも指定するか両方とも省略する必要があることを確認する
+func TestValidCoordinates(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+
+	assert.True(t, ValidCoordinates(nil, nil))
+	assert.True(t, ValidCoordinates(f(34.99), f(135.78)))
+	assert.True(t, ValidCoordinates(f(-90), f(180)))
+	assert.False(t, ValidCoordinates(f(34.99), nil))
+	assert.False(t, ValidCoordinates(nil, f(135.78)))
+	assert.False(t, ValidCoordinates(f(34.99), f(181)))
+	assert.False(t, ValidCoordinates(f(91), f(0)))
+}
@@ -0,0 +1,167 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 注目度の計算に使う値
+// 注目度は各いいね・ブックマークの重みを半減期で減衰させた合計で、対数で保存する。
+// 基準時刻からの経過時間で重みを増やして保存するため、保存した値のまま並べ替えると現在時刻で減衰させた順序と一致する
+const (
+	trendingHalfLife = 72 * time.Hour // 重みが半分になるまでの時間
+	likeWeight       = 1.0            // いいねの重み
+	bookmarkWeight   = 2.0            // ブックマークの重み
+)
+
+// trendingEpoch 注目度の基準時刻
+var trendingEpoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// PlanLike プランへのいいね
+type PlanLike struct {
+	PlanID    string    `gorm:"primaryKey;size:32" json:"planId"`
+	UserID    uint      `gorm:"primaryKey;index" json:"userId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// PlanBookmark プランのブックマーク
+type PlanBookmark struct {
+	PlanID    string    `gorm:"primaryKey;size:32" json:"planId"`
+	UserID    uint      `gorm:"primaryKey;index" json:"userId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// LikePlan プランにいいねする（すでにいいねしている場合は何もしない）
+func LikePlan(plan *TravelPlan, userID uint) error {
+	return addReaction(plan, &PlanLike{PlanID: plan.ID, UserID: userID}, "like_count", likeWeight)
+}
+
+// UnlikePlan プランのいいねを取り消す
+func UnlikePlan(plan *TravelPlan, userID uint) error {
+	return removeReaction(plan, &PlanLike{}, userID, "like_count")
+}
+
+// BookmarkPlan プランをブックマークする（すでにブックマークしている場合は何もしない）
+func BookmarkPlan(plan *TravelPlan, userID uint) error {
+	return addReaction(plan, &PlanBookmark{PlanID: plan.ID, UserID: userID}, "bookmark_count", bookmarkWeight)
+}
+
+// UnbookmarkPlan プランのブックマークを取り消す
+func UnbookmarkPlan(plan *TravelPlan, userID uint) error {
+	return removeReaction(plan, &PlanBookmark{}, userID, "bookmark_count")
+}
+
+// HasReaction ユーザーがプランにいいね・ブックマークしているかどうかを返す
+func HasReaction(plan *TravelPlan, userID uint) (liked, bookmarked bool) {
+	if userID == 0 {
+		return false, false
+	}
+	var count int64
+	DB.Model(&PlanLike{}).Where("plan_id = ? AND user_id = ?", plan.ID, userID).Count(&count)
+	liked = count > 0
+	DB.Model(&PlanBookmark{}).Where("plan_id = ? AND user_id = ?", plan.ID, userID).Count(&count)
+	bookmarked = count > 0
+	return liked, bookmarked
+}
+
+// addReaction いいね・ブックマークを登録し、件数と注目度を更新する
+func addReaction(plan *TravelPlan, reaction interface{}, counter string, weight float64) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		// 同時に更新されないようにプランの行をロックする
+		var locked TravelPlan
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "like_count", "bookmark_count", "trending_score").
+			First(&locked, "id = ?", plan.ID).Error; err != nil {
+			return err
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(reaction)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		point := trendingPoint(time.Now(), weight)
+		score := point
+		if locked.LikeCount+locked.BookmarkCount > 0 {
+			score = logAddExp(locked.TrendingScore, point)
+		}
+		return tx.Model(&TravelPlan{}).Where("id = ?", plan.ID).UpdateColumns(map[string]interface{}{
+			counter:          gorm.Expr(counter + " + 1"),
+			"trending_score": score,
+		}).Error
+	})
+}
+
+// removeReaction いいね・ブックマークを取り消し、件数と注目度を更新する
+// 注目度は対数で保存しているため差し引けず、残っているいいね・ブックマークから計算し直す
+func removeReaction(plan *TravelPlan, model interface{}, userID uint, counter string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var locked TravelPlan
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").First(&locked, "id = ?", plan.ID).Error; err != nil {
+			return err
+		}
+
+		result := tx.Where("plan_id = ? AND user_id = ?", plan.ID, userID).Delete(model)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		score, err := recomputeTrendingScore(tx, plan.ID)
+		if err != nil {
+			return err
+		}
+		return tx.Model(&TravelPlan{}).Where("id = ?", plan.ID).UpdateColumns(map[string]interface{}{
+			counter:          gorm.Expr(counter + " - 1"),
+			"trending_score": score,
+		}).Error
+	})
+}
+
+// recomputeTrendingScore プランのいいね・ブックマークから注目度を計算する
+func recomputeTrendingScore(tx *gorm.DB, planID string) (float64, error) {
+	var likes, bookmarks []time.Time
+	if err := tx.Model(&PlanLike{}).Where("plan_id = ?", planID).Pluck("created_at", &likes).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Model(&PlanBookmark{}).Where("plan_id = ?", planID).Pluck("created_at", &bookmarks).Error; err != nil {
+		return 0, err
+	}
+
+	points := make([]float64, 0, len(likes)+len(bookmarks))
+	for _, at := range likes {
+		points = append(points, trendingPoint(at, likeWeight))
+	}
+	for _, at := range bookmarks {
+		points = append(points, trendingPoint(at, bookmarkWeight))
+	}
+	return trendingScore(points), nil
+}
+
+// trendingPoint 指定した時刻のいいね・ブックマーク1件分の注目度（対数）を返す
+func trendingPoint(at time.Time, weight float64) float64 {
+	return math.Log(weight) + at.Sub(trendingEpoch).Seconds()*math.Ln2/trendingHalfLife.Seconds()
+}
+
+// trendingScore 各件の注目度（対数）を合計した注目度を返す（1件もない場合は0）
+func trendingScore(points []float64) float64 {
+	if len(points) == 0 {
+		return 0
+	}
+	score := points[0]
+	for _, p := range points[1:] {
+		score = logAddExp(score, p)
+	}
+	return score
+}
+
+// logAddExp log(exp(a) + exp(b)) を桁あふれしないように計算する
+func logAddExp(a, b float64) float64 {
+	if a < b {
+		a, b = b, a
+	}
+	return a + math.Log1p(math.Exp(b-a))
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTrendingScore 注目度が時間とともに減衰した合計の順序になることを確認する
+func TestTrendingScore(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	// 半減期前のいいね2件は現在のいいね1件と同じ注目度
+	old := trendingScore([]float64{
+		trendingPoint(now.Add(-trendingHalfLife), likeWeight),
+		trendingPoint(now.Add(-trendingHalfLife), likeWeight),
+	})
+	assert.InDelta(t, trendingPoint(now, likeWeight), old, 1e-9)
+
+	// ブックマークはいいね2件分
+	assert.InDelta(t, trendingScore([]float64{trendingPoint(now, likeWeight), trendingPoint(now, likeWeight)}),
+		trendingPoint(now, bookmarkWeight), 1e-9)
+
+	// 昔に多くのいいねを集めたプランより最近いいねされたプランが上位になる
+	var popularLongAgo []float64
+	for i := 0; i < 50; i++ {
+		popularLongAgo = append(popularLongAgo, trendingPoint(now.AddDate(0, 0, -60), likeWeight))
+	}
+	recent := trendingScore([]float64{trendingPoint(now.Add(-time.Hour), likeWeight)})
+	assert.Greater(t, recent, trendingScore(popularLongAgo))
+
+	assert.Equal(t, 0.0, trendingScore(nil))
+}
+
+// TestLogAddExp 大きな値でも桁あふれしないことを確認する
+func TestLogAddExp(t *testing.T) {
+	assert.InDelta(t, math.Log(3), logAddExp(math.Log(1), math.Log(2)), 1e-12)
+	assert.InDelta(t, 1000+math.Ln2, logAddExp(1000, 1000), 1e-9)
+	assert.False(t, math.IsInf(logAddExp(5000, 4990), 0))
+}
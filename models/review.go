@@ -0,0 +1,129 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 評価順の並び替えに使うベイズ平均の事前分布
+// レビューが少ないプランは全体の中央の評価に寄せ、1件だけの高評価で上位にならないようにする
+const (
+	ratingPriorMean  = 3.0 // 事前の平均評価
+	ratingPriorCount = 5.0 // 事前の平均評価の重み（レビュー数に換算）
+)
+
+var (
+	// ErrReviewNotAllowed プランをレビューする条件を満たしていない
+	ErrReviewNotAllowed = errors.New("review not allowed")
+	// ErrAlreadyReviewed すでにプランをレビューしている
+	ErrAlreadyReviewed = errors.New("already reviewed")
+)
+
+// PlanReview 完了した公開プランへの評価とレビュー
+// 1人のユーザーが1つのプランにレビューできるのは1回まで
+type PlanReview struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	PlanID    string    `gorm:"size:32;not null;uniqueIndex:idx_plan_review_user" json:"planId"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_plan_review_user;index" json:"userId"`
+	Username  string    `gorm:"-" json:"username"`              // 表示用のユーザー名
+	Rating    int       `gorm:"not null" json:"rating"`         // 評価（1〜5）
+	Body      string    `gorm:"type:text;not null" json:"body"` // レビュー本文
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// CanReview ユーザーがプランをレビューできるかどうかを返す
+// 完了した公開プランを複製したか、ブックマークしたユーザーのみレビューでき、作成者はレビューできない
+func CanReview(plan *TravelPlan, userID uint) bool {
+	if userID == 0 || userID == plan.CreatorID || !plan.IsPublic || plan.Status != "completed" {
+		return false
+	}
+
+	var count int64
+	DB.Unscoped().Model(&TravelPlan{}).
+		Where("source_plan_id = ? AND creator_id = ?", plan.ID, userID).Count(&count)
+	if count > 0 {
+		return true
+	}
+	DB.Model(&PlanBookmark{}).Where("plan_id = ? AND user_id = ?", plan.ID, userID).Count(&count)
+	return count > 0
+}
+
+// CreateReview プランのレビューを作成し、評価の集計を更新する
+func CreateReview(plan *TravelPlan, review *PlanReview) error {
+	if !CanReview(plan, review.UserID) {
+		return ErrReviewNotAllowed
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		// 同じユーザーのレビューが同時に作成されないようにプランの行をロックする
+		var locked TravelPlan
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&locked, "id = ?", plan.ID).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		tx.Model(&PlanReview{}).Where("plan_id = ? AND user_id = ?", plan.ID, review.UserID).Count(&count)
+		if count > 0 {
+			return ErrAlreadyReviewed
+		}
+		review.PlanID = plan.ID
+		if err := tx.Create(review).Error; err != nil {
+			return err
+		}
+		return updateRatings(tx, plan.ID)
+	})
+}
+
+// UpdateReview レビューの評価と本文を更新し、評価の集計を更新する
+func UpdateReview(review *PlanReview, rating int, body string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(review).Updates(map[string]interface{}{"rating": rating, "body": body}).Error
+		if err != nil {
+			return err
+		}
+		return updateRatings(tx, review.PlanID)
+	})
+}
+
+// DeleteReview レビューを削除し、評価の集計を更新する
+func DeleteReview(review *PlanReview) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(review).Error; err != nil {
+			return err
+		}
+		return updateRatings(tx, review.PlanID)
+	})
+}
+
+// updateRatings プランのレビュー数・平均評価・並び替え用の評価を計算し直す
+func updateRatings(tx *gorm.DB, planID string) error {
+	var agg struct {
+		Count int
+		Total int
+	}
+	err := tx.Model(&PlanReview{}).Where("plan_id = ?", planID).
+		Select("COUNT(*) AS count, COALESCE(SUM(rating), 0) AS total").Scan(&agg).Error
+	if err != nil {
+		return err
+	}
+
+	average, score := ratingAverages(agg.Count, agg.Total)
+	return tx.Model(&TravelPlan{}).Where("id = ?", planID).UpdateColumns(map[string]interface{}{
+		"review_count":   agg.Count,
+		"rating_average": average,
+		"rating_score":   score,
+	}).Error
+}
+
+// ratingAverages レビュー数と評価の合計から平均評価とベイズ平均を返す（レビューがない場合はどちらも0）
+func ratingAverages(count, total int) (average, score float64) {
+	if count == 0 {
+		return 0, 0
+	}
+	average = float64(total) / float64(count)
+	score = (ratingPriorMean*ratingPriorCount + float64(total)) / (ratingPriorCount + float64(count))
+	return average, score
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRatingAverages レビューが少ないプランの並び替え用の評価が事前の平均に寄せられることを確認する
+func TestRatingAverages(t *testing.T) {
+	average, score := ratingAverages(0, 0)
+	assert.Equal(t, 0.0, average)
+	assert.Equal(t, 0.0, score)
+
+	// 5つ星1件
+	single, singleScore := ratingAverages(1, 5)
+	assert.Equal(t, 5.0, single)
+	assert.InDelta(t, 20.0/6, singleScore, 1e-9)
+
+	// 4.6の平均で50件のほうが上位になる
+	many, manyScore := ratingAverages(50, 230)
+	assert.Equal(t, 4.6, many)
+	assert.Greater(t, manyScore, singleScore)
+}
+
+// TestCanReview 完了した公開プラン以外や作成者はレビューできないことを確認する
+func TestCanReview(t *testing.T) {
+	plan := &TravelPlan{ID: "plan", CreatorID: 1, IsPublic: true, Status: "confirmed"}
+	assert.False(t, CanReview(plan, 2))
+
+	plan.Status = "completed"
+	assert.False(t, CanReview(plan, 1))
+	assert.False(t, CanReview(plan, 0))
+
+	plan.IsPublic = false
+	assert.False(t, CanReview(plan, 2))
+}
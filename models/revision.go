@@ -0,0 +1,329 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// リビジョンの操作の種類
+const (
+	RevisionPlanCreated    = "plan.created"
+	RevisionPlanUpdated    = "plan.updated"
+	RevisionPlanForked     = "plan.forked"
+	RevisionPlanRestored   = "plan.restored"
+	RevisionStatusUpdated  = "plan.status_updated"
+	RevisionItemCreated    = "item.created"
+	RevisionItemUpdated    = "item.updated"
+	RevisionItemDeleted    = "item.deleted"
+	RevisionItemsReordered = "items.reordered"
+	RevisionItemsImported  = "items.imported"
+	RevisionItemsGeocoded  = "items.geocoded"
+)
+
+// PlanRevision プランの変更履歴（変更後のプラン全体のスナップショット）
+type PlanRevision struct {
+	ID        uint          `gorm:"primaryKey" json:"id"`
+	PlanID    string        `gorm:"size:32;not null;uniqueIndex:idx_plan_revision_number" json:"planId"`
+	Number    int           `gorm:"not null;uniqueIndex:idx_plan_revision_number" json:"number"` // プランごとの連番（1始まり）
+	AuthorID  uint          `json:"authorId"`                                                    // 変更したユーザーのID
+	Action    string        `gorm:"size:50" json:"action"`                                       // 変更の種類（例："plan.updated"）
+	Snapshot  string        `gorm:"type:mediumtext" json:"-"`                                    // PlanSnapshotのJSON
+	CreatedAt time.Time     `json:"createdAt"`
+	Plan      *PlanSnapshot `gorm:"-" json:"plan,omitempty"` // 復元したスナップショット（詳細取得時のみ）
+}
+
+// PlanSnapshot リビジョンに保存するプランの内容
+type PlanSnapshot struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	TotalCost   int            `json:"totalCost"`
+	StartDate   Date           `json:"startDate"`
+	EndDate     Date           `json:"endDate"`
+	TimeZone    string         `json:"timeZone"`
+	IsPublic    bool           `json:"isPublic"`
+	Status      string         `json:"status"`
+	CategoryID  *uint          `json:"categoryId"`
+	Tags        []string       `json:"tags"`
+	Items       []ItemSnapshot `json:"items"`
+}
+
+// ItemSnapshot リビジョンに保存するアイテムの内容
+type ItemSnapshot struct {
+	ID          string    `json:"id"`
+	Type        string    `json:"type"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Location    string    `json:"location"`
+	Latitude    *float64  `json:"latitude"`
+	Longitude   *float64  `json:"longitude"`
+	PlaceID     *uint     `json:"placeId"`
+	StartTime   time.Time `json:"startTime"`
+	EndTime     time.Time `json:"endTime"`
+	TimeZone    string    `json:"timeZone"`
+	Duration    int       `json:"duration"`
+	Cost        int       `json:"cost"`
+	Notes       string    `json:"notes"`
+	Order       int       `json:"order"`
+}
+
+// FieldChange フィールドの変更内容
+type FieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// ItemChange アイテムの変更内容
+type ItemChange struct {
+	ItemID string        `json:"itemId"`
+	Title  string        `json:"title"`
+	Change string        `json:"change"` // "added"(追加)、"removed"(削除)、"modified"(変更)
+	Fields []FieldChange `json:"fields,omitempty"`
+}
+
+// PlanDiff 2つのリビジョンの差分
+type PlanDiff struct {
+	From  int           `json:"from"`
+	To    int           `json:"to"`
+	Plan  []FieldChange `json:"plan"`
+	Items []ItemChange  `json:"items"`
+}
+
+// NewPlanSnapshot プランの現在の内容からスナップショットを作成する
+func NewPlanSnapshot(plan *TravelPlan) PlanSnapshot {
+	snapshot := PlanSnapshot{
+		Title:       plan.Title,
+		Description: plan.Description,
+		TotalCost:   plan.TotalCost,
+		StartDate:   plan.StartDate,
+		EndDate:     plan.EndDate,
+		TimeZone:    plan.TimeZone,
+		IsPublic:    plan.IsPublic,
+		Status:      plan.Status,
+		CategoryID:  plan.CategoryID,
+		Tags:        []string{},
+		Items:       []ItemSnapshot{},
+	}
+	for _, tag := range plan.Tags {
+		snapshot.Tags = append(snapshot.Tags, tag.Name)
+	}
+	sort.Strings(snapshot.Tags)
+	for _, item := range plan.Items {
+		snapshot.Items = append(snapshot.Items, ItemSnapshot{
+			ID:          item.ID,
+			Type:        item.Type,
+			Title:       item.Title,
+			Description: item.Description,
+			Location:    item.Location,
+			Latitude:    item.Latitude,
+			Longitude:   item.Longitude,
+			PlaceID:     item.PlaceID,
+			StartTime:   item.StartTime.UTC(),
+			EndTime:     item.EndTime.UTC(),
+			TimeZone:    item.TimeZone,
+			Duration:    item.Duration,
+			Cost:        item.Cost,
+			Notes:       item.Notes,
+			Order:       item.Order,
+		})
+	}
+	return snapshot
+}
+
+// RecordRevision プランの現在の内容をリビジョンとして保存する
+// プランを変更したのと同じトランザクションで呼び出す
+func RecordRevision(tx *gorm.DB, planID string, authorID uint, action string) error {
+	var plan TravelPlan
+	if err := tx.Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).Preload("Tags").
+		First(&plan, "id = ?", planID).Error; err != nil {
+		return err
+	}
+
+	snapshot, err := json.Marshal(NewPlanSnapshot(&plan))
+	if err != nil {
+		return err
+	}
+
+	var last int
+	if err := tx.Model(&PlanRevision{}).Where("plan_id = ?", planID).
+		Select("COALESCE(MAX(number), 0)").Scan(&last).Error; err != nil {
+		return err
+	}
+
+	return tx.Create(&PlanRevision{
+		PlanID:   planID,
+		Number:   last + 1,
+		AuthorID: authorID,
+		Action:   action,
+		Snapshot: string(snapshot),
+	}).Error
+}
+
+// Decode 保存されたスナップショットを復元する
+func (r *PlanRevision) Decode() (PlanSnapshot, error) {
+	var snapshot PlanSnapshot
+	err := json.Unmarshal([]byte(r.Snapshot), &snapshot)
+	return snapshot, err
+}
+
+// DiffSnapshots 2つのスナップショットの差分を求める
+func DiffSnapshots(from, to PlanSnapshot) ([]FieldChange, []ItemChange) {
+	planChanges := diffFields(from, to, "Items")
+
+	fromItems := map[string]ItemSnapshot{}
+	for _, item := range from.Items {
+		fromItems[item.ID] = item
+	}
+	toItems := map[string]ItemSnapshot{}
+	for _, item := range to.Items {
+		toItems[item.ID] = item
+	}
+
+	itemChanges := []ItemChange{}
+	for _, item := range from.Items {
+		next, ok := toItems[item.ID]
+		if !ok {
+			itemChanges = append(itemChanges, ItemChange{ItemID: item.ID, Title: item.Title, Change: "removed"})
+			continue
+		}
+		if fields := diffFields(item, next, "ID"); len(fields) > 0 {
+			itemChanges = append(itemChanges, ItemChange{ItemID: item.ID, Title: next.Title, Change: "modified", Fields: fields})
+		}
+	}
+	for _, item := range to.Items {
+		if _, ok := fromItems[item.ID]; !ok {
+			itemChanges = append(itemChanges, ItemChange{ItemID: item.ID, Title: item.Title, Change: "added"})
+		}
+	}
+
+	return planChanges, itemChanges
+}
+
+// diffFields 同じ型の2つの構造体の異なるフィールドをJSONのフィールド名で返す
+func diffFields(from, to any, skip ...string) []FieldChange {
+	changes := []FieldChange{}
+	a, b := reflect.ValueOf(from), reflect.ValueOf(to)
+	t := a.Type()
+
+fields:
+	for i := 0; i < t.NumField(); i++ {
+		for _, name := range skip {
+			if t.Field(i).Name == name {
+				continue fields
+			}
+		}
+		x, y := a.Field(i).Interface(), b.Field(i).Interface()
+		if reflect.DeepEqual(x, y) {
+			continue
+		}
+		changes = append(changes, FieldChange{Field: jsonName(t.Field(i)), From: x, To: y})
+	}
+	return changes
+}
+
+// jsonName 構造体フィールドのJSONでの名前を返す
+func jsonName(f reflect.StructField) string {
+	name := f.Tag.Get("json")
+	for i, r := range name {
+		if r == ',' {
+			return name[:i]
+		}
+	}
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// RestoreRevision プランをリビジョンの内容に戻し、復元したことを新しいリビジョンとして記録する
+func RestoreRevision(plan *TravelPlan, revision *PlanRevision, authorID uint) error {
+	snapshot, err := revision.Decode()
+	if err != nil {
+		return err
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := BumpPlanVersion(tx, plan.ID, plan.Version); err != nil {
+			return err
+		}
+
+		// リビジョンの保存後に削除されたカテゴリは参照しない
+		categoryID := snapshot.CategoryID
+		if categoryID != nil {
+			var count int64
+			if err := tx.Model(&Category{}).Where("id = ?", *categoryID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				categoryID = nil
+			}
+		}
+
+		// ゼロ値（false・0・空文字）も戻せるようにmapで更新する
+		err := tx.Model(plan).Updates(map[string]interface{}{
+			"title":       snapshot.Title,
+			"description": snapshot.Description,
+			"total_cost":  snapshot.TotalCost,
+			"start_date":  snapshot.StartDate,
+			"end_date":    snapshot.EndDate,
+			"time_zone":   snapshot.TimeZone,
+			"is_public":   snapshot.IsPublic,
+			"status":      snapshot.Status,
+			"category_id": categoryID,
+			"updated_at":  time.Now(),
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		tags, err := FindOrCreateTags(tx, snapshot.Tags)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(plan).Association("Tags").Replace(tags); err != nil {
+			return err
+		}
+
+		// アイテムはすべて置き換える（IDはリビジョンのものを引き継ぐため、削除済みのアイテムも完全に削除する）
+		if err := tx.Unscoped().Where("plan_id = ?", plan.ID).Delete(&PlanItem{}).Error; err != nil {
+			return err
+		}
+		// リビジョンの保存後に統合などで削除された場所は参照しない
+		places, err := existingPlaceIDs(tx, snapshot.Items)
+		if err != nil {
+			return err
+		}
+		for _, s := range snapshot.Items {
+			if s.PlaceID != nil && !places[*s.PlaceID] {
+				s.PlaceID = nil
+			}
+			item := PlanItem{
+				ID:          s.ID,
+				PlanID:      plan.ID,
+				Type:        s.Type,
+				Title:       s.Title,
+				Description: s.Description,
+				Location:    s.Location,
+				Latitude:    s.Latitude,
+				Longitude:   s.Longitude,
+				PlaceID:     s.PlaceID,
+				StartTime:   s.StartTime,
+				EndTime:     s.EndTime,
+				TimeZone:    s.TimeZone,
+				Duration:    s.Duration,
+				Cost:        s.Cost,
+				Notes:       s.Notes,
+				Order:       s.Order,
+			}
+			if err := tx.Create(&item).Error; err != nil {
+				return err
+			}
+		}
+
+		return RecordRevision(tx, plan.ID, authorID, RevisionPlanRestored)
+	})
+}
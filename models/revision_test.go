@@ -0,0 +1,43 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDiffSnapshots プランのフィールドとアイテムの追加・削除・変更が差分として検出されることを確認する
+func TestDiffSnapshots(t *testing.T) {
+	start := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	from := NewPlanSnapshot(&TravelPlan{
+		Title:    "京都旅行",
+		IsPublic: true,
+		Tags:     []Tag{{Name: "京都"}},
+		Items: []PlanItem{
+			{ID: "a", Title: "清水寺観光", StartTime: start, Cost: 400},
+			{ID: "b", Title: "金閣寺", StartTime: start},
+		},
+	})
+	to := NewPlanSnapshot(&TravelPlan{
+		Title:    "京都旅行",
+		IsPublic: false,
+		Tags:     []Tag{{Name: "京都"}, {Name: "紅葉"}},
+		Items: []PlanItem{
+			{ID: "a", Title: "清水寺観光", StartTime: start, Cost: 500},
+			{ID: "c", Title: "伏見稲荷大社", StartTime: start},
+		},
+	})
+
+	planChanges, itemChanges := DiffSnapshots(from, to)
+
+	assert.Equal(t, []FieldChange{
+		{Field: "isPublic", From: true, To: false},
+		{Field: "tags", From: []string{"京都"}, To: []string{"京都", "紅葉"}},
+	}, planChanges)
+	assert.Equal(t, []ItemChange{
+		{ItemID: "a", Title: "清水寺観光", Change: "modified", Fields: []FieldChange{{Field: "cost", From: 400, To: 500}}},
+		{ItemID: "b", Title: "金閣寺", Change: "removed"},
+		{ItemID: "c", Title: "伏見稲荷大社", Change: "added"},
+	}, itemChanges)
+}
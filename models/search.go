@@ -0,0 +1,181 @@
+package models
+
+import (
+	"backend/utils/search"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SearchIndex プランの検索インデックス（ConnectDataBaseでMySQLのインデックスが設定される）
+var SearchIndex search.Index = search.NewMemoryIndex()
+
+// 検索対象フィールドの重み
+const (
+	weightPlanTitle       = 3.0
+	weightPlanDescription = 1.0
+	weightItemTitle       = 2.0
+	weightItemLocation    = 1.5
+	weightItemDescription = 1.0
+	weightItemNotes       = 0.5
+)
+
+// PlanDocument プランとアイテムから検索用のドキュメントを作成する
+func PlanDocument(plan *TravelPlan) search.Document {
+	doc := search.Document{
+		ID: plan.ID,
+		Fields: []search.Field{
+			{Name: "title", Text: plan.Title, Weight: weightPlanTitle},
+			{Name: "description", Text: plan.Description, Weight: weightPlanDescription},
+		},
+	}
+	for _, item := range plan.Items {
+		doc.Fields = append(doc.Fields,
+			search.Field{Name: "items.title", Text: item.Title, Weight: weightItemTitle},
+			search.Field{Name: "items.description", Text: item.Description, Weight: weightItemDescription},
+			search.Field{Name: "items.location", Text: item.Location, Weight: weightItemLocation},
+			search.Field{Name: "items.notes", Text: item.Notes, Weight: weightItemNotes},
+		)
+	}
+	return doc
+}
+
+// ReindexPlan プランを読み込み直して検索インデックスを更新する
+// インデックスの更新に失敗してもプランの操作は成功しているため、ログに残すだけにする
+func ReindexPlan(planID string) {
+	var plan TravelPlan
+	if err := DB.Preload("Items").First(&plan, "id = ?", planID).Error; err != nil {
+		log.Printf("search: failed to load plan %s: %v", planID, err)
+		return
+	}
+	if err := SearchIndex.Index(PlanDocument(&plan)); err != nil {
+		log.Printf("search: failed to index plan %s: %v", planID, err)
+	}
+}
+
+// RemoveFromSearchIndex プランを検索インデックスから削除する
+func RemoveFromSearchIndex(planID string) {
+	if err := SearchIndex.Delete(planID); err != nil {
+		log.Printf("search: failed to remove plan %s: %v", planID, err)
+	}
+}
+
+// PlanSearchDocument MySQLの全文検索用テーブル
+// タイトルとそれ以外の本文をngramパーサーの全文検索インデックスで検索する
+type PlanSearchDocument struct {
+	PlanID    string `gorm:"primaryKey;size:32"`
+	Title     string `gorm:"type:text;index:idx_plan_search_title,class:FULLTEXT,option:WITH PARSER ngram"`
+	Body      string `gorm:"type:mediumtext;index:idx_plan_search_body,class:FULLTEXT,option:WITH PARSER ngram"`
+	Fields    string `gorm:"type:mediumtext"` // ハイライト用に保存するフィールドのJSON
+	UpdatedAt time.Time
+}
+
+// MySQLSearchIndex MySQLの全文検索インデックス（ngramパーサー）を使う検索インデックス
+type MySQLSearchIndex struct {
+	db *gorm.DB
+}
+
+// NewMySQLSearchIndex MySQLの検索インデックスを作成する
+func NewMySQLSearchIndex(db *gorm.DB) *MySQLSearchIndex {
+	return &MySQLSearchIndex{db: db}
+}
+
+// Index ドキュメントを登録する
+func (m *MySQLSearchIndex) Index(doc search.Document) error {
+	fields, err := json.Marshal(doc.Fields)
+	if err != nil {
+		return err
+	}
+
+	var title string
+	var body []string
+	for _, field := range doc.Fields {
+		if field.Name == "title" {
+			title = field.Text
+			continue
+		}
+		body = append(body, field.Text)
+	}
+
+	row := PlanSearchDocument{
+		PlanID:    doc.ID,
+		Title:     title,
+		Body:      strings.Join(body, "\n"),
+		Fields:    string(fields),
+		UpdatedAt: time.Now(),
+	}
+	return m.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&row).Error
+}
+
+// Delete ドキュメントを削除する
+func (m *MySQLSearchIndex) Delete(id string) error {
+	return m.db.Delete(&PlanSearchDocument{}, "plan_id = ?", id).Error
+}
+
+// Search 検索語をすべて含むドキュメントをMySQLの関連度（タイトルを重視）の順に返す
+func (m *MySQLSearchIndex) Search(query string, limit int) ([]search.Hit, error) {
+	terms := search.Terms(query)
+	if len(terms) == 0 {
+		return []search.Hit{}, nil
+	}
+
+	against, where, args := mysqlSearchCondition(terms)
+
+	var rows []struct {
+		PlanSearchDocument
+		Score float64
+	}
+	err := m.db.Model(&PlanSearchDocument{}).
+		Select("*, MATCH(title) AGAINST(? IN BOOLEAN MODE) * ? + MATCH(body) AGAINST(? IN BOOLEAN MODE) AS score",
+			against, weightPlanTitle, against).
+		Where(where, args...).
+		Order("score DESC").
+		Limit(limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]search.Hit, 0, len(rows))
+	for _, row := range rows {
+		doc := search.Document{ID: row.PlanID}
+		if err := json.Unmarshal([]byte(row.Fields), &doc.Fields); err != nil {
+			return nil, err
+		}
+		hits = append(hits, search.Hit{ID: row.PlanID, Score: row.Score, Highlights: search.Highlights(doc, query)})
+	}
+	return hits, nil
+}
+
+// mysqlSearchCondition 検索語から関連度の計算に使う語句と、検索の条件を作成する
+// MemoryIndex と同じくドキュメント全体で検索語をすべて含むものを返すため、
+// 語句ごとにタイトルか本文のどちらかに含むことを条件にする（別々の列に含まれていてもよい）
+func mysqlSearchCondition(terms []string) (against string, where string, args []interface{}) {
+	phrases := make([]string, len(terms))
+	conditions := make([]string, len(terms))
+	for i, term := range terms {
+		// BOOLEAN MODEで各語句をフレーズとして扱う
+		phrases[i] = fmt.Sprintf(`"%s"`, strings.ReplaceAll(term, `"`, ""))
+		conditions[i] = "(MATCH(title) AGAINST(? IN BOOLEAN MODE) OR MATCH(body) AGAINST(? IN BOOLEAN MODE))"
+		args = append(args, phrases[i], phrases[i])
+	}
+	return strings.Join(phrases, " "), strings.Join(conditions, " AND "), args
+}
+
+// RebuildSearchIndex すべてのプランを検索インデックスに登録し直す
+func RebuildSearchIndex() error {
+	var plans []TravelPlan
+	return DB.Preload("Items").FindInBatches(&plans, 100, func(tx *gorm.DB, batch int) error {
+		for i := range plans {
+			if err := SearchIndex.Index(PlanDocument(&plans[i])); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+}
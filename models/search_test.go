@@ -0,0 +1,19 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMySQLSearchCondition 検索語ごとにタイトルか本文のどちらかに含むことを条件にすることを確認する
+func TestMySQLSearchCondition(t *testing.T) {
+	against, where, args := mysqlSearchCondition([]string{"京都", `清水"寺`})
+
+	assert.Equal(t, `"京都" "清水寺"`, against)
+	assert.Equal(t,
+		"(MATCH(title) AGAINST(? IN BOOLEAN MODE) OR MATCH(body) AGAINST(? IN BOOLEAN MODE)) AND "+
+			"(MATCH(title) AGAINST(? IN BOOLEAN MODE) OR MATCH(body) AGAINST(? IN BOOLEAN MODE))",
+		where)
+	assert.Equal(t, []interface{}{`"京都"`, `"京都"`, `"清水寺"`, `"清水寺"`}, args)
+}
@@ -25,7 +25,8 @@ func ConnectDataBase() {
 	dbHost := os.Getenv("DB_HOST")
 	dbPort := os.Getenv("DB_PORT")
 
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+	// 時刻はUTCで保存し、表示時にプランのタイムゾーンへ変換する
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=UTC",
 		dbUser, dbPass, dbHost, dbPort, dbName)
 
 	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
@@ -34,8 +35,30 @@ func ConnectDataBase() {
 	}
 
 	// ここで適切なエンティティに対してマイグレーションを実行します。
-	err = DB.AutoMigrate(&User{})
+	err = DB.AutoMigrate(&User{}, &Category{}, &Tag{}, &TravelPlan{}, &PlanItem{}, &PlanSearchDocument{}, &PlanRevision{},
+		&PlanMember{}, &Comment{}, &CommentMention{}, &PlanLike{}, &PlanBookmark{}, &PlanReview{},
+		&PlanTemplate{}, &TemplateItem{}, &CalendarFeedToken{}, &Place{}, &GeocodeCacheEntry{})
 	if err != nil {
 		return
 	}
+
+	if err := PromoteAdmins(os.Getenv("ADMIN_USERNAMES")); err != nil {
+		log.Println("Could not promote admin users", err)
+	}
+
+	SearchIndex = NewMySQLSearchIndex(DB)
+
+	Geocoder, err = NewGeocoderFromEnv(DB)
+	if err != nil {
+		log.Println("Could not configure the geocoder", err)
+	}
+
+	// 検索インデックスが空の場合は既存のプランから作成する
+	var indexed int64
+	DB.Model(&PlanSearchDocument{}).Count(&indexed)
+	if indexed == 0 {
+		if err := RebuildSearchIndex(); err != nil {
+			log.Println("Could not build the search index", err)
+		}
+	}
 }
@@ -0,0 +1,171 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PlanTemplate 繰り返し使えるプランのひな形
+// アイテムの時刻は日付に依存しない相対的な値（何日目・現地時刻・所要時間）で保存する
+type PlanTemplate struct {
+	ID           string         `gorm:"primaryKey;size:32" json:"id"`
+	Title        string         `gorm:"not null" json:"title"`               // 例：「京都定番1日コース」
+	Description  string         `json:"description"`                         // テンプレートの説明
+	Items        []TemplateItem `gorm:"foreignKey:TemplateID" json:"items"`  // テンプレートの各項目
+	TotalCost    int            `json:"totalCost"`                           // 合計費用
+	DayCount     int            `gorm:"not null;default:1" json:"dayCount"`  // 日数
+	TimeZone     string         `gorm:"size:64" json:"timeZone"`             // 作成元のプランのタイムゾーン（展開時の既定値）
+	CreatorID    uint           `gorm:"index" json:"creatorId"`              // テンプレート作成者のユーザーID
+	IsPublic     bool           `gorm:"index" json:"isPublic"`               // テンプレートの公開状態
+	CategoryID   *uint          `gorm:"index" json:"categoryId"`             // カテゴリID
+	Category     *Category      `json:"category,omitempty"`                  // カテゴリ
+	Tags         []Tag          `gorm:"many2many:template_tags" json:"tags"` // タグ
+	SourcePlanID *string        `gorm:"size:32;index" json:"sourcePlanId"`   // 作成元のプランID
+	UseCount     int            `gorm:"not null;default:0" json:"useCount"`  // このテンプレートからプランが作成された回数
+	CreatedAt    time.Time      `json:"createdAt"`
+	UpdatedAt    time.Time      `json:"updatedAt"`
+}
+
+// TemplateItem テンプレートのアイテム
+// 時刻は現地時刻で保存するため、夏時間のある地域や別のタイムゾーンで展開しても現地の予定時刻は変わらない
+type TemplateItem struct {
+	ID          uint     `gorm:"primaryKey" json:"id"`
+	TemplateID  string   `gorm:"size:32;index" json:"templateId"`
+	Type        string   `json:"type"`                    // "visit"(訪問)、"transport"(移動)、"meal"(食事)など
+	Title       string   `json:"title"`                   // 例：「清水寺観光」
+	Description string   `json:"description"`             // 詳細説明
+	Location    string   `json:"location"`                // 場所
+	Latitude    *float64 `json:"latitude"`                // 緯度
+	Longitude   *float64 `json:"longitude"`               // 経度
+	PlaceID     *uint    `gorm:"index" json:"placeId"`    // 参照する場所のID
+	Day         int      `json:"day"`                     // 何日目か（1始まり）
+	StartMinute int      `json:"startMinute"`             // 開始時刻（現地時刻の0時からの分）
+	Duration    int      `json:"duration"`                // 所要時間（分）
+	TimeZone    string   `gorm:"size:64" json:"timeZone"` // IANAタイムゾーン（未設定の場合は展開先のプランのタイムゾーン）
+	Cost        int      `json:"cost"`                    // 費用（円）
+	Notes       string   `json:"notes"`                   // メモ
+	Order       int      `json:"order"`                   // 順序
+}
+
+// BeforeCreate テンプレートが作成される前にIDを採番する
+func (t *PlanTemplate) BeforeCreate(*gorm.DB) error {
+	if t.ID == "" {
+		t.ID = newID()
+	}
+	return nil
+}
+
+// NewTemplate プランからテンプレートを作成する（保存はしない）
+// 各アイテムは開始日からの日数と現地時刻に変換する
+func NewTemplate(plan *TravelPlan, ownerID uint) PlanTemplate {
+	template := PlanTemplate{
+		Title:        plan.Title,
+		Description:  plan.Description,
+		TotalCost:    plan.TotalCost,
+		DayCount:     1,
+		TimeZone:     plan.TimeZone,
+		CreatorID:    ownerID,
+		CategoryID:   plan.CategoryID,
+		Tags:         plan.Tags,
+		SourcePlanID: &plan.ID,
+		Items:        []TemplateItem{},
+	}
+
+	first := plan.firstDate()
+	if !first.IsZero() && !plan.EndDate.IsZero() {
+		template.DayCount = max(first.DaysUntil(plan.EndDate)+1, 1)
+	}
+
+	for i := range plan.Items {
+		item := &plan.Items[i]
+		start := item.StartTime.In(plan.ItemLocation(item))
+		day := first.DaysUntil(DateOf(start)) + 1
+		template.DayCount = max(template.DayCount, day)
+		template.Items = append(template.Items, TemplateItem{
+			Type:        item.Type,
+			Title:       item.Title,
+			Description: item.Description,
+			Location:    item.Location,
+			Latitude:    item.Latitude,
+			Longitude:   item.Longitude,
+			PlaceID:     item.PlaceID,
+			Day:         day,
+			StartMinute: start.Hour()*60 + start.Minute(),
+			Duration:    item.DurationMinutes(),
+			TimeZone:    item.TimeZone,
+			Cost:        item.Cost,
+			Notes:       item.Notes,
+			Order:       item.Order,
+		})
+	}
+
+	return template
+}
+
+// Instantiate テンプレートから指定した開始日・タイムゾーンの新しい下書きのプランを作成する（保存はしない）
+// timeZone が空の場合はテンプレートのタイムゾーンを使う
+func (t *PlanTemplate) Instantiate(ownerID uint, startDate Date, timeZone string) TravelPlan {
+	if timeZone == "" {
+		timeZone = t.TimeZone
+	}
+	plan := TravelPlan{
+		Title:       t.Title,
+		Description: t.Description,
+		TotalCost:   t.TotalCost,
+		StartDate:   startDate,
+		EndDate:     startDate.AddDays(max(t.DayCount, 1) - 1),
+		TimeZone:    timeZone,
+		CreatorID:   ownerID,
+		IsPublic:    false,
+		Status:      "draft",
+		CategoryID:  t.CategoryID,
+		Tags:        t.Tags,
+	}
+
+	for _, ti := range t.Items {
+		item := PlanItem{
+			Type:        ti.Type,
+			Title:       ti.Title,
+			Description: ti.Description,
+			Location:    ti.Location,
+			Latitude:    ti.Latitude,
+			Longitude:   ti.Longitude,
+			PlaceID:     ti.PlaceID,
+			TimeZone:    ti.TimeZone,
+			Duration:    ti.Duration,
+			Cost:        ti.Cost,
+			Notes:       ti.Notes,
+			Order:       ti.Order,
+		}
+		// 夏時間の切り替えで存在しない時刻は time.Date の正規化に従う
+		day := startDate.AddDays(ti.Day - 1)
+		item.StartTime = time.Date(day.Year(), day.Month(), day.Day(),
+			0, ti.StartMinute, 0, 0, plan.ItemLocation(&item))
+		item.EndTime = item.StartTime.Add(time.Duration(ti.Duration) * time.Minute)
+		plan.Items = append(plan.Items, item)
+	}
+
+	return plan
+}
+
+// InstantiateTemplate テンプレートからプランを作成して保存し、テンプレートの利用回数を増やす
+func InstantiateTemplate(template *PlanTemplate, ownerID uint, startDate Date, timeZone string) (*TravelPlan, error) {
+	plan := template.Instantiate(ownerID, startDate, timeZone)
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&plan).Error; err != nil {
+			return err
+		}
+		if err := RecordRevision(tx, plan.ID, ownerID, RevisionPlanCreated); err != nil {
+			return err
+		}
+		return tx.Model(&PlanTemplate{}).Where("id = ?", template.ID).
+			UpdateColumn("use_count", gorm.Expr("use_count + ?", 1)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &plan, nil
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTemplateRoundTrip テンプレートに保存したアイテムが、別の日付・タイムゾーンで同じ現地時刻に展開されることを確認する
+func TestTemplateRoundTrip(t *testing.T) {
+	tokyo := mustLoad(t, "Asia/Tokyo")
+	plan := TravelPlan{
+		ID:        "kyoto",
+		Title:     "京都2日間",
+		TimeZone:  "Asia/Tokyo",
+		StartDate: NewDate(2026, 4, 1),
+		EndDate:   NewDate(2026, 4, 2),
+		TotalCost: 12000,
+		Items: []PlanItem{
+			{
+				Title:     "清水寺",
+				StartTime: time.Date(2026, 4, 1, 9, 30, 0, 0, tokyo).UTC(),
+				EndTime:   time.Date(2026, 4, 1, 11, 0, 0, 0, tokyo).UTC(),
+				Cost:      500,
+			},
+			{
+				Title:     "夜の先斗町",
+				StartTime: time.Date(2026, 4, 2, 23, 0, 0, 0, tokyo).UTC(),
+				EndTime:   time.Date(2026, 4, 3, 1, 0, 0, 0, tokyo).UTC(),
+			},
+		},
+	}
+
+	template := NewTemplate(&plan, 2)
+	assert.Equal(t, uint(2), template.CreatorID)
+	assert.Equal(t, 2, template.DayCount)
+	assert.Equal(t, "kyoto", *template.SourcePlanID)
+	assert.Equal(t, 1, template.Items[0].Day)
+	assert.Equal(t, 9*60+30, template.Items[0].StartMinute)
+	assert.Equal(t, 90, template.Items[0].Duration)
+	assert.Equal(t, 2, template.Items[1].Day)
+	assert.Equal(t, 23*60, template.Items[1].StartMinute)
+	assert.Equal(t, 120, template.Items[1].Duration)
+
+	// 夏時間が始まる日（3/8）のニューヨークで展開しても現地時刻は同じ
+	newYork := mustLoad(t, "America/New_York")
+	instance := template.Instantiate(3, NewDate(2026, 3, 7), "America/New_York")
+	assert.Equal(t, uint(3), instance.CreatorID)
+	assert.Equal(t, "draft", instance.Status)
+	assert.False(t, instance.IsPublic)
+	assert.Equal(t, "2026-03-07", instance.StartDate.String())
+	assert.Equal(t, "2026-03-08", instance.EndDate.String())
+	assert.Equal(t, time.Date(2026, 3, 7, 9, 30, 0, 0, newYork), instance.Items[0].StartTime)
+	assert.Equal(t, time.Date(2026, 3, 8, 23, 0, 0, 0, newYork), instance.Items[1].StartTime)
+	assert.Equal(t, 2*time.Hour, instance.Items[1].EndTime.Sub(instance.Items[1].StartTime))
+
+	// タイムゾーンを省略するとテンプレートのタイムゾーンを使う
+	assert.Equal(t, "Asia/Tokyo", template.Instantiate(3, NewDate(2026, 5, 1), "").TimeZone)
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	// サーバーにタイムゾーンデータベースがなくても動作するように埋め込む
+	_ "time/tzdata"
+)
+
+// DefaultTimeZone タイムゾーンが未設定のプランに使うタイムゾーン
+const DefaultTimeZone = "Asia/Tokyo"
+
+// LoadTimeZone IANAタイムゾーン名を読み込む（空文字の場合はDefaultTimeZone）
+func LoadTimeZone(name string) (*time.Location, error) {
+	if name == "" {
+		name = DefaultTimeZone
+	}
+	// "Local" はサーバーの設定に依存するため受け付けない
+	if name == "Local" {
+		return nil, fmt.Errorf("invalid time zone %q", name)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q", name)
+	}
+	return loc, nil
+}
+
+// ValidTimeZone IANAタイムゾーン名として正しいかどうかを返す（空文字は未設定として許可）
+func ValidTimeZone(name string) bool {
+	if name == "" {
+		return true
+	}
+	_, err := LoadTimeZone(name)
+	return err == nil
+}
+
+// Location プランのタイムゾーンを返す
+func (p *TravelPlan) Location() *time.Location {
+	loc, err := LoadTimeZone(p.TimeZone)
+	if err != nil {
+		loc, _ = LoadTimeZone(DefaultTimeZone)
+	}
+	return loc
+}
+
+// ItemLocation アイテムのタイムゾーンを返す（未設定の場合はプランのタイムゾーン）
+func (p *TravelPlan) ItemLocation(item *PlanItem) *time.Location {
+	if item.TimeZone != "" {
+		if loc, err := LoadTimeZone(item.TimeZone); err == nil {
+			return loc
+		}
+	}
+	return p.Location()
+}
+
+// LocalDate アイテムの開始日をアイテムのタイムゾーンでの日付として返す
+func (p *TravelPlan) LocalDate(item *PlanItem) Date {
+	return DateOf(item.StartTime.In(p.ItemLocation(item)))
+}
+
+// Localize アイテムの時刻を各アイテムのタイムゾーンでの表示に変換する
+func (p *TravelPlan) Localize() {
+	for i := range p.Items {
+		p.LocalizeItem(&p.Items[i])
+	}
+}
+
+// LocalizeItem アイテムの時刻をアイテムのタイムゾーンでの表示に変換する
+func (p *TravelPlan) LocalizeItem(item *PlanItem) {
+	loc := p.ItemLocation(item)
+	item.StartTime = item.StartTime.In(loc)
+	item.EndTime = item.EndTime.In(loc)
+}
+
+// DurationMinutes 開始時間から終了時間までの実際の経過時間（分）を返す
+// 夏時間の切り替えをまたぐ場合も壁時計ではなく経過時間で計算する
+func (i *PlanItem) DurationMinutes() int {
+	if i.StartTime.IsZero() || i.EndTime.IsZero() {
+		return 0
+	}
+	return int(i.EndTime.Sub(i.StartTime).Minutes())
+}
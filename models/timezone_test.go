@@ -0,0 +1,107 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	loc, err := LoadTimeZone(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+// TestLoadTimeZone タイムゾーン名の検証を確認する
+func TestLoadTimeZone(t *testing.T) {
+	loc, err := LoadTimeZone("")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultTimeZone, loc.String())
+
+	assert.True(t, ValidTimeZone("Europe/Paris"))
+	assert.False(t, ValidTimeZone("Local"))
+	assert.False(t, ValidTimeZone("Mars/Olympus_Mons"))
+}
+
+// TestBeforeSaveStoresUTC 保存時に時刻がUTCに揃えられることを確認する
+func TestBeforeSaveStoresUTC(t *testing.T) {
+	tokyo := mustLoad(t, "Asia/Tokyo")
+	item := PlanItem{
+		StartTime: time.Date(2026, 11, 1, 9, 0, 0, 0, tokyo),
+		EndTime:   time.Date(2026, 11, 1, 11, 0, 0, 0, tokyo),
+	}
+
+	assert.NoError(t, item.BeforeSave(nil))
+	assert.Equal(t, time.UTC, item.StartTime.Location())
+	assert.Equal(t, time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), item.StartTime)
+}
+
+// TestLocalizeRendersPlanZone UTCで保存された時刻がプランの現地時間で表示されることを確認する
+func TestLocalizeRendersPlanZone(t *testing.T) {
+	plan := TravelPlan{
+		TimeZone: "Asia/Tokyo",
+		Items: []PlanItem{
+			{StartTime: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	plan.Localize()
+
+	assert.Equal(t, "2026-11-01T09:00:00+09:00", plan.Items[0].StartTime.Format(time.RFC3339))
+}
+
+// TestDaysAcrossTimeZones 東京からホノルルへ移動する旅行で、各アイテムの現地日付で日ごとにまとめられることを確認する
+func TestDaysAcrossTimeZones(t *testing.T) {
+	tokyo := mustLoad(t, "Asia/Tokyo")
+	honolulu := mustLoad(t, "Pacific/Honolulu")
+
+	plan := TravelPlan{
+		TimeZone:  "Asia/Tokyo",
+		StartDate: NewDate(2026, 12, 20),
+		EndDate:   NewDate(2026, 12, 22),
+		Items: []PlanItem{
+			// 12/20 21:00 JST 羽田発 → 12/20 09:00 HST ホノルル着（日付変更線を越える）
+			{ID: "flight", StartTime: time.Date(2026, 12, 20, 21, 0, 0, 0, tokyo).UTC(), Order: 1},
+			{ID: "beach", TimeZone: "Pacific/Honolulu", StartTime: time.Date(2026, 12, 20, 13, 0, 0, 0, honolulu).UTC(), Order: 2},
+			{ID: "dinner", TimeZone: "Pacific/Honolulu", StartTime: time.Date(2026, 12, 21, 19, 0, 0, 0, honolulu).UTC(), Order: 1},
+		},
+	}
+
+	days := plan.Days()
+
+	assert.Equal(t, 3, len(days))
+	assert.Equal(t, []string{"flight", "beach"}, []string{days[0].Items[0].ID, days[0].Items[1].ID})
+	assert.Equal(t, "2026-12-20T13:00:00-10:00", days[0].Items[1].StartTime.Format(time.RFC3339))
+	assert.Equal(t, "dinner", days[1].Items[0].ID)
+	assert.Empty(t, days[2].Items)
+}
+
+// TestDaysAcrossDST 夏時間の終了日をまたぐアイテムが正しい日付と所要時間になることを確認する
+func TestDaysAcrossDST(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+
+	// 2026-11-01 02:00 EDT に時計が 01:00 EST に戻る
+	start := time.Date(2026, 11, 1, 0, 30, 0, 0, newYork)
+	end := time.Date(2026, 11, 1, 3, 30, 0, 0, newYork)
+	item := PlanItem{ID: "night-tour", StartTime: start.UTC(), EndTime: end.UTC()}
+	late := PlanItem{ID: "late-show", StartTime: time.Date(2026, 10, 31, 23, 30, 0, 0, newYork).UTC()}
+
+	plan := TravelPlan{
+		TimeZone: "America/New_York",
+		Items:    []PlanItem{item, late},
+	}
+
+	// 壁時計では3時間だが、実際の経過時間は4時間
+	assert.Equal(t, 240, item.DurationMinutes())
+
+	days := plan.Days()
+	assert.Equal(t, 2, len(days))
+	assert.Equal(t, "2026-10-31", days[0].Date.String())
+	assert.Equal(t, "late-show", days[0].Items[0].ID)
+	assert.Equal(t, "night-tour", days[1].Items[0].ID)
+	assert.Equal(t, "-04:00", days[1].Items[0].StartTime.Format("-07:00"))
+	assert.Equal(t, "-05:00", days[1].Items[0].EndTime.Format("-07:00"))
+}
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// defaultTrashRetentionDays ゴミ箱に入ったプランを完全に削除するまでの日数の既定値
+const defaultTrashRetentionDays = 30
+
+// TrashRetention ゴミ箱の保持期間を返す（環境変数 TRASH_RETENTION_DAYS で変更できる）
+func TrashRetention() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS"))
+	if err != nil || days < 1 {
+		days = defaultTrashRetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// SoftDeletePlan プランとそのアイテムをゴミ箱に移動する
+// アイテムにはプランと同じ削除日時を記録し、復元時にまとめて戻せるようにする
+func SoftDeletePlan(plan *TravelPlan) error {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := BumpPlanVersion(tx, plan.ID, plan.Version); err != nil {
+			return err
+		}
+		if err := tx.Model(&PlanItem{}).Where("plan_id = ?", plan.ID).
+			UpdateColumn("deleted_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(plan).UpdateColumn("deleted_at", now).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	RemoveFromSearchIndex(plan.ID)
+	return nil
+}
+
+// RestorePlan ゴミ箱のプランと、プランと一緒に削除されたアイテムを元に戻す
+func RestorePlan(plan *TravelPlan) error {
+	deletedAt := plan.DeletedAt.Time
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&PlanItem{}).
+			Where("plan_id = ? AND deleted_at = ?", plan.ID, deletedAt).
+			UpdateColumn("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(plan).UpdateColumn("deleted_at", nil).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	ReindexPlan(plan.ID)
+	return nil
+}
+
+// PurgeTrash 指定した日時より前に削除されたプランとアイテムを完全に削除し、削除したプラン数を返す
+func PurgeTrash(before time.Time) (int, error) {
+	var ids []string
+	if err := DB.Unscoped().Model(&TravelPlan{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		// 個別に削除されたアイテムも保持期間を過ぎたら完全に削除する
+		if err := tx.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+			Delete(&PlanItem{}).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		if err := tx.Unscoped().Where("plan_id IN ?", ids).Delete(&PlanItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("plan_id IN ?", ids).Delete(&PlanRevision{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM plan_tags WHERE travel_plan_id IN ?", ids).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("comment_id IN (?)",
+			tx.Unscoped().Model(&Comment{}).Select("id").Where("plan_id IN ?", ids)).
+			Delete(&CommentMention{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("plan_id IN ?", ids).Delete(&Comment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("plan_id IN ?", ids).Delete(&PlanMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("plan_id IN ?", ids).Delete(&PlanLike{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("plan_id IN ?", ids).Delete(&PlanBookmark{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("plan_id IN ?", ids).Delete(&PlanReview{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id IN ?", ids).Delete(&TravelPlan{}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		RemoveFromSearchIndex(id)
+	}
+	return len(ids), nil
+}
+
+// PurgeTrashPeriodically 一定間隔で保持期間を過ぎたゴミ箱のプランを完全に削除する
+// バックグラウンドのゴルーチンとして起動する
+func PurgeTrashPeriodically(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := PurgeTrash(time.Now().Add(-TrashRetention()))
+		if err != nil {
+			log.Println("Could not purge the trash", err)
+		} else if n > 0 {
+			log.Printf("Purged %d plans from the trash", n)
+		}
+		<-ticker.C
+	}
+}
@@ -12,6 +12,7 @@ type User struct {
 	gorm.Model
 	Username string `gorm:"size:255;not null;unique" json:"username"`
 	Password string `gorm:"size:255;not null;" json:"password"`
+	IsAdmin  bool   `gorm:"not null;default:false" json:"isAdmin"` // 管理者かどうか（カテゴリの管理などができる）
 }
 
 // Save User オブジェクトをデータベースに保存する
@@ -45,6 +46,32 @@ func (u *User) PrepareOutput() *User {
 	return u
 }
 
+// IsAdmin ユーザーが管理者かどうかを返す
+func IsAdmin(userID uint) bool {
+	if userID == 0 {
+		return false
+	}
+	var count int64
+	DB.Model(&User{}).Where("id = ? AND is_admin = ?", userID, true).Count(&count)
+	return count > 0
+}
+
+// PromoteAdmins 指定したユーザー名（カンマ区切り）のユーザーを管理者にする
+// 環境変数 ADMIN_USERNAMES で最初の管理者を設定するために起動時に呼び出す
+func PromoteAdmins(usernames string) error {
+	var names []string
+	for _, name := range strings.Split(usernames, ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	// パスワードを再度ハッシュ化しないようにフックを実行せずに更新する
+	return DB.Model(&User{}).Where("username IN ?", names).UpdateColumn("is_admin", true).Error
+}
+
 func GenerateToken(username string, password string) (string, error) {
 	var user User
 
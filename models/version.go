@@ -0,0 +1,43 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrVersionConflict 読み込んだ後に他のリクエストで更新されていた場合のエラー
+var ErrVersionConflict = errors.New("version conflict")
+
+// BumpPlanVersion プランのバージョンを1つ進める
+// expected と現在のバージョンが異なる場合は ErrVersionConflict を返す
+// 更新と同じトランザクションの最初に呼び出すことで、同時に更新しようとした他のリクエストを検出できる
+// アイテムだけを変更した場合もプランの更新日時を進める
+func BumpPlanVersion(tx *gorm.DB, planID string, expected int) error {
+	return bumpVersion(tx, &TravelPlan{}, planID, expected, map[string]interface{}{"updated_at": time.Now()})
+}
+
+// BumpItemVersion アイテムのバージョンを1つ進める
+// expected と現在のバージョンが異なる場合は ErrVersionConflict を返す
+func BumpItemVersion(tx *gorm.DB, itemID string, expected int) error {
+	return bumpVersion(tx, &PlanItem{}, itemID, expected, nil)
+}
+
+// bumpVersion バージョンを1つ進め、columns があれば同時に更新する
+func bumpVersion(tx *gorm.DB, model interface{}, id string, expected int, columns map[string]interface{}) error {
+	updates := map[string]interface{}{"version": gorm.Expr("version + 1")}
+	for name, value := range columns {
+		updates[name] = value
+	}
+	result := tx.Model(model).
+		Where("id = ? AND version = ?", id, expected).
+		UpdateColumns(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDistance 既知の2地点間の距離を確認する
+func TestDistance(t *testing.T) {
+	tokyo := Point{Latitude: 35.681236, Longitude: 139.767125}
+	osaka := Point{Latitude: 34.733165, Longitude: 135.500214}
+	assert.InDelta(t, 403000, Distance(tokyo, osaka), 2000)
+	assert.Equal(t, 0.0, Distance(tokyo, tokyo))
+	assert.InDelta(t, Distance(tokyo, osaka), Distance(osaka, tokyo), 1e-6)
+
+	// 日付変更線をまたぐ場合も短い方の距離になる
+	a := Point{Latitude: 0, Longitude: 179.9}
+	b := Point{Latitude: 0, Longitude: -179.9}
+	assert.InDelta(t, 22239, Distance(a, b), 10)
+}
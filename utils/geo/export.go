@@ -0,0 +1,291 @@
+// Package geo は旅程の立ち寄り先を地図アプリやナビゲーションアプリで開ける形式で出力する。
+package geo
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Route 順番に立ち寄る地点の一覧
+type Route struct {
+	Name        string    // ルート名（プラン名）
+	Description string    // 説明
+	Updated     time.Time // 更新日時（ゼロ値の場合は出力しない）
+	Stops       []Stop    // 立ち寄る順に並べた地点
+}
+
+// Stop 立ち寄る地点
+type Stop struct {
+	ID          string    // 地点の識別子（アイテムID）
+	Order       int       // 順序
+	Name        string    // 名前
+	Type        string    // 種類（例："visit"）
+	Address     string    // 住所や場所の名前
+	Description string    // 説明
+	Latitude    float64   // 緯度
+	Longitude   float64   // 経度
+	Start       time.Time // 到着時刻（ゼロ値の場合は出力しない）
+	End         time.Time // 出発時刻（ゼロ値の場合は出力しない）
+	Cost        int       // 費用（円）
+}
+
+// formatCoordinate 緯度・経度を必要な桁数だけの文字列にする
+func formatCoordinate(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// GeoJSON
+
+type geoJSONCollection struct {
+	Type     string           `json:"type"`
+	Name     string           `json:"name,omitempty"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string          `json:"type"`
+	ID         string          `json:"id,omitempty"`
+	Geometry   geoJSONGeometry `json:"geometry"`
+	Properties map[string]any  `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string `json:"type"`
+	Coordinates any    `json:"coordinates"`
+}
+
+// EncodeGeoJSON ルートをGeoJSON（RFC 7946）のFeatureCollectionとして書き込む
+// 各地点をPoint、2地点以上ある場合は地点を順に結んだLineStringをFeatureとして出力する
+func EncodeGeoJSON(w io.Writer, route *Route) error {
+	collection := geoJSONCollection{Type: "FeatureCollection", Name: route.Name, Features: []geoJSONFeature{}}
+
+	var line [][2]float64
+	for _, stop := range route.Stops {
+		// GeoJSONの座標は経度・緯度の順
+		point := [2]float64{stop.Longitude, stop.Latitude}
+		line = append(line, point)
+
+		properties := map[string]any{
+			"kind":  "stop",
+			"order": stop.Order,
+			"name":  stop.Name,
+			"type":  stop.Type,
+			"cost":  stop.Cost,
+		}
+		if stop.Address != "" {
+			properties["address"] = stop.Address
+		}
+		if stop.Description != "" {
+			properties["description"] = stop.Description
+		}
+		if !stop.Start.IsZero() {
+			properties["startTime"] = stop.Start.Format(time.RFC3339)
+		}
+		if !stop.End.IsZero() {
+			properties["endTime"] = stop.End.Format(time.RFC3339)
+		}
+		collection.Features = append(collection.Features, geoJSONFeature{
+			Type:       "Feature",
+			ID:         stop.ID,
+			Geometry:   geoJSONGeometry{Type: "Point", Coordinates: point},
+			Properties: properties,
+		})
+	}
+
+	if len(line) >= 2 {
+		collection.Features = append(collection.Features, geoJSONFeature{
+			Type:       "Feature",
+			Geometry:   geoJSONGeometry{Type: "LineString", Coordinates: line},
+			Properties: map[string]any{"kind": "route", "name": route.Name},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(collection)
+}
+
+// GPX
+
+type gpxDocument struct {
+	XMLName  xml.Name    `xml:"gpx"`
+	Version  string      `xml:"version,attr"`
+	Creator  string      `xml:"creator,attr"`
+	XMLNS    string      `xml:"xmlns,attr"`
+	Metadata gpxMetadata `xml:"metadata"`
+	Points   []gpxPoint  `xml:"wpt"`
+	Routes   []gpxRoute  `xml:"rte"`
+}
+
+type gpxMetadata struct {
+	Name string `xml:"name,omitempty"`
+	Desc string `xml:"desc,omitempty"`
+	Time string `xml:"time,omitempty"`
+}
+
+type gpxPoint struct {
+	Lat  string `xml:"lat,attr"`
+	Lon  string `xml:"lon,attr"`
+	Time string `xml:"time,omitempty"`
+	Name string `xml:"name,omitempty"`
+	Desc string `xml:"desc,omitempty"`
+	Type string `xml:"type,omitempty"`
+}
+
+type gpxRoute struct {
+	Name   string     `xml:"name,omitempty"`
+	Points []gpxPoint `xml:"rtept"`
+}
+
+// EncodeGPX ルートをGPX 1.1として書き込む
+// 各地点をウェイポイント（wpt）として、立ち寄る順序をルート（rte）として出力する
+func EncodeGPX(w io.Writer, route *Route, creator string) error {
+	doc := gpxDocument{
+		Version:  "1.1",
+		Creator:  creator,
+		XMLNS:    "http://www.topografix.com/GPX/1/1",
+		Metadata: gpxMetadata{Name: route.Name, Desc: route.Description},
+	}
+	if !route.Updated.IsZero() {
+		doc.Metadata.Time = route.Updated.UTC().Format(time.RFC3339)
+	}
+
+	var points []gpxPoint
+	for _, stop := range route.Stops {
+		point := gpxPoint{
+			Lat:  formatCoordinate(stop.Latitude),
+			Lon:  formatCoordinate(stop.Longitude),
+			Name: stop.Name,
+			Desc: stopDescription(stop),
+			Type: stop.Type,
+		}
+		if !stop.Start.IsZero() {
+			point.Time = stop.Start.UTC().Format(time.RFC3339)
+		}
+		points = append(points, point)
+	}
+	doc.Points = points
+	if len(points) >= 2 {
+		doc.Routes = []gpxRoute{{Name: route.Name, Points: points}}
+	}
+
+	return encodeXML(w, doc)
+}
+
+// KML
+
+type kmlDocument struct {
+	XMLName  xml.Name `xml:"kml"`
+	XMLNS    string   `xml:"xmlns,attr"`
+	Document kmlBody  `xml:"Document"`
+}
+
+type kmlBody struct {
+	Name       string         `xml:"name,omitempty"`
+	Desc       string         `xml:"description,omitempty"`
+	Placemarks []kmlPlacemark `xml:"Placemark"`
+}
+
+type kmlPlacemark struct {
+	ID         string         `xml:"id,attr,omitempty"`
+	Name       string         `xml:"name,omitempty"`
+	Address    string         `xml:"address,omitempty"`
+	Desc       string         `xml:"description,omitempty"`
+	TimeSpan   *kmlTimeSpan   `xml:"TimeSpan"`
+	Point      *kmlGeometry   `xml:"Point"`
+	LineString *kmlLineString `xml:"LineString"`
+}
+
+type kmlTimeSpan struct {
+	Begin string `xml:"begin,omitempty"`
+	End   string `xml:"end,omitempty"`
+}
+
+type kmlGeometry struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+type kmlLineString struct {
+	Tessellate  int    `xml:"tessellate"`
+	Coordinates string `xml:"coordinates"`
+}
+
+// EncodeKML ルートをKML 2.2として書き込む
+// 各地点をPlacemarkのPointとして、2地点以上ある場合は地点を順に結んだLineStringも出力する
+func EncodeKML(w io.Writer, route *Route) error {
+	doc := kmlDocument{
+		XMLNS:    "http://www.opengis.net/kml/2.2",
+		Document: kmlBody{Name: route.Name, Desc: route.Description},
+	}
+
+	var line []string
+	for _, stop := range route.Stops {
+		// KMLの座標は経度,緯度の順
+		coordinates := formatCoordinate(stop.Longitude) + "," + formatCoordinate(stop.Latitude)
+		line = append(line, coordinates)
+
+		placemark := kmlPlacemark{
+			Name:    stop.Name,
+			Address: stop.Address,
+			Desc:    stopDescription(stop),
+			Point:   &kmlGeometry{Coordinates: coordinates},
+		}
+		// id属性は数字で始められないため接頭辞を付ける
+		if stop.ID != "" {
+			placemark.ID = "stop-" + stop.ID
+		}
+		if !stop.Start.IsZero() || !stop.End.IsZero() {
+			placemark.TimeSpan = &kmlTimeSpan{}
+			if !stop.Start.IsZero() {
+				placemark.TimeSpan.Begin = stop.Start.Format(time.RFC3339)
+			}
+			if !stop.End.IsZero() {
+				placemark.TimeSpan.End = stop.End.Format(time.RFC3339)
+			}
+		}
+		doc.Document.Placemarks = append(doc.Document.Placemarks, placemark)
+	}
+
+	if len(line) >= 2 {
+		doc.Document.Placemarks = append(doc.Document.Placemarks, kmlPlacemark{
+			Name:       route.Name,
+			LineString: &kmlLineString{Tessellate: 1, Coordinates: strings.Join(line, " ")},
+		})
+	}
+
+	return encodeXML(w, doc)
+}
+
+// stopDescription GPX・KMLの説明欄に出力する地点の説明（場所・説明・費用）
+func stopDescription(stop Stop) string {
+	var parts []string
+	if stop.Address != "" {
+		parts = append(parts, stop.Address)
+	}
+	if stop.Description != "" {
+		parts = append(parts, stop.Description)
+	}
+	if stop.Cost > 0 {
+		parts = append(parts, fmt.Sprintf("費用: %d円", stop.Cost))
+	}
+	return strings.Join(parts, "\n")
+}
+
+// encodeXML XML宣言を付けて整形したXMLを書き込む
+func encodeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
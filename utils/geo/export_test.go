@@ -0,0 +1,89 @@
+package geo
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sampleRoute() *Route {
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	return &Route{
+		Name:    "京都1日観光",
+		Updated: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		Stops: []Stop{
+			{ID: "1a", Order: 1, Name: "清水寺", Type: "visit", Address: "京都市東山区", Latitude: 34.994856, Longitude: 135.785046,
+				Start: time.Date(2026, 4, 1, 9, 0, 0, 0, tokyo), End: time.Date(2026, 4, 1, 11, 0, 0, 0, tokyo), Cost: 400},
+			{ID: "2b", Order: 2, Name: "祇園 & 花見小路", Latitude: 35.0037, Longitude: 135.7751},
+		},
+	}
+}
+
+// TestEncodeGeoJSON 地点のPointとルートのLineStringが経度・緯度の順で出力されることを確認する
+func TestEncodeGeoJSON(t *testing.T) {
+	var b strings.Builder
+	assert.NoError(t, EncodeGeoJSON(&b, sampleRoute()))
+
+	var collection struct {
+		Type     string
+		Features []struct {
+			ID       string
+			Geometry struct {
+				Type        string
+				Coordinates json.RawMessage
+			}
+			Properties map[string]any
+		}
+	}
+	assert.NoError(t, json.Unmarshal([]byte(b.String()), &collection))
+	assert.Equal(t, "FeatureCollection", collection.Type)
+	assert.Len(t, collection.Features, 3)
+	assert.Equal(t, "1a", collection.Features[0].ID)
+	assert.JSONEq(t, "[135.785046,34.994856]", string(collection.Features[0].Geometry.Coordinates))
+	assert.Equal(t, "2026-04-01T09:00:00+09:00", collection.Features[0].Properties["startTime"])
+	assert.Equal(t, "LineString", collection.Features[2].Geometry.Type)
+	assert.JSONEq(t, "[[135.785046,34.994856],[135.7751,35.0037]]", string(collection.Features[2].Geometry.Coordinates))
+
+	// 地点がない場合も空のFeatureCollectionを出力する
+	b.Reset()
+	assert.NoError(t, EncodeGeoJSON(&b, &Route{}))
+	assert.Contains(t, b.String(), `"features": []`)
+}
+
+// TestEncodeGPX ウェイポイントとルートが出力され、XMLとして読み込めることを確認する
+func TestEncodeGPX(t *testing.T) {
+	var b strings.Builder
+	assert.NoError(t, EncodeGPX(&b, sampleRoute(), "test"))
+	out := b.String()
+	assert.True(t, strings.HasPrefix(out, xml.Header))
+	assert.Contains(t, out, `<wpt lat="34.994856" lon="135.785046">`)
+	assert.Contains(t, out, "<time>2026-04-01T00:00:00Z</time>")
+	assert.Contains(t, out, "<name>祇園 &amp; 花見小路</name>")
+	assert.Contains(t, out, "<desc>京都市東山区&#xA;費用: 400円</desc>")
+
+	var doc gpxDocument
+	assert.NoError(t, xml.Unmarshal([]byte(out), &doc))
+	assert.Len(t, doc.Points, 2)
+	assert.Len(t, doc.Routes, 1)
+	assert.Len(t, doc.Routes[0].Points, 2)
+}
+
+// TestEncodeKML 地点とルートのPlacemarkが出力されることを確認する
+func TestEncodeKML(t *testing.T) {
+	var b strings.Builder
+	assert.NoError(t, EncodeKML(&b, sampleRoute()))
+	out := b.String()
+	assert.Contains(t, out, `<kml xmlns="http://www.opengis.net/kml/2.2">`)
+	assert.Contains(t, out, `<Placemark id="stop-1a">`)
+	assert.Contains(t, out, "<coordinates>135.785046,34.994856</coordinates>")
+	assert.Contains(t, out, "<begin>2026-04-01T09:00:00+09:00</begin>")
+	assert.Contains(t, out, "<coordinates>135.785046,34.994856 135.7751,35.0037</coordinates>")
+
+	var doc kmlDocument
+	assert.NoError(t, xml.Unmarshal([]byte(out), &doc))
+	assert.Len(t, doc.Document.Placemarks, 3)
+}
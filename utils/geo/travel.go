@@ -0,0 +1,109 @@
+package geo
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Mode 移動手段
+type Mode string
+
+// 移動手段の種類
+const (
+	ModeWalk    Mode = "walk"    // 徒歩
+	ModeBicycle Mode = "bicycle" // 自転車
+	ModeCar     Mode = "car"     // 車・タクシー
+	ModeTransit Mode = "transit" // 電車・バスなどの公共交通機関
+	ModeTrain   Mode = "train"   // 新幹線・特急などの長距離列車
+	ModeFlight  Mode = "flight"  // 飛行機
+	ModeFerry   Mode = "ferry"   // フェリー・船
+)
+
+// Profile 直線距離から移動時間を見積もるための移動手段ごとの設定
+type Profile struct {
+	Speed    float64       // 平均速度（km/h）
+	Detour   float64       // 道のりが直線距離の何倍になるか
+	Overhead time.Duration // 待ち時間や乗り換え・搭乗手続きなど距離に関係なくかかる時間
+}
+
+// DefaultProfiles 移動手段ごとの標準の設定
+var DefaultProfiles = map[Mode]Profile{
+	ModeWalk:    {Speed: 4.5, Detour: 1.3},
+	ModeBicycle: {Speed: 15, Detour: 1.3},
+	ModeCar:     {Speed: 35, Detour: 1.4, Overhead: 5 * time.Minute},
+	ModeTransit: {Speed: 25, Detour: 1.4, Overhead: 10 * time.Minute},
+	ModeTrain:   {Speed: 220, Detour: 1.2, Overhead: 15 * time.Minute},
+	ModeFlight:  {Speed: 650, Detour: 1.1, Overhead: 90 * time.Minute},
+	ModeFerry:   {Speed: 30, Detour: 1.2, Overhead: 30 * time.Minute},
+}
+
+// ValidMode 移動手段として有効な値かどうかを返す
+func ValidMode(mode Mode) bool {
+	_, ok := DefaultProfiles[mode]
+	return ok
+}
+
+// DefaultMode 移動手段の指定がない場合に直線距離から選ぶ移動手段
+// 近ければ徒歩、市内程度なら公共交通機関、遠ければ長距離列車、さらに遠ければ飛行機とする
+func DefaultMode(distance float64) Mode {
+	switch {
+	case distance <= 1500:
+		return ModeWalk
+	case distance <= 60000:
+		return ModeTransit
+	case distance <= 700000:
+		return ModeTrain
+	default:
+		return ModeFlight
+	}
+}
+
+// Estimate 2地点間の移動の見積もり
+type Estimate struct {
+	Mode     Mode          // 移動手段
+	Distance float64       // 移動距離（メートル）
+	Duration time.Duration // 移動時間
+}
+
+// Router 2地点間の移動距離と時間を見積もる
+// 直線距離による見積もりと経路探索サービスを差し替えられるようにする
+type Router interface {
+	// Name 見積もりの提供元の名前
+	Name() string
+	// Estimate 指定した移動手段での from から to までの移動を見積もる
+	Estimate(ctx context.Context, from, to Point, mode Mode) (Estimate, error)
+}
+
+// StraightLineRouter 直線距離と移動手段ごとの平均速度から移動時間を見積もる
+type StraightLineRouter struct {
+	Profiles map[Mode]Profile
+}
+
+// NewStraightLineRouter 標準の設定で直線距離から見積もる Router を作成する
+func NewStraightLineRouter() *StraightLineRouter {
+	return &StraightLineRouter{Profiles: DefaultProfiles}
+}
+
+// Name 見積もりの提供元の名前を返す
+func (r *StraightLineRouter) Name() string {
+	return "straight-line"
+}
+
+// Estimate 直線距離に迂回の係数を掛けた距離を平均速度で移動する時間に、待ち時間などを加えて見積もる
+// 同じ地点の間の移動は0とする
+func (r *StraightLineRouter) Estimate(_ context.Context, from, to Point, mode Mode) (Estimate, error) {
+	profile, ok := r.Profiles[mode]
+	if !ok {
+		return Estimate{}, fmt.Errorf("geo: unknown travel mode %q", mode)
+	}
+
+	distance := Distance(from, to) * profile.Detour
+	if distance == 0 {
+		return Estimate{Mode: mode}, nil
+	}
+	hours := distance / 1000 / profile.Speed
+	duration := time.Duration(math.Round(hours*60)) * time.Minute
+	return Estimate{Mode: mode, Distance: distance, Duration: duration + profile.Overhead}, nil
+}
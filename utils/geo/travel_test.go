@@ -0,0 +1,41 @@
+package geo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestStraightLineRouter 直線距離と移動手段ごとの速度から移動時間を見積もれることを確認する
+func TestStraightLineRouter(t *testing.T) {
+	router := NewStraightLineRouter()
+	kyotoStation := Point{Latitude: 34.985849, Longitude: 135.758767}
+	kiyomizu := Point{Latitude: 34.994856, Longitude: 135.785046}
+	tokyoStation := Point{Latitude: 35.681236, Longitude: 139.767125}
+
+	walk, err := router.Estimate(context.Background(), kyotoStation, kiyomizu, ModeWalk)
+	assert.NoError(t, err)
+	assert.InDelta(t, 2600*1.3, walk.Distance, 100)
+	assert.InDelta(t, 45, walk.Duration.Minutes(), 3)
+
+	train, _ := router.Estimate(context.Background(), kyotoStation, tokyoStation, ModeTrain)
+	assert.InDelta(t, 135, train.Duration.Minutes(), 10)
+
+	same, _ := router.Estimate(context.Background(), kiyomizu, kiyomizu, ModeTransit)
+	assert.Equal(t, time.Duration(0), same.Duration)
+
+	_, err = router.Estimate(context.Background(), kiyomizu, kyotoStation, Mode("teleport"))
+	assert.Error(t, err)
+}
+
+// TestDefaultMode 距離に応じて移動手段を選ぶことを確認する
+func TestDefaultMode(t *testing.T) {
+	assert.Equal(t, ModeWalk, DefaultMode(800))
+	assert.Equal(t, ModeTransit, DefaultMode(12000))
+	assert.Equal(t, ModeTrain, DefaultMode(370000))
+	assert.Equal(t, ModeFlight, DefaultMode(1500000))
+	assert.True(t, ValidMode(ModeFerry))
+	assert.False(t, ValidMode(""))
+}
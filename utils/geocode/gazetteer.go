@@ -0,0 +1,150 @@
+package geocode
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// minContainedName 文字列の一部として一致させる地名の最小文字数（短い地名による誤検出を防ぐ）
+const minContainedName = 2
+
+// Gazetteer ローカルの地名辞書で検索するジオコーダー（テストや外部に接続できない環境向け）
+//
+// 辞書はタブ区切りのテキストで、1行に1か所を次の形式で記述する。空行と # で始まる行は無視する。
+//
+//	名前<TAB>緯度<TAB>経度[<TAB>住所[<TAB>別名|別名...]]
+type Gazetteer struct {
+	entries []Result
+	keys    map[string][]int // 正規化した名前・別名 → entries の位置
+	names   []gazetteerName  // 正規化した名前・別名（長い順）
+}
+
+type gazetteerName struct {
+	key   string
+	index int
+}
+
+// LoadGazetteer 地名辞書を読み込む
+func LoadGazetteer(r io.Reader) (*Gazetteer, error) {
+	g := &Gazetteer{keys: map[string][]int{}}
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, "\t")
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("gazetteer: line %d: expected name, latitude and longitude", line)
+		}
+		lat, err := strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+		if err != nil || lat < -90 || lat > 90 {
+			return nil, fmt.Errorf("gazetteer: line %d: invalid latitude %q", line, fields[1])
+		}
+		lng, err := strconv.ParseFloat(strings.TrimSpace(fields[2]), 64)
+		if err != nil || lng < -180 || lng > 180 {
+			return nil, fmt.Errorf("gazetteer: line %d: invalid longitude %q", line, fields[2])
+		}
+
+		entry := Result{
+			Name:      strings.TrimSpace(fields[0]),
+			Latitude:  lat,
+			Longitude: lng,
+			Source:    "gazetteer",
+		}
+		if len(fields) > 3 {
+			entry.Address = strings.TrimSpace(fields[3])
+		}
+		index := len(g.entries)
+		g.entries = append(g.entries, entry)
+
+		names := []string{entry.Name}
+		if len(fields) > 4 {
+			names = append(names, strings.Split(fields[4], "|")...)
+		}
+		for _, name := range names {
+			key := NormalizeQuery(name)
+			if key == "" || containsIndex(g.keys[key], index) {
+				continue
+			}
+			g.keys[key] = append(g.keys[key], index)
+			g.names = append(g.names, gazetteerName{key, index})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(g.names, func(a, b int) bool {
+		return utf8.RuneCountInString(g.names[a].key) > utf8.RuneCountInString(g.names[b].key)
+	})
+	return g, nil
+}
+
+// OpenGazetteer ファイルから地名辞書を読み込む
+func OpenGazetteer(path string) (*Gazetteer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadGazetteer(f)
+}
+
+// Name プロバイダの名前を返す
+func (g *Gazetteer) Name() string {
+	return "gazetteer"
+}
+
+// Len 辞書に登録された場所の数を返す
+func (g *Gazetteer) Len() int {
+	return len(g.entries)
+}
+
+// Geocode 名前・別名が一致する場所を返す
+// 完全に一致する場所がない場合は、文字列に含まれる地名を長い順に返す（例：「京都市東山区の清水寺」→ 清水寺）
+func (g *Gazetteer) Geocode(_ context.Context, query string) ([]Result, error) {
+	key := NormalizeQuery(query)
+	results := []Result{}
+	if key == "" {
+		return results, nil
+	}
+
+	if indexes, ok := g.keys[key]; ok {
+		for _, i := range indexes {
+			results = append(results, g.entries[i])
+		}
+		return results, nil
+	}
+
+	var seen []int
+	for _, name := range g.names {
+		if utf8.RuneCountInString(name.key) < minContainedName || containsIndex(seen, name.index) {
+			continue
+		}
+		if strings.Contains(key, name.key) {
+			seen = append(seen, name.index)
+			results = append(results, g.entries[name.index])
+		}
+	}
+	return results, nil
+}
+
+func containsIndex(indexes []int, index int) bool {
+	for _, i := range indexes {
+		if i == index {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+// Package geocode は場所の名前や住所（自由入力の文字列）を緯度・経度に変換する。
+// 外部のHTTPサービスとローカルの地名辞書（ガゼティア）を同じインターフェースで差し替えられるようにする。
+package geocode
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Result ジオコーディングの結果の1件
+type Result struct {
+	Name       string  `json:"name"`                 // 場所の名前
+	Address    string  `json:"address"`              // 住所（表示用の全体の名前）
+	Latitude   float64 `json:"latitude"`             // 緯度
+	Longitude  float64 `json:"longitude"`            // 経度
+	Source     string  `json:"source"`               // 結果の提供元（例："osm"、"gazetteer"）
+	ExternalID string  `json:"externalId,omitempty"` // 提供元での場所のID（例："way/123"）
+}
+
+// Geocoder 文字列から場所を検索する
+type Geocoder interface {
+	// Name プロバイダの名前（キャッシュのキーに使う）
+	Name() string
+	// Geocode 文字列に一致する場所を一致度の高い順に返す（見つからない場合は空のスライス）
+	Geocode(ctx context.Context, query string) ([]Result, error)
+}
+
+// NormalizeQuery 検索文字列を比較・キャッシュ用に正規化する（全角英数の半角化、小文字化、空白の統一）
+func NormalizeQuery(query string) string {
+	query = norm.NFKC.String(query)
+	return strings.ToLower(strings.Join(strings.Fields(query), " "))
+}
+
+// Entry キャッシュに保存する検索結果
+type Entry struct {
+	Results []Result  `json:"results"`
+	Stored  time.Time `json:"stored"` // 保存日時
+}
+
+// Cache 検索結果のキャッシュ
+// プロセス内のキャッシュとデータベースのキャッシュを差し替えられるようにする
+type Cache interface {
+	// Get キーに対応する結果を返す（ない場合はfalse）
+	Get(key string) (Entry, bool, error)
+	// Set キーに対応する結果を保存する（同じキーがあれば置き換える）
+	Set(key string, entry Entry) error
+}
+
+// MemoryCache プロセス内のキャッシュ（テストや単一プロセスでの利用向け）
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]Entry
+}
+
+// NewMemoryCache 空のキャッシュを作成する
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: map[string]Entry{}}
+}
+
+// Get キーに対応する結果を返す
+func (m *MemoryCache) Get(key string) (Entry, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[key]
+	return entry, ok, nil
+}
+
+// Set キーに対応する結果を保存する
+func (m *MemoryCache) Set(key string, entry Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = entry
+	return nil
+}
+
+// Cached 検索結果をキャッシュするジオコーダー
+// 見つからなかったことも NegativeTTL の間キャッシュし、同じ文字列で外部サービスを何度も呼ばないようにする
+// キャッシュの読み書きに失敗した場合はキャッシュを使わずに検索する
+type Cached struct {
+	Geocoder    Geocoder
+	Cache       Cache
+	TTL         time.Duration // 結果があった場合の有効期間
+	NegativeTTL time.Duration // 結果がなかった場合の有効期間
+	now         func() time.Time
+}
+
+// NewCached ジオコーダーの検索結果をキャッシュする
+func NewCached(g Geocoder, cache Cache, ttl, negativeTTL time.Duration) *Cached {
+	return &Cached{Geocoder: g, Cache: cache, TTL: ttl, NegativeTTL: negativeTTL, now: time.Now}
+}
+
+// Name 元のジオコーダーの名前を返す
+func (c *Cached) Name() string {
+	return c.Geocoder.Name()
+}
+
+// Geocode キャッシュに有効な結果があればそれを、なければ元のジオコーダーで検索した結果を返す
+func (c *Cached) Geocode(ctx context.Context, query string) ([]Result, error) {
+	normalized := NormalizeQuery(query)
+	if normalized == "" {
+		return []Result{}, nil
+	}
+	key := c.Geocoder.Name() + ":" + normalized
+
+	if entry, ok, err := c.Cache.Get(key); err == nil && ok {
+		ttl := c.TTL
+		if len(entry.Results) == 0 {
+			ttl = c.NegativeTTL
+		}
+		if c.now().Sub(entry.Stored) < ttl {
+			return entry.Results, nil
+		}
+	}
+
+	results, err := c.Geocoder.Geocode(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if results == nil {
+		results = []Result{}
+	}
+	_ = c.Cache.Set(key, Entry{Results: results, Stored: c.now()})
+	return results, nil
+}
@@ -0,0 +1,152 @@
+package geocode
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testGazetteer = `# 名前	緯度	経度	住所	別名
+清水寺	34.994856	135.785046	京都府京都市東山区清水1丁目294	清水の舞台|Kiyomizu-dera
+京都駅	34.985849	135.758767	京都府京都市下京区	Kyoto Station
+東京タワー	35.658581	139.745433	東京都港区芝公園4丁目2-8	Tokyo Tower
+
+京	35.0	135.7
+`
+
+// TestGazetteer 名前・別名の完全一致と、文字列に含まれる地名で検索できることを確認する
+func TestGazetteer(t *testing.T) {
+	g, err := LoadGazetteer(strings.NewReader(testGazetteer))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 4, g.Len())
+
+	results, err := g.Geocode(context.Background(), "  ＴＯＫＹＯ　tower ")
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, "東京タワー", results[0].Name)
+		assert.Equal(t, "東京都港区芝公園4丁目2-8", results[0].Address)
+		assert.Equal(t, 35.658581, results[0].Latitude)
+		assert.Equal(t, "gazetteer", results[0].Source)
+	}
+
+	// 文字列に含まれる地名を長い順に返す（1文字の地名は部分一致させない）
+	results, _ = g.Geocode(context.Background(), "京都駅から清水の舞台へ")
+	var names []string
+	for _, r := range results {
+		names = append(names, r.Name)
+	}
+	assert.Equal(t, []string{"清水寺", "京都駅"}, names)
+
+	results, _ = g.Geocode(context.Background(), "大阪城")
+	assert.Empty(t, results)
+	assert.NotNil(t, results)
+}
+
+// TestLoadGazetteerInvalid 緯度・経度が正しくない行をエラーにすることを確認する
+func TestLoadGazetteerInvalid(t *testing.T) {
+	_, err := LoadGazetteer(strings.NewReader("清水寺\t34.99"))
+	assert.ErrorContains(t, err, "line 1")
+
+	_, err = LoadGazetteer(strings.NewReader("# comment\n清水寺\t134.99\t135.78"))
+	assert.ErrorContains(t, err, "line 2: invalid latitude")
+}
+
+// TestNominatim 検索APIのリクエストとレスポンスの変換を確認する
+func TestNominatim(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/search", r.URL.Path)
+		assert.Equal(t, "清水寺", r.URL.Query().Get("q"))
+		assert.Equal(t, "jsonv2", r.URL.Query().Get("format"))
+		assert.Equal(t, "test-agent", r.Header.Get("User-Agent"))
+		assert.Equal(t, "ja", r.Header.Get("Accept-Language"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[
+			{"osm_type":"way","osm_id":123,"lat":"34.9948","lon":"135.7850","name":"清水寺","display_name":"清水寺, 清水1丁目, 東山区, 京都市"},
+			{"osm_type":"node","osm_id":456,"lat":"34.98","lon":"135.78","name":"","display_name":"清水寺前, 京都市"},
+			{"osm_type":"node","osm_id":789,"lat":"invalid","lon":"135.78","name":"x","display_name":"x"}
+		]`))
+	}))
+	defer server.Close()
+
+	n := NewNominatim(server.URL+"/", "test-agent")
+	n.Interval = 0
+	results, err := n.Geocode(context.Background(), "清水寺")
+	if !assert.NoError(t, err) || !assert.Len(t, results, 2) {
+		return
+	}
+	assert.Equal(t, Result{
+		Name:       "清水寺",
+		Address:    "清水寺, 清水1丁目, 東山区, 京都市",
+		Latitude:   34.9948,
+		Longitude:  135.7850,
+		Source:     "osm",
+		ExternalID: "way/123",
+	}, results[0])
+	assert.Equal(t, "清水寺前", results[1].Name)
+}
+
+// TestNominatimError エラーのステータスをエラーとして返すことを確認する
+func TestNominatimError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	n := NewNominatim(server.URL, "test-agent")
+	n.Interval = 0
+	_, err := n.Geocode(context.Background(), "清水寺")
+	assert.ErrorContains(t, err, "429")
+}
+
+// countingGeocoder 検索した回数を数えるジオコーダー
+type countingGeocoder struct {
+	calls   int
+	results map[string][]Result
+}
+
+func (g *countingGeocoder) Name() string { return "counting" }
+
+func (g *countingGeocoder) Geocode(_ context.Context, query string) ([]Result, error) {
+	g.calls++
+	return g.results[query], nil
+}
+
+// TestCached 結果と見つからなかったことをそれぞれの有効期間だけキャッシュすることを確認する
+func TestCached(t *testing.T) {
+	inner := &countingGeocoder{results: map[string][]Result{"清水寺": {{Name: "清水寺", Latitude: 34.99, Longitude: 135.78}}}}
+	cache := NewMemoryCache()
+	cached := NewCached(inner, cache, 24*time.Hour, time.Hour)
+	now := time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC)
+	cached.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	results, _ := cached.Geocode(ctx, "清水寺")
+	assert.Len(t, results, 1)
+	// 正規化した文字列が同じであればキャッシュを使う
+	results, _ = cached.Geocode(ctx, " 清水寺 ")
+	assert.Len(t, results, 1)
+	assert.Equal(t, 1, inner.calls)
+
+	results, _ = cached.Geocode(ctx, "大阪城")
+	assert.Empty(t, results)
+	assert.NotNil(t, results)
+	_, _ = cached.Geocode(ctx, "大阪城")
+	assert.Equal(t, 2, inner.calls)
+
+	// 見つからなかった結果だけ期限が切れる
+	now = now.Add(2 * time.Hour)
+	_, _ = cached.Geocode(ctx, "清水寺")
+	_, _ = cached.Geocode(ctx, "大阪城")
+	assert.Equal(t, 3, inner.calls)
+
+	entry, ok, _ := cache.Get("counting:大阪城")
+	assert.True(t, ok)
+	assert.Equal(t, now, entry.Stored)
+}
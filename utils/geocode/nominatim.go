@@ -0,0 +1,144 @@
+package geocode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultNominatimURL OpenStreetMapの公式のNominatimサーバー
+	DefaultNominatimURL = "https://nominatim.openstreetmap.org"
+	// nominatimInterval 公式サーバーの利用規約で定められたリクエストの最小間隔
+	nominatimInterval = time.Second
+	nominatimTimeout  = 10 * time.Second
+	nominatimLimit    = 5
+)
+
+// Nominatim Nominatim互換のHTTP APIで検索するジオコーダー
+// 利用規約に従い、アプリケーションを識別できる User-Agent を送り、リクエストの間隔を空ける
+type Nominatim struct {
+	BaseURL   string        // APIのURL（例："https://nominatim.openstreetmap.org"）
+	UserAgent string        // 送信する User-Agent
+	Email     string        // 大量のリクエストを送る場合の連絡先（省略可）
+	Language  string        // 結果の言語（Accept-Language）
+	Interval  time.Duration // リクエストの最小間隔
+	Client    *http.Client
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+// NewNominatim Nominatim互換のAPIを使うジオコーダーを作成する（baseURL が空の場合は公式サーバー）
+func NewNominatim(baseURL, userAgent string) *Nominatim {
+	if baseURL == "" {
+		baseURL = DefaultNominatimURL
+	}
+	return &Nominatim{
+		BaseURL:   strings.TrimRight(baseURL, "/"),
+		UserAgent: userAgent,
+		Language:  "ja",
+		Interval:  nominatimInterval,
+		Client:    &http.Client{Timeout: nominatimTimeout},
+	}
+}
+
+// Name プロバイダの名前を返す
+func (n *Nominatim) Name() string {
+	return "nominatim"
+}
+
+// nominatimPlace 検索APIのレスポンス（format=jsonv2）の1件
+type nominatimPlace struct {
+	OSMType     string `json:"osm_type"`
+	OSMID       int64  `json:"osm_id"`
+	Lat         string `json:"lat"`
+	Lon         string `json:"lon"`
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+// Geocode 検索APIで文字列に一致する場所を検索する
+func (n *Nominatim) Geocode(ctx context.Context, query string) ([]Result, error) {
+	params := url.Values{
+		"q":      {query},
+		"format": {"jsonv2"},
+		"limit":  {strconv.Itoa(nominatimLimit)},
+	}
+	if n.Email != "" {
+		params.Set("email", n.Email)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, n.BaseURL+"/search?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", n.UserAgent)
+	if n.Language != "" {
+		req.Header.Set("Accept-Language", n.Language)
+	}
+
+	if err := n.wait(ctx); err != nil {
+		return nil, err
+	}
+	res, err := n.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("nominatim: unexpected status %s", res.Status)
+	}
+
+	var places []nominatimPlace
+	if err := json.NewDecoder(res.Body).Decode(&places); err != nil {
+		return nil, fmt.Errorf("nominatim: %w", err)
+	}
+
+	results := []Result{}
+	for _, p := range places {
+		lat, err1 := strconv.ParseFloat(p.Lat, 64)
+		lon, err2 := strconv.ParseFloat(p.Lon, 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		result := Result{
+			Name:      p.Name,
+			Address:   p.DisplayName,
+			Latitude:  lat,
+			Longitude: lon,
+			Source:    "osm",
+		}
+		if result.Name == "" {
+			result.Name, _, _ = strings.Cut(p.DisplayName, ",")
+		}
+		if p.OSMType != "" && p.OSMID != 0 {
+			result.ExternalID = fmt.Sprintf("%s/%d", p.OSMType, p.OSMID)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// wait 前回のリクエストから Interval が経過するまで待つ
+func (n *Nominatim) wait(ctx context.Context) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if delay := n.Interval - time.Since(n.last); delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	n.last = time.Now()
+	return nil
+}
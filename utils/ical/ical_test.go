@@ -0,0 +1,228 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFold 75オクテットを超える行がマルチバイト文字の途中で折り返されないことを確認する
+func TestFold(t *testing.T) {
+	assert.Equal(t, "SUMMARY:short", Fold("SUMMARY:short"))
+
+	folded := Fold("DESCRIPTION:" + strings.Repeat("清水寺", 20))
+	lines := strings.Split(folded, "\r\n")
+	assert.Greater(t, len(lines), 1)
+	for i, line := range lines {
+		assert.LessOrEqual(t, len(line), 75)
+		if i > 0 {
+			assert.True(t, strings.HasPrefix(line, " "))
+		}
+	}
+	assert.Equal(t, "DESCRIPTION:"+strings.Repeat("清水寺", 20), strings.ReplaceAll(folded, "\r\n ", ""))
+}
+
+// TestEscape テキストの特殊文字がエスケープされることを確認する
+func TestEscape(t *testing.T) {
+	assert.Equal(t, `京都\, 清水寺\; 集合\n9時 \\`, Escape("京都, 清水寺; 集合\r\n9時 \\"))
+}
+
+// TestEncode 予定がタイムゾーン付きで出力され、夏時間の切り替えがVTIMEZONEに含まれることを確認する
+func TestEncode(t *testing.T) {
+	newYork, _ := time.LoadLocation("America/New_York")
+	cal := Calendar{
+		ProdID:   "-//test//EN",
+		Name:     "ニューヨーク旅行",
+		TimeZone: "America/New_York",
+		Events: []Event{
+			{
+				UID:      "item-1@test",
+				Summary:  "自由の女神",
+				Location: "Liberty Island, New York",
+				Start:    time.Date(2026, 10, 31, 9, 0, 0, 0, newYork),
+				End:      time.Date(2026, 10, 31, 12, 0, 0, 0, newYork),
+				Stamp:    time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+			},
+			{
+				UID:     "item-2@test",
+				Summary: "帰国便",
+				Start:   time.Date(2026, 11, 2, 3, 0, 0, 0, time.UTC),
+				Stamp:   time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	var b strings.Builder
+	assert.NoError(t, cal.Encode(&b))
+	out := b.String()
+
+	assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(out, "END:VCALENDAR\r\n"))
+	assert.Contains(t, out, "DTSTART;TZID=America/New_York:20261031T090000\r\n")
+	assert.Contains(t, out, "DTSTAMP:20261001T000000Z\r\n")
+	assert.Contains(t, out, "DTSTART:20261102T030000Z\r\n")
+	assert.Contains(t, out, `LOCATION:Liberty Island\, New York`)
+	assert.Equal(t, 1, strings.Count(out, "BEGIN:VTIMEZONE"))
+
+	// 2026/11/1 2:00 (EDT) に標準時へ切り替わる
+	assert.Contains(t, out, "BEGIN:STANDARD\r\nDTSTART:20261101T020000\r\nTZOFFSETFROM:-0400\r\nTZOFFSETTO:-0500\r\nTZNAME:EST\r\n")
+	assert.Contains(t, out, "BEGIN:DAYLIGHT\r\nDTSTART:20260308T020000\r\nTZOFFSETFROM:-0500\r\nTZOFFSETTO:-0400\r\nTZNAME:EDT\r\n")
+}
+
+// TestTimeZoneWithoutTransitions 夏時間のないタイムゾーンでも時差の定義が出力されることを確認する
+func TestTimeZoneWithoutTransitions(t *testing.T) {
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	cal := Calendar{ProdID: "-//test//EN", Events: []Event{{
+		UID:   "item@test",
+		Start: time.Date(2026, 4, 1, 9, 0, 0, 0, tokyo),
+		End:   time.Date(2026, 4, 1, 10, 0, 0, 0, tokyo),
+	}}}
+
+	var b strings.Builder
+	assert.NoError(t, cal.Encode(&b))
+	assert.Contains(t, b.String(), "TZID:Asia/Tokyo\r\nBEGIN:STANDARD\r\n")
+	assert.Contains(t, b.String(), "TZOFFSETFROM:+0900\r\nTZOFFSETTO:+0900\r\nTZNAME:JST\r\n")
+	assert.Equal(t, 1, strings.Count(b.String(), "BEGIN:STANDARD"))
+}
+
+const sampleCalendar = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"PRODID:-//test//EN\r\n" +
+	"X-WR-CALNAME:京都旅行\r\n" +
+	"X-WR-TIMEZONE:Asia/Tokyo\r\n" +
+	"BEGIN:VTIMEZONE\r\n" +
+	"TZID:Tokyo Standard Time\r\n" +
+	"BEGIN:STANDARD\r\n" +
+	"DTSTART:16010101T000000\r\n" +
+	"TZOFFSETFROM:+0900\r\n" +
+	"TZOFFSETTO:+0900\r\n" +
+	"END:STANDARD\r\n" +
+	"END:VTIMEZONE\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:flight@test\r\n" +
+	"SUMMARY:羽田→伊丹 JAL123便\r\n" +
+	"DTSTART:20260401T000000Z\r\n" +
+	"DURATION:PT1H10M\r\n" +
+	"CATEGORIES:Travel,Flight\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:temple@test\r\n" +
+	"SUMMARY:清水寺\r\n" +
+	"LOCATION:京都市東山区清水1-294\\, 清水寺\r\n" +
+	"DESCRIPTION:本堂を見学\\n混雑に注意。とても長い説明文を折り返して送ってくるカレンダーア\r\n" +
+	" プリもある\r\n" +
+	"DTSTART;TZID=Tokyo Standard Time:20260401T140000\r\n" +
+	"DTEND;TZID=Tokyo Standard Time:20260401T160000\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"ACTION:DISPLAY\r\n" +
+	"TRIGGER:-PT15M\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:dinner@test\r\n" +
+	"SUMMARY:夕食\r\n" +
+	"DTSTART;TZID=Asia/Tokyo:20260401T190000\r\n" +
+	"DTEND;TZID=Asia/Tokyo:20260401T203000\r\n" +
+	"RRULE:FREQ=DAILY;COUNT=2\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:hotel@test\r\n" +
+	"SUMMARY:ホテル\r\n" +
+	"DTSTART;VALUE=DATE:20260401\r\n" +
+	"DTEND;VALUE=DATE:20260402\r\n" +
+	"STATUS:CANCELLED\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:floating@test\r\n" +
+	"SUMMARY:散策\r\n" +
+	"DTSTART:20260402T100000\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+// TestParse 予定の日時・タイムゾーン・テキストが読み込まれることを確認する
+func TestParse(t *testing.T) {
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	cal, err := Parse(strings.NewReader(sampleCalendar), tokyo)
+	assert.NoError(t, err)
+	assert.Equal(t, "京都旅行", cal.Name)
+	assert.Equal(t, "Asia/Tokyo", cal.TimeZone)
+	assert.Len(t, cal.Events, 5)
+
+	flight := cal.Events[0]
+	assert.Equal(t, "flight@test", flight.UID)
+	assert.Equal(t, []string{"Travel", "Flight"}, flight.Categories)
+	assert.True(t, flight.Start.Equal(time.Date(2026, 4, 1, 9, 0, 0, 0, tokyo)))
+	assert.Equal(t, 70*time.Minute, flight.End.Sub(flight.Start))
+
+	// Windowsのタイムゾーン名はVTIMEZONEの時差から推定する
+	temple := cal.Events[1]
+	assert.Equal(t, "京都市東山区清水1-294, 清水寺", temple.Location)
+	assert.Equal(t, "本堂を見学\n混雑に注意。とても長い説明文を折り返して送ってくるカレンダーアプリもある", temple.Description)
+	assert.True(t, temple.Start.Equal(time.Date(2026, 4, 1, 14, 0, 0, 0, tokyo)))
+	assert.Len(t, temple.Warnings, 2)
+
+	dinner := cal.Events[2]
+	assert.True(t, dinner.Recurring)
+	assert.Equal(t, "Asia/Tokyo", dinner.Start.Location().String())
+
+	hotel := cal.Events[3]
+	assert.True(t, hotel.AllDay)
+	assert.True(t, hotel.Cancelled)
+
+	// タイムゾーンの指定がない日時は指定したタイムゾーンで読み込む
+	floating := cal.Events[4]
+	assert.Equal(t, time.Date(2026, 4, 2, 10, 0, 0, 0, tokyo), floating.Start)
+	assert.Equal(t, floating.Start, floating.End)
+}
+
+// TestParseInvalid カレンダーでない入力はエラーになることを確認する
+func TestParseInvalid(t *testing.T) {
+	_, err := Parse(strings.NewReader("hello"), time.UTC)
+	assert.Error(t, err)
+	_, err = Parse(strings.NewReader("BEGIN:VEVENT\r\nEND:VEVENT\r\n"), time.UTC)
+	assert.ErrorIs(t, err, ErrNotCalendar)
+	_, err = Parse(strings.NewReader("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nEND:VCALENDAR\r\n"), time.UTC)
+	assert.Error(t, err)
+}
+
+// TestParseDuration RFC 5545の期間を読み込めることを確認する
+func TestParseDuration(t *testing.T) {
+	for s, want := range map[string]time.Duration{
+		"PT1H30M": 90 * time.Minute,
+		"P1D":     24 * time.Hour,
+		"P1W":     7 * 24 * time.Hour,
+		"-PT15M":  -15 * time.Minute,
+		"P1DT2H":  26 * time.Hour,
+	} {
+		d, err := ParseDuration(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, want, d, s)
+	}
+	for _, s := range []string{"P", "PT", "1H", "P1H"} {
+		_, err := ParseDuration(s)
+		assert.Error(t, err, s)
+	}
+}
+
+// TestRoundTrip 出力したカレンダーを読み込むと同じ予定になることを確認する
+func TestRoundTrip(t *testing.T) {
+	newYork, _ := time.LoadLocation("America/New_York")
+	cal := Calendar{ProdID: "-//test//EN", Events: []Event{{
+		UID:         "item@test",
+		Summary:     "ブロードウェイ; ミュージカル, 夜の部",
+		Description: strings.Repeat("説明", 50),
+		Start:       time.Date(2026, 11, 1, 19, 0, 0, 0, newYork),
+		End:         time.Date(2026, 11, 1, 22, 0, 0, 0, newYork),
+	}}}
+	var b strings.Builder
+	assert.NoError(t, cal.Encode(&b))
+
+	parsed, err := Parse(strings.NewReader(b.String()), time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, cal.Events[0].Summary, parsed.Events[0].Summary)
+	assert.Equal(t, cal.Events[0].Description, parsed.Events[0].Description)
+	assert.True(t, cal.Events[0].Start.Equal(parsed.Events[0].Start))
+	assert.True(t, cal.Events[0].End.Equal(parsed.Events[0].End))
+}
@@ -0,0 +1,462 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotCalendar 入力がVCALENDARではない
+var ErrNotCalendar = errors.New("ical: not a VCALENDAR")
+
+// ParsedCalendar 読み込んだカレンダー
+type ParsedCalendar struct {
+	Name     string        // カレンダー名（X-WR-CALNAME）
+	TimeZone string        // カレンダーの既定のタイムゾーン（X-WR-TIMEZONE）
+	Events   []ParsedEvent // 予定（ファイル内の順）
+}
+
+// ParsedEvent 読み込んだ予定
+type ParsedEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	Categories  []string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool     // 終日の予定（日付のみ）
+	Recurring   bool     // 繰り返しの予定（RRULE・RDATE）
+	Cancelled   bool     // 中止された予定（STATUS:CANCELLED）
+	Warnings    []string // 読み込み時に推定した内容など、確認が必要な点
+	Line        int      // BEGIN:VEVENT の行番号（1始まり）
+}
+
+// property 1行分のプロパティ
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// component BEGIN〜ENDで囲まれたコンポーネント
+type component struct {
+	name       string
+	line       int
+	props      []property
+	components []*component
+}
+
+func (c *component) get(name string) (property, bool) {
+	for _, p := range c.props {
+		if p.name == name {
+			return p, true
+		}
+	}
+	return property{}, false
+}
+
+func (c *component) all(name string) []property {
+	var result []property
+	for _, p := range c.props {
+		if p.name == name {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// Parse iCalendar形式のカレンダーを読み込む
+// タイムゾーンの指定がない日時（フローティング）は floating のタイムゾーンの日時として扱う
+func Parse(r io.Reader, floating *time.Location) (*ParsedCalendar, error) {
+	root, err := parseComponents(r)
+	if err != nil {
+		return nil, err
+	}
+
+	cal := &ParsedCalendar{}
+	if p, ok := root.get("X-WR-CALNAME"); ok {
+		cal.Name = Unescape(p.value)
+	}
+	if p, ok := root.get("X-WR-TIMEZONE"); ok {
+		cal.TimeZone = p.value
+	}
+
+	zones := map[string]*component{}
+	for _, sub := range root.components {
+		if sub.name == "VTIMEZONE" {
+			if id, ok := sub.get("TZID"); ok {
+				zones[id.value] = sub
+			}
+		}
+	}
+
+	for _, sub := range root.components {
+		if sub.name == "VEVENT" {
+			cal.Events = append(cal.Events, parseEvent(sub, zones, floating))
+		}
+	}
+	return cal, nil
+}
+
+// parseComponents 行を読み込み、VCALENDARのコンポーネントのツリーを作る
+func parseComponents(r io.Reader) (*component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var stack []*component
+	var root *component
+	for _, l := range lines {
+		p, err := parseProperty(l.text)
+		if err != nil {
+			return nil, fmt.Errorf("ical: line %d: %w", l.number, err)
+		}
+		switch p.name {
+		case "BEGIN":
+			c := &component{name: strings.ToUpper(p.value), line: l.number}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.components = append(parent.components, c)
+			} else if c.name != "VCALENDAR" {
+				return nil, ErrNotCalendar
+			}
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].name != strings.ToUpper(p.value) {
+				return nil, fmt.Errorf("ical: line %d: unexpected END:%s", l.number, p.value)
+			}
+			c := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 && root == nil {
+				root = c
+			}
+		default:
+			if len(stack) == 0 {
+				return nil, ErrNotCalendar
+			}
+			c := stack[len(stack)-1]
+			c.props = append(c.props, p)
+		}
+	}
+	if root == nil || len(stack) > 0 {
+		return nil, ErrNotCalendar
+	}
+	return root, nil
+}
+
+type rawLine struct {
+	number int
+	text   string
+}
+
+// unfold 折り返された行を元に戻す（CRLFとLFの両方に対応する）
+func unfold(r io.Reader) ([]rawLine, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var lines []rawLine
+	number := 0
+	for scanner.Scan() {
+		number++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if number == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		if (strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1].text += text[1:]
+			continue
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		lines = append(lines, rawLine{number: number, text: text})
+	}
+	return lines, scanner.Err()
+}
+
+// parseProperty "NAME;PARAM=VALUE:value" の形式の行を分解する
+func parseProperty(line string) (property, error) {
+	p := property{params: map[string]string{}}
+
+	// 値の区切りのコロンを探す（引用符で囲まれたパラメータ内のコロンは除く）
+	inQuote := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuote = !inQuote
+		} else if r == ':' && !inQuote {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return p, errors.New("missing ':'")
+	}
+	p.value = line[colon+1:]
+
+	parts := splitParams(line[:colon])
+	p.name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		p.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+	return p, nil
+}
+
+// splitParams セミコロンで区切る（引用符で囲まれた部分は区切らない）
+func splitParams(s string) []string {
+	var parts []string
+	inQuote := false
+	start := 0
+	for i, r := range s {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+		case r == ';' && !inQuote:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// parseEvent VEVENTを予定に変換する
+func parseEvent(c *component, zones map[string]*component, floating *time.Location) ParsedEvent {
+	e := ParsedEvent{Line: c.line}
+	text := func(name string) string {
+		if p, ok := c.get(name); ok {
+			return strings.TrimSpace(Unescape(p.value))
+		}
+		return ""
+	}
+	e.UID = text("UID")
+	e.Summary = text("SUMMARY")
+	e.Description = text("DESCRIPTION")
+	e.Location = text("LOCATION")
+	for _, p := range c.all("CATEGORIES") {
+		for _, category := range splitList(p.value) {
+			if category = strings.TrimSpace(Unescape(category)); category != "" {
+				e.Categories = append(e.Categories, category)
+			}
+		}
+	}
+	_, rrule := c.get("RRULE")
+	_, rdate := c.get("RDATE")
+	e.Recurring = rrule || rdate
+	if p, ok := c.get("STATUS"); ok && strings.EqualFold(p.value, "CANCELLED") {
+		e.Cancelled = true
+	}
+
+	start, ok := c.get("DTSTART")
+	if !ok {
+		e.Warnings = append(e.Warnings, "開始日時（DTSTART）がありません")
+		return e
+	}
+	var warning string
+	e.Start, e.AllDay, warning = parseDateTime(start, zones, floating)
+	if warning != "" {
+		e.Warnings = append(e.Warnings, warning)
+	}
+
+	if end, ok := c.get("DTEND"); ok {
+		e.End, _, warning = parseDateTime(end, zones, floating)
+		if warning != "" {
+			e.Warnings = append(e.Warnings, warning)
+		}
+	} else if p, ok := c.get("DURATION"); ok && !e.Start.IsZero() {
+		d, err := ParseDuration(p.value)
+		if err != nil {
+			e.Warnings = append(e.Warnings, "所要時間（DURATION）を読み込めません")
+		}
+		e.End = e.Start.Add(d)
+	} else if e.AllDay {
+		e.End = e.Start.AddDate(0, 0, 1)
+	} else {
+		e.End = e.Start
+	}
+	if e.End.Before(e.Start) {
+		e.Warnings = append(e.Warnings, "終了日時が開始日時より前のため、開始日時に揃えました")
+		e.End = e.Start
+	}
+	return e
+}
+
+// parseDateTime DATEまたはDATE-TIMEの値を読み込む
+// TZIDがIANAのタイムゾーン名でない場合はVTIMEZONEの時差から推定し、その旨を返す
+func parseDateTime(p property, zones map[string]*component, floating *time.Location) (time.Time, bool, string) {
+	value := strings.TrimSpace(p.value)
+	if strings.EqualFold(p.params["VALUE"], "DATE") || len(value) == len(dateLayout) {
+		t, err := time.ParseInLocation(dateLayout, value, floating)
+		if err != nil {
+			return time.Time{}, true, fmt.Sprintf("日付 %q を読み込めません", value)
+		}
+		return t, true, ""
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(dateTimeLayout+"Z", value)
+		if err != nil {
+			return time.Time{}, false, fmt.Sprintf("日時 %q を読み込めません", value)
+		}
+		return t, false, ""
+	}
+
+	loc, warning := floating, ""
+	if tzid := p.params["TZID"]; tzid != "" {
+		loc, warning = resolveTZID(tzid, zones, value, floating)
+	}
+	t, err := time.ParseInLocation(dateTimeLayout, value, loc)
+	if err != nil {
+		return time.Time{}, false, fmt.Sprintf("日時 %q を読み込めません", value)
+	}
+	return t, false, warning
+}
+
+// resolveTZID TZIDをロケーションに変換する
+func resolveTZID(tzid string, zones map[string]*component, value string, fallback *time.Location) (*time.Location, string) {
+	if loc, err := time.LoadLocation(strings.TrimPrefix(tzid, "/")); err == nil && tzid != "Local" {
+		return loc, ""
+	}
+
+	// Outlookの "Tokyo Standard Time" のような名前はVTIMEZONEの時差を使う
+	if zone, ok := zones[tzid]; ok {
+		if offset, ok := observanceOffset(zone, value); ok {
+			return time.FixedZone(tzid, offset), fmt.Sprintf("タイムゾーン %q をUTCからの時差で推定しました", tzid)
+		}
+	}
+	return fallback, fmt.Sprintf("タイムゾーン %q が不明なため %s として読み込みました", tzid, fallback)
+}
+
+// observanceOffset VTIMEZONEの定義のうち、指定した現地時刻より前に始まった最も新しい定義の時差を返す
+// 繰り返しの規則（RRULE）は解釈せず、各定義の開始日時の月日が毎年繰り返されるものとみなす
+func observanceOffset(zone *component, value string) (int, bool) {
+	local, err := time.Parse(dateTimeLayout, value)
+	if err != nil {
+		return 0, false
+	}
+
+	best, bestAt, found := 0, time.Time{}, false
+	for _, obs := range zone.components {
+		start, ok1 := obs.get("DTSTART")
+		to, ok2 := obs.get("TZOFFSETTO")
+		if !ok1 || !ok2 {
+			continue
+		}
+		t, err := time.Parse(dateTimeLayout, start.value)
+		if err != nil {
+			continue
+		}
+		offset, err := parseOffset(to.value)
+		if err != nil {
+			continue
+		}
+		if _, yearly := obs.get("RRULE"); yearly && t.Before(local) {
+			// 対象の年（それより後なら前年）の同じ月日に移す
+			t = time.Date(local.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+			if t.After(local) {
+				t = t.AddDate(-1, 0, 0)
+			}
+		}
+		if t.After(local) {
+			continue
+		}
+		if !found || t.After(bestAt) {
+			best, bestAt, found = offset, t, true
+		}
+	}
+	return best, found
+}
+
+// parseOffset "+0900" の形式の時差を秒に変換する
+func parseOffset(s string) (int, error) {
+	if len(s) != 5 && len(s) != 7 {
+		return 0, fmt.Errorf("invalid offset %q", s)
+	}
+	sign := 1
+	switch s[0] {
+	case '+':
+	case '-':
+		sign = -1
+	default:
+		return 0, fmt.Errorf("invalid offset %q", s)
+	}
+	hours, err1 := strconv.Atoi(s[1:3])
+	minutes, err2 := strconv.Atoi(s[3:5])
+	seconds := 0
+	var err3 error
+	if len(s) == 7 {
+		seconds, err3 = strconv.Atoi(s[5:7])
+	}
+	if err1 != nil || err2 != nil || err3 != nil {
+		return 0, fmt.Errorf("invalid offset %q", s)
+	}
+	return sign * (hours*3600 + minutes*60 + seconds), nil
+}
+
+var durationPattern = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// ParseDuration "PT1H30M" のようなRFC 5545の期間を読み込む
+func ParseDuration(s string) (time.Duration, error) {
+	m := durationPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil || s == "P" || strings.HasSuffix(s, "T") {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+2] == "" {
+			continue
+		}
+		n, _ := strconv.Atoi(m[i+2])
+		d += time.Duration(n) * unit
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
+
+// splitList カンマ区切りの値を分割する（エスケープされたカンマは区切らない）
+func splitList(s string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case ',':
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// Unescape テキストの値のエスケープを元に戻す
+func Unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,103 @@
+package ical
+
+import (
+	"fmt"
+	"time"
+)
+
+// zone 予定で使われているタイムゾーンと、その予定の期間
+type zone struct {
+	loc      *time.Location
+	from, to time.Time
+}
+
+// transition タイムゾーンのUTCからの時差の切り替え
+type transition struct {
+	at         time.Time // 切り替わる時刻
+	offsetFrom int       // 切り替え前の時差（秒）
+	offsetTo   int       // 切り替え後の時差（秒）
+	name       string    // 切り替え後の略称（例："JST"、"EDT"）
+	isDST      bool      // 切り替え後が夏時間かどうか
+}
+
+// encode VTIMEZONEを書き込む
+// Goのロケーションからは規則を取り出せないため、予定の期間の前後1年の切り替えを列挙して出力する
+func (z *zone) encode(lw *lineWriter) {
+	from := z.from.AddDate(-1, 0, 0)
+	to := z.to.AddDate(1, 0, 0)
+
+	lw.line("BEGIN:VTIMEZONE")
+	lw.line("TZID:" + z.loc.String())
+
+	// 期間の開始時点の時差を最初の定義とし、その後の切り替えを続ける
+	name, offset := from.In(z.loc).Zone()
+	encodeObservance(lw, transition{at: from.Truncate(time.Hour), offsetFrom: offset, offsetTo: offset,
+		name: name, isDST: from.In(z.loc).IsDST()})
+	for _, tr := range transitions(z.loc, from, to) {
+		encodeObservance(lw, tr)
+	}
+
+	lw.line("END:VTIMEZONE")
+}
+
+// encodeObservance 切り替え1回分のSTANDARDまたはDAYLIGHTを書き込む
+// DTSTARTは切り替え前の時差での現地時刻で表す
+func encodeObservance(lw *lineWriter, tr transition) {
+	kind := "STANDARD"
+	if tr.isDST {
+		kind = "DAYLIGHT"
+	}
+	lw.line("BEGIN:" + kind)
+	lw.line("DTSTART:" + tr.at.In(time.FixedZone("", tr.offsetFrom)).Format(dateTimeLayout))
+	lw.line("TZOFFSETFROM:" + formatOffset(tr.offsetFrom))
+	lw.line("TZOFFSETTO:" + formatOffset(tr.offsetTo))
+	if tr.name != "" {
+		lw.line("TZNAME:" + Escape(tr.name))
+	}
+	lw.line("END:" + kind)
+}
+
+// transitions 指定した期間内のタイムゾーンの時差の切り替えを古い順に返す
+func transitions(loc *time.Location, from, to time.Time) []transition {
+	var result []transition
+
+	// 1日ごとに時差を比べ、変わっていれば二分探索で切り替わった秒を求める
+	prev := from
+	_, prevOffset := prev.In(loc).Zone()
+	for t := from.Add(24 * time.Hour); !prev.After(to); t = t.Add(24 * time.Hour) {
+		_, offset := t.In(loc).Zone()
+		if offset != prevOffset {
+			lo, hi := prev, t
+			for hi.Sub(lo) > time.Second {
+				mid := lo.Add(hi.Sub(lo) / 2)
+				if _, o := mid.In(loc).Zone(); o == prevOffset {
+					lo = mid
+				} else {
+					hi = mid
+				}
+			}
+			at := hi.Truncate(time.Second)
+			name, _ := at.In(loc).Zone()
+			result = append(result, transition{
+				at:         at,
+				offsetFrom: prevOffset,
+				offsetTo:   offset,
+				name:       name,
+				isDST:      at.In(loc).IsDST(),
+			})
+		}
+		prev, prevOffset = t, offset
+	}
+
+	return result
+}
+
+// formatOffset 時差を "+0900" の形式で返す
+func formatOffset(seconds int) string {
+	sign := '+'
+	if seconds < 0 {
+		sign = '-'
+		seconds = -seconds
+	}
+	return fmt.Sprintf("%c%02d%02d", sign, seconds/3600, seconds%3600/60)
+}
@@ -0,0 +1,217 @@
+// Package ical はRFC 5545形式（iCalendar）のカレンダーを読み書きする。
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxLineOctets  = 75 // 折り返す前の1行の最大オクテット数
+	dateTimeLayout = "20060102T150405"
+	dateLayout     = "20060102"
+)
+
+// Calendar 1つのカレンダー（VCALENDAR）
+type Calendar struct {
+	ProdID   string  // カレンダーを作成した製品の識別子
+	Name     string  // カレンダー名（X-WR-CALNAME）
+	TimeZone string  // カレンダーの既定のタイムゾーン（X-WR-TIMEZONE）
+	Events   []Event // 予定
+}
+
+// Event 1つの予定（VEVENT）
+// Start・Endが持つロケーションのタイムゾーンで出力し、そのタイムゾーンのVTIMEZONEも出力する
+type Event struct {
+	UID          string    // 予定の一意な識別子（同じ予定は何度出力しても同じ値にする）
+	Summary      string    // 件名
+	Description  string    // 説明
+	Location     string    // 場所
+	Categories   []string  // 分類
+	URL          string    // 関連するURL
+	Start        time.Time // 開始日時
+	End          time.Time // 終了日時
+	AllDay       bool      // 終日の予定（StartとEndの日付のみを使い、Endは翌日を指定する）
+	Stamp        time.Time // この予定の情報を作成した日時（DTSTAMP）
+	LastModified time.Time // 最終更新日時
+	Sequence     int       // 更新の回数
+}
+
+// Encode カレンダーをiCalendar形式で書き込む
+func (cal *Calendar) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	lw := &lineWriter{w: bw}
+
+	lw.line("BEGIN:VCALENDAR")
+	lw.line("VERSION:2.0")
+	lw.line("PRODID:" + cal.ProdID)
+	lw.line("CALSCALE:GREGORIAN")
+	lw.line("METHOD:PUBLISH")
+	if cal.Name != "" {
+		lw.line("X-WR-CALNAME:" + Escape(cal.Name))
+	}
+	if cal.TimeZone != "" {
+		lw.line("X-WR-TIMEZONE:" + cal.TimeZone)
+	}
+
+	for _, tz := range cal.timeZones() {
+		tz.encode(lw)
+	}
+	for i := range cal.Events {
+		cal.Events[i].encode(lw)
+	}
+
+	lw.line("END:VCALENDAR")
+	if lw.err != nil {
+		return lw.err
+	}
+	return bw.Flush()
+}
+
+// timeZones 予定で使われているタイムゾーンを、予定の期間の切り替えとともに返す
+func (cal *Calendar) timeZones() []*zone {
+	zones := map[string]*zone{}
+	for _, e := range cal.Events {
+		if e.AllDay {
+			continue
+		}
+		for _, t := range []time.Time{e.Start, e.End} {
+			if t.IsZero() || !hasTZID(t.Location()) {
+				continue
+			}
+			name := t.Location().String()
+			z, ok := zones[name]
+			if !ok {
+				z = &zone{loc: t.Location(), from: t, to: t}
+				zones[name] = z
+			}
+			if t.Before(z.from) {
+				z.from = t
+			}
+			if t.After(z.to) {
+				z.to = t
+			}
+		}
+	}
+
+	names := make([]string, 0, len(zones))
+	for name := range zones {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]*zone, len(names))
+	for i, name := range names {
+		result[i] = zones[name]
+	}
+	return result
+}
+
+func (e *Event) encode(lw *lineWriter) {
+	lw.line("BEGIN:VEVENT")
+	lw.line("UID:" + e.UID)
+	stamp := e.Stamp
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+	lw.line("DTSTAMP:" + utcDateTime(stamp))
+	if e.AllDay {
+		lw.line("DTSTART;VALUE=DATE:" + e.Start.Format(dateLayout))
+		if !e.End.IsZero() {
+			lw.line("DTEND;VALUE=DATE:" + e.End.Format(dateLayout))
+		}
+	} else {
+		lw.line(dateTimeProperty("DTSTART", e.Start))
+		if !e.End.IsZero() {
+			lw.line(dateTimeProperty("DTEND", e.End))
+		}
+	}
+	lw.line("SUMMARY:" + Escape(e.Summary))
+	if e.Location != "" {
+		lw.line("LOCATION:" + Escape(e.Location))
+	}
+	if e.Description != "" {
+		lw.line("DESCRIPTION:" + Escape(e.Description))
+	}
+	if len(e.Categories) > 0 {
+		escaped := make([]string, len(e.Categories))
+		for i, c := range e.Categories {
+			escaped[i] = Escape(c)
+		}
+		lw.line("CATEGORIES:" + strings.Join(escaped, ","))
+	}
+	if e.URL != "" {
+		lw.line("URL:" + e.URL)
+	}
+	if !e.LastModified.IsZero() {
+		lw.line("LAST-MODIFIED:" + utcDateTime(e.LastModified))
+	}
+	if e.Sequence > 0 {
+		lw.line(fmt.Sprintf("SEQUENCE:%d", e.Sequence))
+	}
+	lw.line("END:VEVENT")
+}
+
+// dateTimeProperty 日時のプロパティを、UTCであれば "Z" 付きで、それ以外はTZID付きの現地時刻で返す
+func dateTimeProperty(name string, t time.Time) string {
+	if !hasTZID(t.Location()) {
+		return name + ":" + utcDateTime(t)
+	}
+	return name + ";TZID=" + t.Location().String() + ":" + t.Format(dateTimeLayout)
+}
+
+// hasTZID VTIMEZONEを出力するロケーションかどうかを返す（UTCとLocalは除く）
+func hasTZID(loc *time.Location) bool {
+	name := loc.String()
+	return name != "UTC" && name != "Local" && name != ""
+}
+
+func utcDateTime(t time.Time) string {
+	return t.UTC().Format(dateTimeLayout) + "Z"
+}
+
+// Escape テキストの値に含まれる特殊文字をエスケープする
+func Escape(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`, "\r", `\n`).Replace(s)
+}
+
+// lineWriter 長い行を折り返してCRLFで書き込む
+type lineWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (lw *lineWriter) line(s string) {
+	if lw.err != nil {
+		return
+	}
+	_, lw.err = io.WriteString(lw.w, Fold(s)+"\r\n")
+}
+
+// Fold 75オクテットを超える行を折り返す（マルチバイト文字の途中では折り返さない）
+func Fold(s string) string {
+	if len(s) <= maxLineOctets {
+		return s
+	}
+	var b strings.Builder
+	limit := maxLineOctets
+	n := 0
+	for _, r := range s {
+		size := utf8.RuneLen(r)
+		if n+size > limit {
+			b.WriteString("\r\n ")
+			// 継続行は先頭の空白も1オクテットとして数える
+			limit = maxLineOctets - 1
+			n = 0
+		}
+		b.WriteRune(r)
+		n += size
+	}
+	return b.String()
+}
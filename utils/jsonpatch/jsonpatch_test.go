@@ -0,0 +1,72 @@
+package jsonpatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMergePatch RFC 7396 の付録Aの例を確認する
+func TestMergePatch(t *testing.T) {
+	cases := []struct{ doc, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		{`{"isPublic":true,"totalCost":3000}`, `{"isPublic":false,"totalCost":0}`, `{"isPublic":false,"totalCost":0}`},
+	}
+	for _, tc := range cases {
+		got, err := MergePatch([]byte(tc.doc), []byte(tc.patch))
+		assert.NoError(t, err)
+		assert.JSONEq(t, tc.want, string(got), "doc=%s patch=%s", tc.doc, tc.patch)
+	}
+
+	_, err := MergePatch([]byte(`{}`), []byte(`{`))
+	assert.Error(t, err)
+}
+
+// TestApply RFC 6902 の付録Aの主な例を確認する
+func TestApply(t *testing.T) {
+	cases := []struct{ doc, patch, want string }{
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{`{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{`{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{`{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{`{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`, `{"baz":"qux","foo":["a",2,"c"]}`},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"foo":"bar","child":{"grandchild":{}}}`},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+		{`{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10}]`, `{"/":9,"~1":10}`},
+		{`{"a":[[1,2],[3]]}`, `[{"op":"add","path":"/a/0/1","value":9}]`, `{"a":[[1,9,2],[3]]}`},
+		{`{"a":[[1,2],[3]]}`, `[{"op":"remove","path":"/a/0/0"}]`, `{"a":[[2],[3]]}`},
+		{`{"tags":["京都"]}`, `[{"op":"copy","from":"/tags/0","path":"/tags/-"}]`, `{"tags":["京都","京都"]}`},
+	}
+	for _, tc := range cases {
+		got, err := Apply([]byte(tc.doc), []byte(tc.patch))
+		assert.NoError(t, err, "patch=%s", tc.patch)
+		assert.JSONEq(t, tc.want, string(got), "patch=%s", tc.patch)
+	}
+
+	errorCases := []struct{ doc, patch string }{
+		{`{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`},
+		{`{"foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`},
+		{`{"foo":"bar"}`, `[{"op":"replace","path":"/baz","value":1}]`},
+		{`{"foo":[1]}`, `[{"op":"add","path":"/foo/01","value":2}]`},
+		{`{"foo":"bar"}`, `[{"op":"unknown","path":"/foo"}]`},
+		{`{"foo":{"bar":1}}`, `[{"op":"move","from":"/foo","path":"/foo/bar/baz"}]`},
+	}
+	for _, tc := range errorCases {
+		_, err := Apply([]byte(tc.doc), []byte(tc.patch))
+		assert.Error(t, err, "patch=%s", tc.patch)
+	}
+}
@@ -0,0 +1,460 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// ErrUnsupportedFont 埋め込みに対応していないフォントの場合のエラー
+var ErrUnsupportedFont = errors.New("pdf: unsupported font")
+
+// subsetTables サブセットに残すテーブル（グリフの描画とヒンティングに必要なもの）
+var subsetTables = []string{"cvt ", "fpgm", "glyf", "head", "hhea", "hmtx", "loca", "maxp", "prep"}
+
+// Font 文書に埋め込むTrueTypeフォント
+// 文書で使った文字のグリフだけを残したサブセットを埋め込む（グリフIDは元のフォントと同じにする）
+type Font struct {
+	name       string            // PostScript名
+	tables     map[string][]byte // テーブルのタグと内容
+	glyphs     map[rune]uint16   // 文字からグリフIDへの対応（cmap）
+	numGlyphs  int
+	unitsPerEm int
+	bbox       [4]int // xMin, yMin, xMax, yMax（フォントの単位）
+	ascent     int
+	descent    int
+}
+
+// LoadFont TrueType（.ttf、TrueTypeのアウトラインの .otf・.ttc）のフォントファイルを読み込む
+// .ttc の場合は先頭のフォントを使う。CFFのアウトラインのフォントには対応していない
+func LoadFont(path string) (*Font, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseFont(data)
+}
+
+// ParseFont TrueTypeのフォントを解析する
+func ParseFont(data []byte) (*Font, error) {
+	tables, err := readTables(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range []string{"head", "hhea", "maxp", "loca", "glyf", "cmap"} {
+		if tables[tag] == nil {
+			return nil, fmt.Errorf("%w: missing %q table", ErrUnsupportedFont, tag)
+		}
+	}
+	head, hhea, maxp := tables["head"], tables["hhea"], tables["maxp"]
+	if len(head) < 54 || len(hhea) < 8 || len(maxp) < 6 {
+		return nil, fmt.Errorf("%w: truncated table", ErrUnsupportedFont)
+	}
+
+	f := &Font{
+		tables:     tables,
+		numGlyphs:  int(binary.BigEndian.Uint16(maxp[4:])),
+		unitsPerEm: int(binary.BigEndian.Uint16(head[18:])),
+		bbox: [4]int{
+			int(int16(binary.BigEndian.Uint16(head[36:]))),
+			int(int16(binary.BigEndian.Uint16(head[38:]))),
+			int(int16(binary.BigEndian.Uint16(head[40:]))),
+			int(int16(binary.BigEndian.Uint16(head[42:]))),
+		},
+		ascent:  int(int16(binary.BigEndian.Uint16(hhea[4:]))),
+		descent: int(int16(binary.BigEndian.Uint16(hhea[6:]))),
+	}
+	if f.unitsPerEm == 0 {
+		return nil, fmt.Errorf("%w: invalid unitsPerEm", ErrUnsupportedFont)
+	}
+	if _, err := f.glyphRange(0); err != nil {
+		return nil, err
+	}
+	if f.glyphs, err = parseCmap(tables["cmap"]); err != nil {
+		return nil, err
+	}
+	f.name = postScriptName(tables["name"])
+	return f, nil
+}
+
+// Name フォントのPostScript名を返す
+func (f *Font) Name() string {
+	return f.name
+}
+
+// HasGlyph 文字のグリフがあるかどうかを返す
+func (f *Font) HasGlyph(r rune) bool {
+	return f.glyphs[r] != 0
+}
+
+// readTables テーブルディレクトリを読み、タグごとのテーブルの内容を返す
+func readTables(data []byte) (map[string][]byte, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("%w: too short", ErrUnsupportedFont)
+	}
+	offset := 0
+	switch string(data[:4]) {
+	case "ttcf":
+		// フォントコレクションは先頭のフォントを使う
+		if len(data) < 16 {
+			return nil, fmt.Errorf("%w: too short", ErrUnsupportedFont)
+		}
+		offset = int(binary.BigEndian.Uint32(data[12:]))
+	case "OTTO":
+		return nil, fmt.Errorf("%w: CFF outlines are not supported", ErrUnsupportedFont)
+	}
+	if offset+12 > len(data) {
+		return nil, fmt.Errorf("%w: invalid offset", ErrUnsupportedFont)
+	}
+	if version := string(data[offset : offset+4]); version != "\x00\x01\x00\x00" && version != "true" {
+		return nil, fmt.Errorf("%w: not a TrueType font", ErrUnsupportedFont)
+	}
+
+	numTables := int(binary.BigEndian.Uint16(data[offset+4:]))
+	if offset+12+numTables*16 > len(data) {
+		return nil, fmt.Errorf("%w: truncated table directory", ErrUnsupportedFont)
+	}
+	tables := map[string][]byte{}
+	for i := 0; i < numTables; i++ {
+		record := data[offset+12+i*16:]
+		start := int(binary.BigEndian.Uint32(record[8:]))
+		length := int(binary.BigEndian.Uint32(record[12:]))
+		if start < 0 || length < 0 || start+length > len(data) {
+			return nil, fmt.Errorf("%w: invalid table %q", ErrUnsupportedFont, record[:4])
+		}
+		tables[string(record[:4])] = data[start : start+length]
+	}
+	return tables, nil
+}
+
+// parseCmap Unicodeのcmap（形式12または4）から基本多言語面の文字とグリフIDの対応を読む
+func parseCmap(cmap []byte) (map[rune]uint16, error) {
+	if len(cmap) < 4 {
+		return nil, fmt.Errorf("%w: truncated cmap", ErrUnsupportedFont)
+	}
+	var format4, format12 []byte
+	numTables := int(binary.BigEndian.Uint16(cmap[2:]))
+	for i := 0; i < numTables && 4+i*8+8 <= len(cmap); i++ {
+		record := cmap[4+i*8:]
+		platform, encoding := binary.BigEndian.Uint16(record), binary.BigEndian.Uint16(record[2:])
+		if platform != 0 && !(platform == 3 && (encoding == 1 || encoding == 10)) {
+			continue
+		}
+		offset := int(binary.BigEndian.Uint32(record[4:]))
+		if offset+2 > len(cmap) {
+			continue
+		}
+		switch binary.BigEndian.Uint16(cmap[offset:]) {
+		case 4:
+			format4 = cmap[offset:]
+		case 12:
+			format12 = cmap[offset:]
+		}
+	}
+
+	glyphs := map[rune]uint16{}
+	switch {
+	case format12 != nil && len(format12) >= 16:
+		groups := int(binary.BigEndian.Uint32(format12[12:]))
+		for i := 0; i < groups && 16+i*12+12 <= len(format12); i++ {
+			group := format12[16+i*12:]
+			start, end := binary.BigEndian.Uint32(group), binary.BigEndian.Uint32(group[4:])
+			gid := binary.BigEndian.Uint32(group[8:])
+			for c := start; c <= end && c <= 0xffff; c++ {
+				glyphs[rune(c)] = uint16(gid + c - start)
+			}
+		}
+	case format4 != nil && len(format4) >= 14:
+		segCount := int(binary.BigEndian.Uint16(format4[6:])) / 2
+		ends, starts := 14, 16+segCount*2
+		deltas, rangeOffsets := starts+segCount*2, starts+segCount*4
+		if rangeOffsets+segCount*2 > len(format4) {
+			return nil, fmt.Errorf("%w: truncated cmap", ErrUnsupportedFont)
+		}
+		for i := 0; i < segCount; i++ {
+			end := binary.BigEndian.Uint16(format4[ends+i*2:])
+			start := binary.BigEndian.Uint16(format4[starts+i*2:])
+			delta := binary.BigEndian.Uint16(format4[deltas+i*2:])
+			rangeOffset := int(binary.BigEndian.Uint16(format4[rangeOffsets+i*2:]))
+			for c := int(start); c <= int(end) && c < 0xffff; c++ {
+				gid := uint16(c) + delta
+				if rangeOffset != 0 {
+					at := rangeOffsets + i*2 + rangeOffset + (c-int(start))*2
+					if at+2 > len(format4) {
+						break
+					}
+					if gid = binary.BigEndian.Uint16(format4[at:]); gid != 0 {
+						gid += delta
+					}
+				}
+				if gid != 0 {
+					glyphs[rune(c)] = gid
+				}
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%w: no Unicode cmap", ErrUnsupportedFont)
+	}
+	return glyphs, nil
+}
+
+// postScriptName name テーブルからPostScript名（名前ID 6）を読む（ない場合は "Embedded"）
+func postScriptName(table []byte) string {
+	name := ""
+	if len(table) >= 6 {
+		count := int(binary.BigEndian.Uint16(table[2:]))
+		storage := int(binary.BigEndian.Uint16(table[4:]))
+		for i := 0; i < count && 6+i*12+12 <= len(table) && name == ""; i++ {
+			record := table[6+i*12:]
+			platform, nameID := binary.BigEndian.Uint16(record), binary.BigEndian.Uint16(record[6:])
+			length, offset := int(binary.BigEndian.Uint16(record[8:])), int(binary.BigEndian.Uint16(record[10:]))
+			if nameID != 6 || storage+offset+length > len(table) {
+				continue
+			}
+			raw := table[storage+offset : storage+offset+length]
+			switch platform {
+			case 1:
+				name = string(raw)
+			case 0, 3:
+				units := make([]uint16, len(raw)/2)
+				for j := range units {
+					units[j] = binary.BigEndian.Uint16(raw[j*2:])
+				}
+				name = string(utf16.Decode(units))
+			}
+		}
+	}
+	// PDFの名前に使えない文字を取り除く
+	name = strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return -1
+	}, name)
+	if name == "" {
+		return "Embedded"
+	}
+	return name
+}
+
+// glyphRange glyf テーブルでのグリフの範囲を返す
+func (f *Font) glyphRange(gid int) ([2]int, error) {
+	loca, glyf := f.tables["loca"], f.tables["glyf"]
+	var start, end int
+	if binary.BigEndian.Uint16(f.tables["head"][50:]) == 0 {
+		if (gid+2)*2 > len(loca) {
+			return [2]int{}, fmt.Errorf("%w: truncated loca", ErrUnsupportedFont)
+		}
+		start = int(binary.BigEndian.Uint16(loca[gid*2:])) * 2
+		end = int(binary.BigEndian.Uint16(loca[gid*2+2:])) * 2
+	} else {
+		if (gid+2)*4 > len(loca) {
+			return [2]int{}, fmt.Errorf("%w: truncated loca", ErrUnsupportedFont)
+		}
+		start = int(binary.BigEndian.Uint32(loca[gid*4:]))
+		end = int(binary.BigEndian.Uint32(loca[gid*4+4:]))
+	}
+	if start > end || end > len(glyf) {
+		return [2]int{}, fmt.Errorf("%w: invalid glyph %d", ErrUnsupportedFont, gid)
+	}
+	return [2]int{start, end}, nil
+}
+
+// subsetGlyphs 文字のグリフと、複合グリフが参照するグリフのIDを返す（.notdef を含む）
+func (f *Font) subsetGlyphs(runes []rune) map[uint16]bool {
+	used := map[uint16]bool{0: true}
+	queue := []uint16{0}
+	for _, r := range runes {
+		if gid, ok := f.glyphs[r]; ok && !used[gid] {
+			used[gid] = true
+			queue = append(queue, gid)
+		}
+	}
+	for len(queue) > 0 {
+		gid := queue[0]
+		queue = queue[1:]
+		for _, component := range f.components(gid) {
+			if !used[component] {
+				used[component] = true
+				queue = append(queue, component)
+			}
+		}
+	}
+	return used
+}
+
+// 複合グリフのフラグ
+const (
+	argsAreWords    = 0x0001
+	haveScale       = 0x0008
+	moreComponents  = 0x0020
+	haveXYScale     = 0x0040
+	haveTwoByTwo    = 0x0080
+	compositeHeader = 10
+)
+
+// components 複合グリフが参照するグリフのIDを返す（単純なグリフの場合は空）
+func (f *Font) components(gid uint16) []uint16 {
+	if int(gid) >= f.numGlyphs {
+		return nil
+	}
+	r, err := f.glyphRange(int(gid))
+	if err != nil || r[1]-r[0] < compositeHeader {
+		return nil
+	}
+	glyph := f.tables["glyf"][r[0]:r[1]]
+	if int16(binary.BigEndian.Uint16(glyph)) >= 0 {
+		return nil
+	}
+
+	var ids []uint16
+	for at := compositeHeader; at+4 <= len(glyph); {
+		flags := binary.BigEndian.Uint16(glyph[at:])
+		ids = append(ids, binary.BigEndian.Uint16(glyph[at+2:]))
+		at += 4
+		if flags&argsAreWords != 0 {
+			at += 4
+		} else {
+			at += 2
+		}
+		switch {
+		case flags&haveScale != 0:
+			at += 2
+		case flags&haveXYScale != 0:
+			at += 4
+		case flags&haveTwoByTwo != 0:
+			at += 8
+		}
+		if flags&moreComponents == 0 {
+			break
+		}
+	}
+	return ids
+}
+
+// subset 文字のグリフだけを残したフォントファイルを作成する
+// グリフIDを変えないように、使わないグリフは長さ0にする
+func (f *Font) subset(runes []rune) ([]byte, error) {
+	used := f.subsetGlyphs(runes)
+
+	var glyf bytes.Buffer
+	loca := make([]byte, (f.numGlyphs+1)*4)
+	for gid := 0; gid < f.numGlyphs; gid++ {
+		binary.BigEndian.PutUint32(loca[gid*4:], uint32(glyf.Len()))
+		if !used[uint16(gid)] {
+			continue
+		}
+		r, err := f.glyphRange(gid)
+		if err != nil {
+			return nil, err
+		}
+		glyf.Write(f.tables["glyf"][r[0]:r[1]])
+		for glyf.Len()%4 != 0 {
+			glyf.WriteByte(0)
+		}
+	}
+	binary.BigEndian.PutUint32(loca[f.numGlyphs*4:], uint32(glyf.Len()))
+
+	// loca を長い形式で書き、チェックサムは書き出した後に計算する
+	head := append([]byte(nil), f.tables["head"]...)
+	binary.BigEndian.PutUint32(head[8:], 0)
+	binary.BigEndian.PutUint16(head[50:], 1)
+
+	tables := map[string][]byte{}
+	for _, tag := range subsetTables {
+		if table, ok := f.tables[tag]; ok {
+			tables[tag] = table
+		}
+	}
+	tables["head"], tables["loca"], tables["glyf"] = head, loca, glyf.Bytes()
+
+	out := writeTables(tables)
+	headOffset := int(binary.BigEndian.Uint32(out[tableRecord(tables, "head")+8:]))
+	binary.BigEndian.PutUint32(out[headOffset+8:], 0xb1b0afba-checksum(out))
+	return out, nil
+}
+
+// tableRecord テーブルディレクトリでのテーブルのレコードの位置を返す
+func tableRecord(tables map[string][]byte, tag string) int {
+	i := 0
+	for t := range tables {
+		if t < tag {
+			i++
+		}
+	}
+	return 12 + i*16
+}
+
+// writeTables テーブルをタグの順に並べたフォントファイルを作成する
+func writeTables(tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	entrySelector := 0
+	for 1<<(entrySelector+1) <= len(tags) {
+		entrySelector++
+	}
+	searchRange := (1 << entrySelector) * 16
+
+	header := make([]byte, 12+len(tags)*16)
+	binary.BigEndian.PutUint32(header, 0x00010000)
+	binary.BigEndian.PutUint16(header[4:], uint16(len(tags)))
+	binary.BigEndian.PutUint16(header[6:], uint16(searchRange))
+	binary.BigEndian.PutUint16(header[8:], uint16(entrySelector))
+	binary.BigEndian.PutUint16(header[10:], uint16(len(tags)*16-searchRange))
+
+	var body bytes.Buffer
+	for i, tag := range tags {
+		table := tables[tag]
+		record := header[12+i*16:]
+		copy(record, tag)
+		binary.BigEndian.PutUint32(record[4:], checksum(table))
+		binary.BigEndian.PutUint32(record[8:], uint32(len(header)+body.Len()))
+		binary.BigEndian.PutUint32(record[12:], uint32(len(table)))
+		body.Write(table)
+		for body.Len()%4 != 0 {
+			body.WriteByte(0)
+		}
+	}
+	return append(header, body.Bytes()...)
+}
+
+// checksum TrueTypeのテーブルのチェックサム（4バイトごとの合計）
+func checksum(b []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(b); i += 4 {
+		var word [4]byte
+		copy(word[:], b[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
+
+// subsetName サブセットのフォント名（使ったグリフから決めた6文字の英大文字のタグ + "+" + PostScript名）
+func (f *Font) subsetName(runes []rune) string {
+	h := fnv.New32a()
+	for _, r := range runes {
+		fmt.Fprintf(h, "%d,", f.glyphs[r])
+	}
+	sum := h.Sum32()
+	tag := make([]byte, 6)
+	for i := range tag {
+		tag[i] = byte('A' + sum%26)
+		sum /= 26
+	}
+	return string(tag) + "+" + f.name
+}
+
+// scale フォントの単位の値をPDFのグリフの単位（1000分率）にする
+func (f *Font) scale(v int) int {
+	return v * 1000 / f.unitsPerEm
+}
@@ -0,0 +1,156 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testFont テスト用の最小限のTrueTypeフォントを作成する
+// グリフは .notdef、"A"、"B"、"A" を参照する複合グリフの "あ" の4つ
+func testFont() []byte {
+	be16 := func(values ...int) []byte {
+		b := make([]byte, len(values)*2)
+		for i, v := range values {
+			binary.BigEndian.PutUint16(b[i*2:], uint16(v))
+		}
+		return b
+	}
+	join := func(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+
+	head := make([]byte, 54)
+	binary.BigEndian.PutUint32(head, 0x00010000)
+	binary.BigEndian.PutUint32(head[12:], 0x5f0f3cf5)
+	copy(head[18:], be16(2048))
+	copy(head[36:], be16(0, -512, 2048, 1800))
+
+	hhea := make([]byte, 36)
+	copy(hhea[4:], be16(1800, -512))
+	copy(hhea[34:], be16(4))
+
+	maxp := join(be16(0, 0x5000), be16(4))
+	hmtx := be16(1000, 0, 1000, 0, 1000, 0, 2048, 0)
+
+	// 単純なグリフは輪郭なし（ヘッダーのみ）、複合グリフは1つの部品を参照する
+	simple := be16(0, 0, 0, 100, 100)
+	composite := join(be16(-1, 0, 0, 100, 100), be16(0x0002, 1), []byte{0, 0})
+	glyf := join(simple, simple, simple, composite, []byte{0, 0})
+	loca := be16(0, 5, 10, 15, 23)
+
+	// 形式4のcmap："A"〜"B" → 1〜2、"あ" → 3
+	subtable := join(
+		be16(4, 40, 0, 6, 4, 1, 2),
+		be16(0x42, 0x3042, 0xffff), be16(0),
+		be16(0x41, 0x3042, 0xffff),
+		be16(1-0x41, 3-0x3042, 1),
+		be16(0, 0, 0),
+	)
+	cmap := join(be16(0, 1), be16(3, 1), []byte{0, 0, 0, 12}, subtable)
+
+	psName := utf16.Encode([]rune("Test Gothic"))
+	nameValue := make([]int, len(psName))
+	for i, u := range psName {
+		nameValue[i] = int(u)
+	}
+	name := join(be16(0, 1, 18), be16(3, 1, 0x411, 6, len(psName)*2, 0), be16(nameValue...))
+
+	return writeTables(map[string][]byte{
+		"head": head, "hhea": hhea, "maxp": maxp, "hmtx": hmtx,
+		"glyf": glyf, "loca": loca, "cmap": cmap, "name": name,
+	})
+}
+
+// TestParseFont cmap・名前を読み込み、対応していないフォントをエラーにすることを確認する
+func TestParseFont(t *testing.T) {
+	f, err := ParseFont(testFont())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "TestGothic", f.Name())
+	assert.True(t, f.HasGlyph('A'))
+	assert.True(t, f.HasGlyph('あ'))
+	assert.False(t, f.HasGlyph('C'))
+	assert.Equal(t, []int{0, -250, 1000, 878}, []int{f.scale(f.bbox[0]), f.scale(f.bbox[1]), f.scale(f.bbox[2]), f.scale(f.bbox[3])})
+
+	_, err = ParseFont([]byte("OTTO\x00\x00\x00\x00\x00\x00\x00\x00"))
+	assert.True(t, errors.Is(err, ErrUnsupportedFont))
+	_, err = ParseFont([]byte("not a font"))
+	assert.True(t, errors.Is(err, ErrUnsupportedFont))
+}
+
+// TestSubset 使った文字と複合グリフが参照するグリフだけを残し、グリフIDを変えないことを確認する
+func TestSubset(t *testing.T) {
+	f, err := ParseFont(testFont())
+	if !assert.NoError(t, err) {
+		return
+	}
+	out, err := f.subset([]rune{'あ'})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, uint32(0xb1b0afba), checksum(out))
+
+	tables, err := readTables(out)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotContains(t, tables, "cmap")
+	sub := &Font{tables: tables, numGlyphs: f.numGlyphs}
+	var lengths []int
+	for gid := 0; gid < f.numGlyphs; gid++ {
+		r, err := sub.glyphRange(gid)
+		assert.NoError(t, err)
+		lengths = append(lengths, r[1]-r[0])
+	}
+	// .notdef、"あ" とその部品の "A" は残り、"B" は空になる（4バイト境界に揃える）
+	assert.Equal(t, []int{12, 12, 0, 16}, lengths)
+}
+
+// TestEncodeEmbeddedFont フォントを埋め込んだ文書の相互参照表とフォントのオブジェクトを確認する
+func TestEncodeEmbeddedFont(t *testing.T) {
+	f, err := ParseFont(testFont())
+	if !assert.NoError(t, err) {
+		return
+	}
+	doc := New(A4Width, A4Height)
+	doc.Font = f
+	doc.AddPage().Text(50, 50, "あA", Style{Size: 12})
+
+	var b bytes.Buffer
+	assert.NoError(t, doc.Encode(&b))
+	out := b.Bytes()
+
+	assert.Regexp(t, `/BaseFont /[A-Z]{6}\+TestGothic /Encoding /Identity-H /DescendantFonts \[4 0 R\] /ToUnicode 11 0 R`, string(out))
+	assert.Contains(t, string(out), "/Subtype /CIDFontType2")
+	assert.Contains(t, string(out), "/CIDToGIDMap 10 0 R")
+	assert.Contains(t, string(out), "/FontFile2 9 0 R")
+	assert.Contains(t, string(out), "/FontBBox [0 -250 1000 878]")
+
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(out)
+	if !assert.NotNil(t, m) {
+		return
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	assert.True(t, bytes.HasPrefix(out[xref:], []byte("xref\n0 12\n")))
+	for i, entry := range strings.Split(string(out[xref:]), "\n")[3:14] {
+		offset, _ := strconv.Atoi(entry[:10])
+		assert.True(t, bytes.HasPrefix(out[offset:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))), entry)
+	}
+
+	// CIDToGIDMap は文字コードからcmapのグリフIDを引く
+	gids := cidToGIDMap(f, []rune{'A', 'あ'})
+	assert.Len(t, gids, ('あ'+1)*2)
+	assert.Equal(t, uint16(1), binary.BigEndian.Uint16(gids['A'*2:]))
+	assert.Equal(t, uint16(3), binary.BigEndian.Uint16(gids['あ'*2:]))
+	assert.Equal(t, uint16(0), binary.BigEndian.Uint16(gids['B'*2:]))
+
+	assert.Contains(t, toUnicodeCMap([]rune{'A', 'B', 'あ'}), "2 beginbfrange\n<0000> <00FF> <0000>\n<3000> <30FF> <3000>\nendbfrange")
+}
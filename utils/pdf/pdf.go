@@ -0,0 +1,422 @@
+// Package pdf は日本語のテキストと罫線からなる簡単なPDF文書を書き出す。
+// 標準ではフォントを埋め込まず、日本語フォント（HeiseiKakuGo-W5）を参照するため、
+// 外部のフォントファイルやライブラリなしで日本語を出力できる。
+// ただし参照したフォントを表示できるのは Adobe-Japan1 のフォントを備えるビューア（Acrobat など）に限られ、
+// それ以外のビューアでは代わりのフォントで表示されるか、文字が表示されない。
+// Document.Font にTrueTypeのフォントを指定すると、使った文字のサブセットを埋め込み、どのビューアでも同じように表示できる。
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// A4の用紙サイズ（ポイント）
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+// 参照するフォント（Adobe-Japan1のゴシック体）
+// UniJIS-UCS2-HW-H はASCII文字を半角のグリフに割り当てるため、文字幅を正確に計算できる
+const (
+	fontName     = "HeiseiKakuGo-W5"
+	fontEncoding = "UniJIS-UCS2-HW-H"
+)
+
+// Style テキストの書式
+type Style struct {
+	Size float64 // 文字の大きさ（ポイント）
+	Bold bool    // 太字（輪郭を重ねて描画する）
+	Gray float64 // 文字の濃さ（0が黒、1が白）
+}
+
+// Document PDF文書
+type Document struct {
+	Title   string    // 文書のタイトル
+	Author  string    // 作成者
+	Created time.Time // 作成日時（ゼロ値の場合は出力しない）
+	Width   float64   // 用紙の幅（ポイント）
+	Height  float64   // 用紙の高さ（ポイント）
+	Font    *Font     // 埋め込むフォント（nilの場合は埋め込まずに HeiseiKakuGo-W5 を参照する）
+	pages   []*Page
+	runes   map[rune]bool // 描画した文字（埋め込むフォントのサブセットに使う）
+}
+
+// Page 1ページ分の描画内容
+// 座標はポイント単位で、用紙の左上を原点とし、下に向かって y が増える
+type Page struct {
+	doc     *Document
+	content bytes.Buffer
+}
+
+// New 用紙サイズを指定して空の文書を作成する
+func New(width, height float64) *Document {
+	return &Document{Width: width, Height: height}
+}
+
+// AddPage 文書の末尾にページを追加する
+func (d *Document) AddPage() *Page {
+	page := &Page{doc: d}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// Pages 追加したページを返す
+func (d *Document) Pages() []*Page {
+	return d.pages
+}
+
+// PageCount ページ数を返す
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+// Text ベースラインの左端を (x, y) として1行のテキストを描画する（改行は無視する）
+func (p *Page) Text(x, y float64, s string, style Style) {
+	s = strings.NewReplacer("\r", "", "\n", " ").Replace(s)
+	if s == "" {
+		return
+	}
+	fmt.Fprintf(&p.content, "q %s g ", number(style.Gray))
+	if style.Bold {
+		fmt.Fprintf(&p.content, "%s G 2 Tr %s w ", number(style.Gray), number(style.Size/30))
+	}
+	fmt.Fprintf(&p.content, "BT /F1 %s Tf %s %s Td <%s> Tj ET Q\n",
+		number(style.Size), number(x), number(p.doc.Height-y), encodeText(s))
+	if p.doc.runes == nil {
+		p.doc.runes = map[rune]bool{}
+	}
+	for _, r := range s {
+		p.doc.runes[encodableRune(r)] = true
+	}
+}
+
+// Line (x1, y1) から (x2, y2) まで線を描画する
+func (p *Page) Line(x1, y1, x2, y2, width, gray float64) {
+	fmt.Fprintf(&p.content, "q %s G %s w %s %s m %s %s l S Q\n",
+		number(gray), number(width), number(x1), number(p.doc.Height-y1), number(x2), number(p.doc.Height-y2))
+}
+
+// Rect 左上を (x, y) とする長方形を塗りつぶす
+func (p *Page) Rect(x, y, width, height, gray float64) {
+	fmt.Fprintf(&p.content, "q %s g %s %s %s %s re f Q\n",
+		number(gray), number(x), number(p.doc.Height-y-height), number(width), number(height))
+}
+
+// runeWidth 文字の幅（文字の大きさに対する1000分率）
+// ASCII文字と半角カタカナは半角、それ以外は全角として扱う（フォントの W・DW 配列と一致させる）
+func runeWidth(r rune) float64 {
+	if r >= 0x20 && r <= 0x7e || r >= 0xff61 && r <= 0xff9f {
+		return 500
+	}
+	return 1000
+}
+
+// TextWidth テキストを指定した大きさで描画したときの幅を返す
+func TextWidth(s string, size float64) float64 {
+	var w float64
+	for _, r := range s {
+		w += runeWidth(r)
+	}
+	return w * size / 1000
+}
+
+// noLineStart 行頭に置かない文字（句読点・閉じ括弧など）
+const noLineStart = "、。，．,.)）」』】〕〉》ー～ぁぃぅぇぉっゃゅょァィゥェォッャュョ!?！？:;：；"
+
+// Wrap テキストを指定した幅に収まるように行に分割する
+// 改行文字で段落を分け、英単語の途中ではできるだけ改行しない
+func Wrap(s string, size, maxWidth float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+		lines = append(lines, wrapParagraph([]rune(paragraph), size, maxWidth)...)
+	}
+	return lines
+}
+
+func wrapParagraph(runes []rune, size, maxWidth float64) []string {
+	if len(runes) == 0 {
+		return []string{""}
+	}
+
+	var lines []string
+	start, lastSpace := 0, -1
+	var width float64
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		w := runeWidth(r) * size / 1000
+		if width+w > maxWidth && i > start && !strings.ContainsRune(noLineStart, r) {
+			end, next := i, i
+			// 英単語の途中であれば直前の空白で改行する
+			if r != ' ' && lastSpace > start && runes[i-1] != ' ' {
+				end, next = lastSpace, lastSpace+1
+			}
+			lines = append(lines, strings.TrimRight(string(runes[start:end]), " "))
+			for next < len(runes) && runes[next] == ' ' {
+				next++
+			}
+			start, lastSpace, i = next, -1, next-1
+			width = 0
+			continue
+		}
+		if r == ' ' {
+			lastSpace = i
+		}
+		width += w
+	}
+	if start < len(runes) {
+		lines = append(lines, string(runes[start:]))
+	}
+	return lines
+}
+
+// encodeText テキストをフォントの符号化（UCS-2のビッグエンディアン）の16進文字列にする
+// フォントを埋め込む場合も、文字コードをそのままCIDとして使う
+func encodeText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		fmt.Fprintf(&b, "%04X", encodableRune(r))
+	}
+	return b.String()
+}
+
+// encodableRune 基本多言語面にない文字（絵文字など）は表示できないため "?" に置き換える
+func encodableRune(r rune) rune {
+	if r > 0xffff || r >= 0xd800 && r <= 0xdfff {
+		return '?'
+	}
+	return r
+}
+
+// textString 文書情報に使う文字列（BOM付きUTF-16BEの16進文字列）
+func textString(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// number 座標などの数値をPDFの数値の表記にする
+func number(f float64) string {
+	s := fmt.Sprintf("%.2f", f)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+	return s
+}
+
+// Encode 文書をPDF形式で書き込む
+func (d *Document) Encode(w io.Writer) error {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	// オブジェクト番号: 1 カタログ、2 ページツリー、3〜5 フォント、6 文書情報、7以降 ページと内容、
+	// 最後にフォントを埋め込む場合のフォントファイル・CIDToGIDMap・ToUnicode
+	const firstPage = 7
+	var objects []string
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+i*2)
+	}
+
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)),
+	)
+	// 埋め込むフォントのデータはページの後に置く
+	fontObjects, fontData, err := d.fontObjects(firstPage + len(d.pages)*2)
+	if err != nil {
+		return err
+	}
+	objects = append(objects, fontObjects...)
+	objects = append(objects, d.info())
+
+	for i, page := range d.pages {
+		content, err := flateStream(page.content.Bytes(), "")
+		if err != nil {
+			return err
+		}
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] "+
+				"/Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+				number(d.Width), number(d.Height), firstPage+i*2+1),
+			content,
+		)
+	}
+	objects = append(objects, fontData...)
+
+	bw := bufio.NewWriter(w)
+	cw := &countingWriter{w: bw}
+	fmt.Fprint(cw, "%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = cw.n
+		fmt.Fprintf(cw, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := cw.n
+	fmt.Fprintf(cw, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(cw, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(cw, "trailer\n<< /Size %d /Root 1 0 R /Info 6 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	if cw.err != nil {
+		return cw.err
+	}
+	return bw.Flush()
+}
+
+// fontObjects フォントのオブジェクト（3〜5）と、埋め込むデータのオブジェクト（next 以降）を返す
+func (d *Document) fontObjects(next int) ([]string, []string, error) {
+	if d.Font == nil {
+		return []string{
+			fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s-%s /Encoding /%s /DescendantFonts [4 0 R] >>",
+				fontName, fontEncoding, fontEncoding),
+			fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /%s "+
+				"/CIDSystemInfo << /Registry (Adobe) /Ordering (Japan1) /Supplement 2 >> "+
+				"/FontDescriptor 5 0 R /DW 1000 /W [231 389 500] >>", fontName),
+			fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 4 /FontBBox [-92 -250 1010 922] "+
+				"/ItalicAngle 0 /Ascent 752 /Descent -221 /CapHeight 737 /StemV 114 >>", fontName),
+		}, nil, nil
+	}
+
+	f := d.Font
+	runes := make([]rune, 0, len(d.runes))
+	for r := range d.runes {
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(a, b int) bool { return runes[a] < runes[b] })
+
+	program, err := f.subset(runes)
+	if err != nil {
+		return nil, nil, err
+	}
+	fontFile, err := flateStream(program, fmt.Sprintf("/Length1 %d ", len(program)))
+	if err != nil {
+		return nil, nil, err
+	}
+	cidToGID, err := flateStream(cidToGIDMap(f, runes), "")
+	if err != nil {
+		return nil, nil, err
+	}
+	toUnicode, err := flateStream([]byte(toUnicodeCMap(runes)), "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 文字幅はレイアウト（runeWidth）と一致させるため、参照するフォントと同じく半角・全角の固定幅にする
+	name := f.subsetName(runes)
+	return []string{
+			fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H "+
+				"/DescendantFonts [4 0 R] /ToUnicode %d 0 R >>", name, next+2),
+			fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s "+
+				"/CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> "+
+				"/FontDescriptor 5 0 R /DW 1000 /W [32 126 500 65377 65439 500] /CIDToGIDMap %d 0 R >>", name, next+1),
+			fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 4 /FontBBox [%d %d %d %d] "+
+				"/ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+				name, f.scale(f.bbox[0]), f.scale(f.bbox[1]), f.scale(f.bbox[2]), f.scale(f.bbox[3]),
+				f.scale(f.ascent), f.scale(f.descent), f.scale(f.ascent), next),
+		},
+		[]string{fontFile, cidToGID, toUnicode}, nil
+}
+
+// cidToGIDMap CID（文字コード）からグリフIDへの対応表（CIDごとに2バイトのグリフID）をフォントのcmapから作成する
+func cidToGIDMap(f *Font, runes []rune) []byte {
+	if len(runes) == 0 {
+		return []byte{0, 0}
+	}
+	m := make([]byte, (runes[len(runes)-1]+1)*2)
+	for _, r := range runes {
+		gid := f.glyphs[r]
+		m[r*2], m[r*2+1] = byte(gid>>8), byte(gid)
+	}
+	return m
+}
+
+// toUnicodeCMap テキストのコピーや検索のため、CID（文字コード）をそのままUnicodeに対応させるCMap
+func toUnicodeCMap(runes []rune) string {
+	var ranges []string
+	for _, r := range runes {
+		high := fmt.Sprintf("<%02X00> <%02XFF> <%02X00>", r>>8, r>>8, r>>8)
+		if len(ranges) == 0 || ranges[len(ranges)-1] != high {
+			ranges = append(ranges, high)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	// 1つのブロックには100件まで書ける
+	for start := 0; start < len(ranges); start += 100 {
+		end := min(start+100, len(ranges))
+		fmt.Fprintf(&b, "%d beginbfrange\n%s\nendbfrange\n", end-start, strings.Join(ranges[start:end], "\n"))
+	}
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return b.String()
+}
+
+// flateStream データを圧縮したストリームのオブジェクトを返す（extra は辞書に加える項目）
+func flateStream(data []byte, extra string) (string, error) {
+	var b bytes.Buffer
+	zw := zlib.NewWriter(&b)
+	if _, err := zw.Write(data); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("<< /Length %d %s/Filter /FlateDecode >>\nstream\n%s\nendstream", b.Len(), extra, b.String()), nil
+}
+
+// info 文書情報の辞書を返す
+func (d *Document) info() string {
+	entries := []string{"/Producer " + textString("my_home_backend")}
+	if d.Title != "" {
+		entries = append(entries, "/Title "+textString(d.Title))
+	}
+	if d.Author != "" {
+		entries = append(entries, "/Author "+textString(d.Author))
+	}
+	if !d.Created.IsZero() {
+		_, offset := d.Created.Zone()
+		sign := "+"
+		if offset < 0 {
+			sign, offset = "-", -offset
+		}
+		entries = append(entries, fmt.Sprintf("/CreationDate (D:%s%s%02d'%02d')",
+			d.Created.Format("20060102150405"), sign, offset/3600, offset%3600/60))
+	}
+	return "<< " + strings.Join(entries, " ") + " >>"
+}
+
+// countingWriter 書き込んだバイト数を数える（相互参照表のオフセットに使う）
+type countingWriter struct {
+	w   io.Writer
+	n   int
+	err error
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.n += n
+	cw.err = err
+	return n, err
+}
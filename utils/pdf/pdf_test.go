@@ -0,0 +1,73 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWrap 指定した幅で折り返し、英単語と行頭禁則を考慮することを確認する
+func TestWrap(t *testing.T) {
+	assert.Equal(t, 5.0, TextWidth("ab", 5))
+	assert.Equal(t, 20.0, TextWidth("清水", 10))
+
+	// 全角10pt・幅40ptなら4文字ずつ
+	assert.Equal(t, []string{"清水寺の", "本堂を見", "学"}, Wrap("清水寺の本堂を見学", 10, 40))
+	// 句点は前の行に残す
+	assert.Equal(t, []string{"本堂を見", "学。", "庭園"}, Wrap("本堂を見学。\n庭園", 10, 40))
+	// 英単語は空白で改行する
+	assert.Equal(t, []string{"Kiyomizu", "temple main", "hall"}, Wrap("Kiyomizu temple main hall", 10, 60))
+	// 1単語が幅を超える場合は単語の途中で改行する
+	assert.Equal(t, []string{"abcd", "ef"}, Wrap("abcdef", 10, 20))
+	assert.Equal(t, []string{""}, Wrap("", 10, 20))
+}
+
+// TestEncode 相互参照表のオフセットが各オブジェクトを指し、テキストがUCS-2で出力されることを確認する
+func TestEncode(t *testing.T) {
+	doc := New(A4Width, A4Height)
+	doc.Title = "京都旅行"
+	doc.Created = time.Date(2026, 4, 1, 9, 0, 0, 0, time.FixedZone("JST", 9*60*60))
+	doc.AddPage().Text(50, 50, "清水寺 A", Style{Size: 12, Bold: true})
+	doc.AddPage().Line(0, 0, 100, 100, 1, 0.5)
+
+	var b bytes.Buffer
+	assert.NoError(t, doc.Encode(&b))
+	out := b.Bytes()
+
+	assert.True(t, bytes.HasPrefix(out, []byte("%PDF-1.4\n")))
+	assert.Contains(t, string(out), "/Count 2")
+	assert.Contains(t, string(out), "/Title <FEFF4EAC90FD65C5884C>")
+	assert.Contains(t, string(out), "/CreationDate (D:20260401090000+09'00')")
+
+	// startxref が相互参照表を指し、各オブジェクトのオフセットが正しいか確認する
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(out)
+	assert.NotNil(t, m)
+	xref, _ := strconv.Atoi(string(m[1]))
+	assert.True(t, bytes.HasPrefix(out[xref:], []byte("xref\n0 11\n")))
+	entries := strings.Split(string(out[xref:]), "\n")[3:13]
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(entry[:10])
+		assert.True(t, bytes.HasPrefix(out[offset:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))), entry)
+	}
+
+	// 1ページ目の内容を展開してテキストを確認する
+	start := bytes.Index(out, []byte("stream\n")) + len("stream\n")
+	end := bytes.Index(out, []byte("\nendstream"))
+	r, err := zlib.NewReader(bytes.NewReader(out[start:end]))
+	assert.NoError(t, err)
+	content, _ := io.ReadAll(r)
+	assert.Equal(t, "q 0 g 0 G 2 Tr 0.4 w BT /F1 12 Tf 50 791.89 Td <6E056C345BFA00200041> Tj ET Q\n", string(content))
+}
+
+// TestEncodeText 基本多言語面にない文字を置き換えることを確認する
+func TestEncodeText(t *testing.T) {
+	assert.Equal(t, "0041003F3042", encodeText("A😀あ"))
+}
@@ -0,0 +1,106 @@
+// Package realtime はプランごとのイベントを購読者に配信する。
+// プロセス内のHubのほか、Brokerを実装すれば外部のメッセージブローカーに差し替えられる。
+package realtime
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// イベントの種類
+const (
+	EventPlanUpdated    = "plan.updated"
+	EventPlanDeleted    = "plan.deleted"
+	EventItemCreated    = "item.created"
+	EventItemUpdated    = "item.updated"
+	EventItemDeleted    = "item.deleted"
+	EventItemsReordered = "items.reordered"
+	EventItemsImported  = "items.imported"
+	EventPresenceJoined = "presence.joined"
+	EventPresenceLeft   = "presence.left"
+)
+
+// subscriptionBuffer 購読者ごとに保持するイベント数（溢れた場合は古い購読者を待たずに破棄する）
+const subscriptionBuffer = 32
+
+// Event 購読者に配信するイベント
+type Event struct {
+	ID        uint64      `json:"id"`        // ハブ内で一意な連番
+	Type      string      `json:"type"`      // イベントの種類（例："item.created"）
+	Topic     string      `json:"topic"`     // 配信先（プランID）
+	UserID    uint        `json:"userId"`    // 操作したユーザーのID
+	Data      interface{} `json:"data"`      // イベントの内容
+	CreatedAt time.Time   `json:"createdAt"` // 発生日時
+}
+
+// Broker イベントの配信と購読
+type Broker interface {
+	// Publish トピックの購読者全員にイベントを配信する
+	Publish(topic string, event Event)
+	// Subscribe トピックを購読する。返された関数を呼ぶと購読を解除し、チャネルを閉じる
+	Subscribe(topic string) (<-chan Event, func())
+}
+
+// Hub プロセス内で動作するBroker
+type Hub struct {
+	mu     sync.RWMutex
+	topics map[string]map[chan Event]struct{}
+	nextID atomic.Uint64
+}
+
+// NewHub 空のハブを作成する
+func NewHub() *Hub {
+	return &Hub{topics: map[string]map[chan Event]struct{}{}}
+}
+
+// Publish トピックの購読者全員にイベントを配信する
+// 受信が追いつかない購読者の分は破棄し、配信元を待たせない
+func (h *Hub) Publish(topic string, event Event) {
+	event.ID = h.nextID.Add(1)
+	event.Topic = topic
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for ch := range h.topics[topic] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe トピックを購読する
+func (h *Hub) Subscribe(topic string) (<-chan Event, func()) {
+	ch := make(chan Event, subscriptionBuffer)
+
+	h.mu.Lock()
+	if h.topics[topic] == nil {
+		h.topics[topic] = map[chan Event]struct{}{}
+	}
+	h.topics[topic][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.topics[topic], ch)
+			if len(h.topics[topic]) == 0 {
+				delete(h.topics, topic)
+			}
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Subscribers トピックの購読者数を返す
+func (h *Hub) Subscribers(topic string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.topics[topic])
+}
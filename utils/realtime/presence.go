@@ -0,0 +1,60 @@
+package realtime
+
+import (
+	"sort"
+	"sync"
+)
+
+// Presence トピックごとに接続中のユーザーを数える
+// 同じユーザーが複数のタブから接続している場合は、最初の接続と最後の切断だけを入退室として扱う
+type Presence struct {
+	mu    sync.Mutex
+	users map[string]map[uint]int
+}
+
+// NewPresence 空のPresenceを作成する
+func NewPresence() *Presence {
+	return &Presence{users: map[string]map[uint]int{}}
+}
+
+// Join ユーザーの接続を記録し、そのユーザーの最初の接続であればtrueを返す
+func (p *Presence) Join(topic string, userID uint) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.users[topic] == nil {
+		p.users[topic] = map[uint]int{}
+	}
+	p.users[topic][userID]++
+	return p.users[topic][userID] == 1
+}
+
+// Leave ユーザーの切断を記録し、そのユーザーの最後の接続であればtrueを返す
+func (p *Presence) Leave(topic string, userID uint) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	count, ok := p.users[topic][userID]
+	if !ok {
+		return false
+	}
+	if count > 1 {
+		p.users[topic][userID] = count - 1
+		return false
+	}
+	delete(p.users[topic], userID)
+	if len(p.users[topic]) == 0 {
+		delete(p.users, topic)
+	}
+	return true
+}
+
+// Users トピックに接続中のユーザーIDを昇順で返す
+func (p *Presence) Users(topic string) []uint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	users := make([]uint, 0, len(p.users[topic]))
+	for id := range p.users[topic] {
+		users = append(users, id)
+	}
+	sort.Slice(users, func(a, b int) bool { return users[a] < users[b] })
+	return users
+}
@@ -0,0 +1,55 @@
+package realtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHub 購読者にだけイベントが配信され、解除後はチャネルが閉じられることを確認する
+func TestHub(t *testing.T) {
+	hub := NewHub()
+	kyoto, unsubscribe := hub.Subscribe("kyoto")
+	osaka, unsubscribeOsaka := hub.Subscribe("osaka")
+	defer unsubscribeOsaka()
+
+	hub.Publish("kyoto", Event{Type: EventItemCreated, UserID: 1, Data: "清水寺"})
+	hub.Publish("kyoto", Event{Type: EventItemDeleted, UserID: 1})
+
+	first := <-kyoto
+	assert.Equal(t, EventItemCreated, first.Type)
+	assert.Equal(t, "kyoto", first.Topic)
+	assert.Equal(t, "清水寺", first.Data)
+	assert.False(t, first.CreatedAt.IsZero())
+	second := <-kyoto
+	assert.Greater(t, second.ID, first.ID)
+	assert.Empty(t, osaka)
+
+	unsubscribe()
+	unsubscribe()
+	_, ok := <-kyoto
+	assert.False(t, ok)
+	assert.Equal(t, 0, hub.Subscribers("kyoto"))
+	assert.Equal(t, 1, hub.Subscribers("osaka"))
+
+	// 受信しない購読者がいても配信元は待たされない
+	for i := 0; i < subscriptionBuffer*2; i++ {
+		hub.Publish("osaka", Event{Type: EventPlanUpdated})
+	}
+	assert.Len(t, osaka, subscriptionBuffer)
+}
+
+// TestPresence 同じユーザーの複数の接続は1人として数えることを確認する
+func TestPresence(t *testing.T) {
+	p := NewPresence()
+	assert.True(t, p.Join("kyoto", 2))
+	assert.False(t, p.Join("kyoto", 2))
+	assert.True(t, p.Join("kyoto", 1))
+	assert.Equal(t, []uint{1, 2}, p.Users("kyoto"))
+
+	assert.False(t, p.Leave("kyoto", 2))
+	assert.True(t, p.Leave("kyoto", 2))
+	assert.False(t, p.Leave("kyoto", 2))
+	assert.Equal(t, []uint{1}, p.Users("kyoto"))
+	assert.Empty(t, p.Users("osaka"))
+}
@@ -0,0 +1,98 @@
+// Package render は行程表をGoのテンプレートでMarkdownとHTMLに変換する。
+// 日付や金額の表示形式は呼び出し側で整えた Itinerary を受け取り、テンプレートはレイアウトのみを扱う。
+package render
+
+import (
+	"embed"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates
+var templateFiles embed.FS
+
+var (
+	markdownTemplate = texttemplate.Must(texttemplate.New("plan.md.tmpl").
+				Funcs(texttemplate.FuncMap{"md": EscapeMarkdown, "indent": indent}).
+				ParseFS(templateFiles, "templates/plan.md.tmpl"))
+	htmlTemplate = htmltemplate.Must(htmltemplate.New("plan.html.tmpl").
+			Funcs(htmltemplate.FuncMap{"lines": lines}).
+			ParseFS(templateFiles, "templates/plan.html.tmpl"))
+)
+
+// Itinerary 行程表の表示内容（すべての値は表示用に整形済みの文字列）
+type Itinerary struct {
+	Title       string // プラン名
+	Summary     string // 旅行期間・タイムゾーンなどの1行
+	Description string // プランの説明
+	Category    string // カテゴリ名
+	Tags        []string
+	Days        []Day
+	TotalCost   string // 合計費用
+	ItemsCost   string // アイテムの費用の合計（合計費用と異なる場合のみ）
+	Lang        string // HTMLの言語（未設定の場合は "ja"）
+	Page        *Page  // 公開ページとして出力する場合のURL・リンクプレビューの情報
+}
+
+// Day 1日分の行程
+type Day struct {
+	Heading string // 見出し（例：「1日目 2026年4月1日（水）」）
+	Cost    string // その日の費用の合計（0円の場合は空）
+	Items   []Item
+}
+
+// Item 1つのアイテム
+type Item struct {
+	Time        string // 時刻（例：「09:00〜11:00」）
+	TimeZone    string // プランと異なるタイムゾーンの場合のみ
+	Title       string
+	Type        string // 種類の表示名
+	Location    string
+	Description string
+	Cost        string // 費用（0円の場合は空）
+	Notes       string
+}
+
+// Page 公開ページの情報（Open Graph のメタタグに使う）
+type Page struct {
+	URL         string // ページの正規URL
+	SiteName    string // サイト名
+	Description string // リンクプレビューの説明文
+	ImageURL    string // リンクプレビューの画像（任意）
+}
+
+// Markdown 行程表をMarkdownで書き込む
+func Markdown(w io.Writer, it *Itinerary) error {
+	return markdownTemplate.Execute(w, it)
+}
+
+// HTML 行程表を単体で表示できるHTMLページとして書き込む
+func HTML(w io.Writer, it *Itinerary) error {
+	if it.Lang == "" {
+		it.Lang = "ja"
+	}
+	return htmlTemplate.Execute(w, it)
+}
+
+// markdownEscaper Markdownの書式として解釈される記号をエスケープする
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "#", `\#`, "|", `\|`, "~", `\~`, "\r", "",
+)
+
+// EscapeMarkdown テキストをMarkdownの書式として解釈されないようにエスケープする
+func EscapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// indent 複数行のテキストの2行目以降を字下げし、行末に強制改行を入れる（リストの項目内で改行するため）
+func indent(spaces int, s string) string {
+	return strings.ReplaceAll(s, "\n", "  \n"+strings.Repeat(" ", spaces))
+}
+
+// lines テキストを行に分割する（HTMLで改行を <br> にするため）
+func lines(s string) []string {
+	return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+}
@@ -0,0 +1,89 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sampleItinerary() *Itinerary {
+	return &Itinerary{
+		Title:       "京都 *紅葉* 旅行",
+		Summary:     "期間: 2026年11月20日（金） 〜 2026年11月21日（土）（2日間）",
+		Description: "東山を歩く\n<script>alert(1)</script>",
+		Category:    "国内旅行",
+		Tags:        []string{"紅葉", "京都"},
+		Days: []Day{
+			{
+				Heading: "1日目 2026年11月20日（金）",
+				Cost:    "400円",
+				Items: []Item{{
+					Time:     "09:00〜11:00",
+					Title:    "清水寺",
+					Type:     "訪問",
+					Location: "京都市東山区",
+					Cost:     "400円",
+					Notes:    "朝一番に行く\n混雑に注意",
+				}},
+			},
+			{Heading: "2日目 2026年11月21日（土）", Items: []Item{}},
+		},
+		TotalCost: "12,000円",
+		ItemsCost: "400円",
+	}
+}
+
+// TestMarkdown 行程表がMarkdownのリストとして出力されることを確認する
+func TestMarkdown(t *testing.T) {
+	var b strings.Builder
+	assert.NoError(t, Markdown(&b, sampleItinerary()))
+	assert.Equal(t, "# 京都 \\*紅葉\\* 旅行\n"+
+		"\n"+
+		"期間: 2026年11月20日（金） 〜 2026年11月21日（土）（2日間）  \n"+
+		"カテゴリ: 国内旅行  \n"+
+		"タグ: \\#紅葉 \\#京都\n"+
+		"\n"+
+		"東山を歩く  \n"+
+		"\\<script\\>alert(1)\\</script\\>\n"+
+		"\n"+
+		"## 1日目 2026年11月20日（金）（400円）\n"+
+		"\n"+
+		"- **09:00〜11:00** 清水寺（訪問）\n"+
+		"  - 場所: 京都市東山区\n"+
+		"  - 費用: 400円\n"+
+		"  - メモ: 朝一番に行く  \n"+
+		"    混雑に注意\n"+
+		"\n"+
+		"## 2日目 2026年11月21日（土）\n"+
+		"\n"+
+		"予定はありません\n"+
+		"\n"+
+		"---\n"+
+		"\n"+
+		"**合計費用: 12,000円**（アイテムの費用の合計: 400円）\n", b.String())
+}
+
+// TestHTML HTMLがエスケープされ、公開ページの場合のみ Open Graph のメタタグが出力されることを確認する
+func TestHTML(t *testing.T) {
+	var b strings.Builder
+	assert.NoError(t, HTML(&b, sampleItinerary()))
+	html := b.String()
+	assert.Contains(t, html, `<html lang="ja">`)
+	assert.Contains(t, html, "東山を歩く<br>&lt;script&gt;alert(1)&lt;/script&gt;")
+	assert.Contains(t, html, "メモ: 朝一番に行く<br>混雑に注意")
+	assert.Contains(t, html, `<meta name="robots" content="noindex">`)
+	assert.NotContains(t, html, "og:title")
+
+	it := sampleItinerary()
+	it.Page = &Page{URL: "https://example.com/p/abc", SiteName: "旅のしおり", Description: `"東山"を歩く`}
+	b.Reset()
+	assert.NoError(t, HTML(&b, it))
+	html = b.String()
+	assert.Contains(t, html, `<meta property="og:title" content="京都 *紅葉* 旅行">`)
+	assert.Contains(t, html, `<meta property="og:url" content="https://example.com/p/abc">`)
+	assert.Contains(t, html, `<meta property="og:description" content="&#34;東山&#34;を歩く">`)
+	assert.Contains(t, html, `<meta name="twitter:card" content="summary">`)
+	assert.Contains(t, html, `<title>京都 *紅葉* 旅行 | 旅のしおり</title>`)
+	assert.NotContains(t, html, "noindex")
+}
@@ -0,0 +1,113 @@
+package search
+
+import (
+	"html"
+	"sort"
+	"strings"
+)
+
+const (
+	highlightOpen  = "<mark>"
+	highlightClose = "</mark>"
+	snippetContext = 20  // 一致箇所の前後に含める文字数
+	snippetMaxLen  = 120 // 抜粋の最大文字数（目安）
+)
+
+// Highlight テキスト中の検索語に一致する箇所を <mark> で囲んだ抜粋を返す
+// テキストはHTMLエスケープされる。一致しない場合は空文字を返す
+func Highlight(text, query string) string {
+	spans := matchSpans(text, QueryTokens(query))
+	if len(spans) == 0 {
+		return ""
+	}
+
+	runes := []rune(text)
+	start := max(spans[0][0]-snippetContext, 0)
+	end := min(spans[len(spans)-1][1]+snippetContext, start+snippetMaxLen, len(runes))
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, span := range spans {
+		if span[0] >= end {
+			break
+		}
+		// 抜粋の末尾にかかる一致箇所は途中で切らない
+		end = max(end, span[1])
+		b.WriteString(html.EscapeString(string(runes[pos:span[0]])))
+		b.WriteString(highlightOpen)
+		b.WriteString(html.EscapeString(string(runes[span[0]:span[1]])))
+		b.WriteString(highlightClose)
+		pos = span[1]
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:end])))
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+// Highlights ドキュメントの各フィールドについて一致箇所の抜粋をフィールド名ごとにまとめる
+func Highlights(doc Document, query string) map[string][]string {
+	result := map[string][]string{}
+	for _, field := range doc.Fields {
+		if snippet := Highlight(field.Text, query); snippet != "" {
+			result[field.Name] = append(result[field.Name], snippet)
+		}
+	}
+	return result
+}
+
+// matchSpans テキスト中でトークンに一致する範囲（元の文字列でのルーン位置）を重ならないようにまとめて返す
+func matchSpans(text string, tokens []string) [][2]int {
+	folded := fold(text)
+	if len(folded) == 0 {
+		return nil
+	}
+	chars := make([]rune, len(folded))
+	for i, f := range folded {
+		chars[i] = f.r
+	}
+	lastPos := folded[len(folded)-1].pos
+
+	var spans [][2]int
+	for _, token := range tokens {
+		t := []rune(token)
+		for i := 0; i+len(t) <= len(chars); i++ {
+			if string(chars[i:i+len(t)]) != token {
+				continue
+			}
+			// 英数字の単語は単語の途中に一致させない
+			if isWord(t[0]) && !wordBoundary(chars, i, i+len(t)) {
+				continue
+			}
+			end := lastPos + 1
+			if i+len(t) < len(folded) {
+				end = folded[i+len(t)].pos
+			}
+			spans = append(spans, [2]int{folded[i].pos, end})
+		}
+	}
+	if len(spans) == 0 {
+		return nil
+	}
+
+	sort.Slice(spans, func(a, b int) bool { return spans[a][0] < spans[b][0] })
+	merged := [][2]int{spans[0]}
+	for _, span := range spans[1:] {
+		last := &merged[len(merged)-1]
+		if span[0] <= last[1] {
+			last[1] = max(last[1], span[1])
+			continue
+		}
+		merged = append(merged, span)
+	}
+	return merged
+}
+
+// wordBoundary chars[start:end] の前後が単語の区切りかどうかを返す
+func wordBoundary(chars []rune, start, end int) bool {
+	return (start == 0 || !isWord(chars[start-1])) && (end == len(chars) || !isWord(chars[end]))
+}
@@ -0,0 +1,156 @@
+// Package search はプランの全文検索を提供する。
+// 日本語はバイグラムで分割するため、分かち書きのない文章でも部分一致で検索できる。
+package search
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// Field 検索対象のフィールド
+type Field struct {
+	Name   string  `json:"name"`   // フィールド名（例："title"、"items.location"）
+	Text   string  `json:"text"`   // 本文
+	Weight float64 `json:"weight"` // 関連度の重み
+}
+
+// Document 検索対象のドキュメント（1つのプラン）
+type Document struct {
+	ID     string  `json:"id"`
+	Fields []Field `json:"fields"`
+}
+
+// Hit 検索結果の1件
+type Hit struct {
+	ID         string              `json:"id"`
+	Score      float64             `json:"score"`      // 関連度
+	Highlights map[string][]string `json:"highlights"` // フィールド名ごとの一致箇所の抜粋
+}
+
+// Index 検索インデックス
+// MySQLの全文検索インデックスとプロセス内のインデックスを差し替えられるようにする
+type Index interface {
+	// Index ドキュメントを登録する（同じIDがあれば置き換える）
+	Index(doc Document) error
+	// Delete ドキュメントを削除する
+	Delete(id string) error
+	// Search 検索語に一致するドキュメントを関連度の高い順に最大limit件返す
+	Search(query string, limit int) ([]Hit, error)
+}
+
+// MemoryIndex プロセス内で動作する検索インデックス（テストや小規模な環境向け）
+type MemoryIndex struct {
+	mu   sync.RWMutex
+	docs map[string]*memoryDoc
+}
+
+type memoryDoc struct {
+	doc    Document
+	counts []map[string]int // フィールドごとのトークンの出現回数
+}
+
+// NewMemoryIndex 空のプロセス内インデックスを作成する
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{docs: map[string]*memoryDoc{}}
+}
+
+// Index ドキュメントを登録する
+func (m *MemoryIndex) Index(doc Document) error {
+	indexed := &memoryDoc{doc: doc, counts: make([]map[string]int, len(doc.Fields))}
+	for i, field := range doc.Fields {
+		indexed.counts[i] = map[string]int{}
+		for _, token := range Tokenize(field.Text) {
+			indexed.counts[i][token]++
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.docs[doc.ID] = indexed
+	return nil
+}
+
+// Delete ドキュメントを削除する
+func (m *MemoryIndex) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.docs, id)
+	return nil
+}
+
+// Search すべての検索トークンを含むドキュメントをBM25に近いスコアで並べて返す
+func (m *MemoryIndex) Search(query string, limit int) ([]Hit, error) {
+	tokens := QueryTokens(query)
+	if len(tokens) == 0 {
+		return []Hit{}, nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// トークンごとの出現ドキュメント数
+	df := map[string]int{}
+	for _, d := range m.docs {
+		for _, token := range tokens {
+			if d.contains(token) {
+				df[token]++
+			}
+		}
+	}
+
+	const k = 1.2
+	n := float64(len(m.docs))
+	hits := []Hit{}
+	for id, d := range m.docs {
+		score := 0.0
+		matched := true
+		for _, token := range tokens {
+			if !d.contains(token) {
+				matched = false
+				break
+			}
+			idf := math.Log(1 + (n-float64(df[token])+0.5)/(float64(df[token])+0.5))
+			for i, field := range d.doc.Fields {
+				if tf := float64(d.counts[i][token]); tf > 0 {
+					score += idf * field.weight() * tf * (k + 1) / (tf + k)
+				}
+			}
+		}
+		if matched {
+			hits = append(hits, Hit{ID: id, Score: score, Highlights: Highlights(d.doc, query)})
+		}
+	}
+
+	SortHits(hits)
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+func (d *memoryDoc) contains(token string) bool {
+	for _, counts := range d.counts {
+		if counts[token] > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (f Field) weight() float64 {
+	if f.Weight == 0 {
+		return 1
+	}
+	return f.Weight
+}
+
+// SortHits 検索結果を関連度の高い順に並べる（同じ関連度の場合はIDの順）
+func SortHits(hits []Hit) {
+	sort.Slice(hits, func(a, b int) bool {
+		if hits[a].Score != hits[b].Score {
+			return hits[a].Score > hits[b].Score
+		}
+		return hits[a].ID < hits[b].ID
+	})
+}
@@ -0,0 +1,65 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTokenize 英数字は単語、日本語はユニグラムとバイグラムに分割されることを確認する
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"清", "清水", "水", "水寺", "寺", "kyoto", "2026"}, Tokenize("清水寺 Ｋｙｏｔｏ・2026"))
+	assert.Equal(t, []string{"清水", "水寺", "観光"}, QueryTokens("清水寺　観光"))
+	assert.Equal(t, []string{"寺"}, QueryTokens("寺"))
+	assert.Empty(t, QueryTokens("・、。"))
+}
+
+func newTestIndex() *MemoryIndex {
+	index := NewMemoryIndex()
+	_ = index.Index(Document{ID: "kyoto", Fields: []Field{
+		{Name: "title", Text: "京都1日観光プラン", Weight: 3},
+		{Name: "items.title", Text: "清水寺観光", Weight: 2},
+		{Name: "items.location", Text: "京都市東山区清水", Weight: 1.5},
+	}})
+	_ = index.Index(Document{ID: "tokyo", Fields: []Field{
+		{Name: "title", Text: "東京週末プラン", Weight: 3},
+		{Name: "description", Text: "京都から新幹線で移動して観光", Weight: 1},
+	}})
+	_ = index.Index(Document{ID: "osaka", Fields: []Field{
+		{Name: "title", Text: "大阪食い倒れ", Weight: 3},
+	}})
+	return index
+}
+
+// TestMemoryIndexSearch 関連度の順に並び、すべての検索語を含むドキュメントだけが返ることを確認する
+func TestMemoryIndexSearch(t *testing.T) {
+	index := newTestIndex()
+
+	hits, err := index.Search("京都 観光", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(hits))
+	assert.Equal(t, "kyoto", hits[0].ID)
+	assert.Equal(t, "tokyo", hits[1].ID)
+	assert.Greater(t, hits[0].Score, hits[1].Score)
+
+	hits, _ = index.Search("清水寺", 10)
+	assert.Equal(t, 1, len(hits))
+	assert.Equal(t, []string{"<mark>清水寺</mark>観光"}, hits[0].Highlights["items.title"])
+
+	assert.NoError(t, index.Delete("kyoto"))
+	hits, _ = index.Search("清水寺", 10)
+	assert.Empty(t, hits)
+
+	hits, _ = index.Search("京都 観光", 1)
+	assert.Equal(t, 1, len(hits))
+}
+
+// TestHighlight 一致箇所が <mark> で囲まれ、本文がHTMLエスケープされることを確認する
+func TestHighlight(t *testing.T) {
+	assert.Equal(t, "<mark>Kyoto</mark> &lt;tour&gt;", Highlight("Kyoto <tour>", "kyoto"))
+	assert.Equal(t, "", Highlight("Kyotoite", "kyoto"))
+	assert.Equal(t, "ＪＲ<mark>京都</mark>駅", Highlight("ＪＲ京都駅", "京都"))
+
+	long := "あいうえおかきくけこさしすせそたちつてとなにぬねのはひふへほ清水寺"
+	assert.Equal(t, "…さしすせそたちつてとなにぬねのはひふへほ<mark>清水寺</mark>", Highlight(long, "清水寺"))
+}
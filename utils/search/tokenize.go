@@ -0,0 +1,120 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// foldedRune 正規化後の文字と元の文字列での位置
+type foldedRune struct {
+	r   rune
+	pos int // 元の文字列でのルーン位置
+}
+
+// fold 文字列を1文字ずつ正規化（NFKC・小文字化）し、元の位置を保ったまま返す
+func fold(text string) []foldedRune {
+	var out []foldedRune
+	pos := 0
+	for _, r := range text {
+		for _, n := range norm.NFKC.String(string(r)) {
+			out = append(out, foldedRune{r: unicode.ToLower(n), pos: pos})
+		}
+		pos++
+	}
+	return out
+}
+
+// isCJK 日本語・中国語の文字（漢字・ひらがな・カタカナ・長音記号）かどうかを返す
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) || r == 'ー' || r == '々'
+}
+
+// isWord 英数字など単語として扱う文字かどうかを返す
+func isWord(r rune) bool {
+	return !isCJK(r) && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// segment 連続する同じ種類の文字のまとまり
+type segment struct {
+	runes []rune
+	cjk   bool
+}
+
+// segments 文字列を英数字の単語と日本語の文字列に分割する（記号や空白は区切りとして捨てる）
+func segments(text string) []segment {
+	var segs []segment
+	var cur *segment
+	for _, f := range fold(text) {
+		var cjk bool
+		switch {
+		case isCJK(f.r):
+			cjk = true
+		case isWord(f.r):
+			cjk = false
+		default:
+			cur = nil
+			continue
+		}
+		if cur == nil || cur.cjk != cjk {
+			segs = append(segs, segment{cjk: cjk})
+			cur = &segs[len(segs)-1]
+		}
+		cur.runes = append(cur.runes, f.r)
+	}
+	return segs
+}
+
+// Tokenize 索引用に文字列をトークンに分割する
+// 英数字は単語単位、日本語は1文字（ユニグラム）と2文字（バイグラム）の両方を出力する
+func Tokenize(text string) []string {
+	var tokens []string
+	for _, seg := range segments(text) {
+		if !seg.cjk {
+			tokens = append(tokens, string(seg.runes))
+			continue
+		}
+		for i := range seg.runes {
+			tokens = append(tokens, string(seg.runes[i]))
+			if i+1 < len(seg.runes) {
+				tokens = append(tokens, string(seg.runes[i:i+2]))
+			}
+		}
+	}
+	return tokens
+}
+
+// QueryTokens 検索語をトークンに分割する（重複は取り除く）
+// 日本語は2文字ずつのバイグラムにし、1文字だけの場合はその文字をそのまま使う
+func QueryTokens(query string) []string {
+	seen := map[string]bool{}
+	var tokens []string
+	add := func(token string) {
+		if !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+	for _, seg := range segments(query) {
+		if !seg.cjk || len(seg.runes) == 1 {
+			add(string(seg.runes))
+			continue
+		}
+		for i := 0; i+1 < len(seg.runes); i++ {
+			add(string(seg.runes[i : i+2]))
+		}
+	}
+	return tokens
+}
+
+// Terms 検索語を空白で区切った語句として返す（MySQLのフレーズ検索などに使う）
+func Terms(query string) []string {
+	var terms []string
+	for _, term := range strings.Fields(norm.NFKC.String(query)) {
+		if len(QueryTokens(term)) > 0 {
+			terms = append(terms, strings.ToLower(term))
+		}
+	}
+	return terms
+}
@@ -19,6 +19,11 @@ const (
 	defaultKeyPath = "./keys"
 	privateKeyFile = "ed25519.key"
 	publicKeyFile  = "ed25519.pub"
+
+	// streamTicketPurpose イベント配信の接続用チケットであることを示す purpose クレームの値
+	streamTicketPurpose = "stream"
+	// StreamTicketLifespan イベント配信の接続用チケットの有効期間
+	StreamTicketLifespan = time.Minute
 )
 
 // 鍵の初期化と取得
@@ -137,6 +142,46 @@ func GenerateToken(id uint) (string, error) {
 	return token.SignedString(privateKey)
 }
 
+// GenerateStreamTicket プランのイベント配信に接続するための短期間だけ有効なチケットを生成する
+// EventSourceはヘッダーを指定できずURLに認証情報を含めることになるため、ログに残っても影響が小さいように
+// 認証トークンの代わりにプランを限定した有効期間の短いチケットを使う
+func GenerateStreamTicket(userID uint, planID string) (string, time.Time, error) {
+	privateKey, _, err := getKeys()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	expiresAt := time.Now().Add(StreamTicketLifespan)
+	claims := jwt.MapClaims{
+		"user_id": userID,
+		"plan_id": planID,
+		"purpose": streamTicketPurpose,
+		"exp":     expiresAt.Unix(),
+	}
+	ticket, err := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims).SignedString(privateKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return ticket, expiresAt, nil
+}
+
+// ParseStreamTicket イベント配信の接続用チケットを検証し、ユーザーIDを返す
+func ParseStreamTicket(ticket, planID string) (uint, error) {
+	token, err := parseToken(ticket)
+	if err != nil {
+		return 0, err
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid || claims["purpose"] != streamTicketPurpose || claims["plan_id"] != planID {
+		return 0, fmt.Errorf("invalid stream ticket")
+	}
+	userId, ok := claims["user_id"].(float64)
+	if !ok {
+		return 0, fmt.Errorf("invalid user_id in token")
+	}
+	return uint(userId), nil
+}
+
 // 以下は前回と同じ実装
 func extractTokenString(c *gin.Context) string {
 	bearToken := c.Request.Header.Get("Authorization")
@@ -186,6 +231,11 @@ func Valid(c *gin.Context) error {
 		return fmt.Errorf("invalid token")
 	}
 
+	// 用途を限定したチケットは認証トークンとして使えない
+	if claims, ok := token.Claims.(jwt.MapClaims); ok && claims["purpose"] != nil {
+		return fmt.Errorf("invalid token")
+	}
+
 	return nil
 }
 
@@ -202,7 +252,7 @@ func ExtractTokenId(c *gin.Context) (uint, error) {
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
-	if ok && token.Valid {
+	if ok && token.Valid && claims["purpose"] == nil {
 		userId, ok := claims["user_id"].(float64)
 		if !ok {
 			return 0, fmt.Errorf("invalid user_id in token")
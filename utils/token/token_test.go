@@ -0,0 +1,58 @@
+package token
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func testContext(target, authorization string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", target, nil)
+	if authorization != "" {
+		c.Request.Header.Set("Authorization", authorization)
+	}
+	return c
+}
+
+// TestTokenFromHeaderOnly 認証トークンはAuthorizationヘッダーからのみ受け付けることを確認する
+func TestTokenFromHeaderOnly(t *testing.T) {
+	t.Setenv("KEY_PATH", t.TempDir())
+	jwt, err := GenerateToken(7)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	id, err := ExtractTokenId(testContext("/api/plans", "Bearer "+jwt))
+	assert.NoError(t, err)
+	assert.Equal(t, uint(7), id)
+
+	assert.Error(t, Valid(testContext("/api/plans?token="+jwt, "")))
+}
+
+// TestStreamTicket チケットは指定したプランの配信にだけ使え、認証トークンとしては使えないことを確認する
+func TestStreamTicket(t *testing.T) {
+	t.Setenv("KEY_PATH", t.TempDir())
+	ticket, _, err := GenerateStreamTicket(7, "plan1")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	id, err := ParseStreamTicket(ticket, "plan1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(7), id)
+
+	_, err = ParseStreamTicket(ticket, "plan2")
+	assert.Error(t, err)
+
+	assert.Error(t, Valid(testContext("/api/plans", "Bearer "+ticket)))
+	_, err = ExtractTokenId(testContext("/api/plans", "Bearer "+ticket))
+	assert.Error(t, err)
+
+	// 通常の認証トークンはチケットとして使えない
+	jwt, _ := GenerateToken(7)
+	_, err = ParseStreamTicket(jwt, "plan1")
+	assert.Error(t, err)
+}